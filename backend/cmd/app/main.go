@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"time"
 
+	"github.com/rwrrioe/integrity/backend/internal/clients/mail"
 	grpc_client "github.com/rwrrioe/integrity/backend/internal/clients/sensors/grpc"
 	"github.com/rwrrioe/integrity/backend/internal/database"
 	"github.com/rwrrioe/integrity/backend/internal/repository"
 	"github.com/rwrrioe/integrity/backend/internal/service"
 	"github.com/rwrrioe/integrity/backend/internal/storage"
+	"github.com/rwrrioe/integrity/backend/internal/storage/blob"
 	"github.com/rwrrioe/integrity/backend/internal/transport/rest"
 	"github.com/rwrrioe/integrity/backend/internal/transport/ws/ws_hub"
 	"github.com/rwrrioe/integrity/backend/pkg/generators"
@@ -32,6 +36,7 @@ func main() {
 	redis := storage.NewRedisStorage("6379", time.Hour)
 
 	defectRepo := repository.NewDefectRepo(db)
+	auditService := service.NewAuditService(repository.NewAuditRepository(db))
 
 	predictionClient, err := grpc_client.NewClient(ctx, "9081", time.Hour, 10)
 	if err != nil {
@@ -40,25 +45,118 @@ func main() {
 
 	objRepo := repository.NewObjectRepository(db)
 	diagRepo := repository.NewDiagnosticRepository(db)
-	objService := service.NewObjectService(objRepo, defectRepo, diagRepo, predictionClient)
+	conditionService := service.NewConditionService(repository.NewConditionRepository(db), objRepo, repository.NewPipelineRepository(db), auditService)
+	objService := service.NewObjectService(objRepo, defectRepo, diagRepo, repository.NewPipelineRepository(db), conditionService, predictionClient, auditService)
 
-	defectService := service.NewDefectService(defectRepo, redis)
 	hmapService := service.NewHeatmapService(redis, defectRepo)
+	defectService := service.NewDefectService(defectRepo, redis, hmapService, auditService)
 
 	reportRepo := repository.NewReportRepository(db)
 	gen := generators.NewPDFGenerator()
 
 	cc, err := grpc.NewClient("9080", grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		fmt.Errorf("%s:%w", op, err)
+		log.Fatal(fmt.Errorf("%s:%w", op, err))
 	}
 	inspectionRepo := repository.NewDiagnosticRepository(db)
 	inspectionService := service.NewInspectionService(inspectionRepo, redis)
 	reportClient := v2.NewAnalyticsServiceClient(cc)
-	reportService := service.NewReportService(reportRepo, reportClient, gen)
-	parser := service.NewScvParser(*redis, db)
+	assessmentService := service.NewAssessmentService(defectRepo, objRepo, auditService)
+	gradeService := service.NewQualityGradeService(repository.NewQualityGradeRepository(db), hmapService, auditService)
+	searchService := service.NewSearchService(repository.NewSearchRepository(db))
+	pipelineService := service.NewPipelineService(repository.NewPipelineRepository(db), defectRepo, auditService)
+	districtService := service.NewDistrictService(repository.NewDistrictRepository(db), auditService)
+	restrictionService := service.NewRestrictionService(repository.NewRestrictionRepository(db), repository.NewPipelineRepository(db), defectRepo, auditService)
+	reportService := service.NewReportService(reportRepo, reportClient, gen, assessmentService, auditService)
+	parser := service.NewScvParser(*redis, db, conditionService, auditService)
 
-	h := rest.NewHandler(defectService, defectRepo, hmapService, objService, inspectionService, parser, redis, reportService, hub)
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Fatal("JWT_SECRET is not set")
+	}
+	userRepo := repository.NewUserRepository(db)
+	accessRepo := repository.NewAccessRepository(db)
+	accessService := service.NewAccessService(accessRepo, userRepo, repository.NewDistrictRepository(db), auditService, time.Minute)
+	if err := accessService.EnsureDefaults(ctx); err != nil {
+		log.Fatal(fmt.Errorf("%s:%w", op, err))
+	}
+
+	authService := service.NewAuthService(userRepo, redis, auditService, []byte(jwtSecret), 15*time.Minute, 30*24*time.Hour)
+	if email, password := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD"); email != "" && password != "" {
+		if err := authService.EnsureAdmin(ctx, email, password); err != nil {
+			log.Fatal(fmt.Errorf("%s:%w", op, err))
+		}
+	}
+
+	mailer, err := newMailer()
+	if err != nil {
+		log.Fatal(fmt.Errorf("%s:%w", op, err))
+	}
+	accountService := service.NewAccountService(userRepo, authService, mailer, auditService, os.Getenv("APP_URL"))
+
+	apiKeyService := service.NewApiKeyService(repository.NewApiKeyRepository(db), accessRepo, auditService)
+
+	workOrderRepo := repository.NewWorkOrderRepository(db)
+	workOrderService := service.NewWorkOrderService(workOrderRepo, auditService)
+	bulkService := service.NewBulkService(workOrderRepo, defectRepo, auditService)
+	workspaceService := service.NewWorkspaceService(userRepo, repository.NewEmployeeRepository(db), workOrderRepo)
+
+	blobStore, err := newBlobStore()
+	if err != nil {
+		log.Fatal(fmt.Errorf("%s:%w", op, err))
+	}
+	maxUpload, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_BYTES"), 10, 64)
+	if err != nil || maxUpload <= 0 {
+		maxUpload = 50 << 20
+	}
+	attachmentService := service.NewAttachmentService(repository.NewAttachmentRepository(db), blobStore, auditService, maxUpload)
+
+	commentService := service.NewCommentService(repository.NewCommentRepository(db), defectRepo, auditService)
+
+	h := rest.NewHandler(defectService, defectRepo, hmapService, objService, inspectionService, parser, redis, reportService, hub, authService, accessService, accountService, apiKeyService, auditService, workspaceService, workOrderService, attachmentService, commentService, assessmentService, restrictionService, gradeService, searchService, bulkService, pipelineService, districtService, conditionService)
 	engine := h.InitRoutes()
 	engine.Run()
 }
+
+// newMailer отправляет письма через SMTP, если задан SMTP_HOST, иначе складывает их в MAIL_OUTBOX_DIR
+func newMailer() (mail.Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "IntegrityOS <no-reply@integrityos.local>"
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		return mail.NewSMTPMailer(host, port, os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"), from), nil
+	}
+
+	dir := os.Getenv("MAIL_OUTBOX_DIR")
+	if dir == "" {
+		dir = "outbox"
+	}
+	return mail.NewOutboxMailer(dir, from)
+}
+
+// newBlobStore хранит вложения в S3-совместимом хранилище, если задан S3_BUCKET, иначе в ATTACHMENTS_DIR
+func newBlobStore() (blob.BlobStore, error) {
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := os.Getenv("S3_ENDPOINT")
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return blob.NewS3Store(endpoint, region, bucket, os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"))
+	}
+
+	dir := os.Getenv("ATTACHMENTS_DIR")
+	if dir == "" {
+		dir = "attachments"
+	}
+	return blob.NewLocalStore(dir)
+}
@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
 	"github.com/rwrrioe/integrity/backend/internal/repository/models"
 	"google.golang.org/genai"
 	"gorm.io/driver/postgres"
@@ -129,9 +131,10 @@ func DbConnect() (*gorm.DB, error) {
 	dbname := os.Getenv("DB_NAME")
 	port := os.Getenv("DB_PORT")
 
+	// порог нечеткого поиска задается на соединение: его читает оператор pg_trgm "<%" в repository.SearchRepository
 	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		host, user, password, dbname, port,
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable pg_trgm.word_similarity_threshold=%v",
+		host, user, password, dbname, port, repository.MinWordSimilarity,
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
@@ -143,12 +146,148 @@ func DbConnect() (*gorm.DB, error) {
 
 	log.Println("🔄 Running Migrations...")
 	err = db.AutoMigrate(
-		&models.Pipeline{}, &models.ObjectType{}, &models.Method{},
+		&models.Pipeline{}, &models.District{}, &models.ObjectType{}, &models.Method{},
 		&models.DefectType{}, &models.QualityGrade{}, &models.SensorType{}, &models.InspectionType{},
 		&models.Object{}, &models.Employee{},
 		&models.Diagnostic{}, &models.Defect{}, &models.Sensor{}, &models.Inspection{}, &models.ProbabilityHistory{},
+		&models.User{}, &models.Role{}, &models.Permission{}, &models.UserToken{}, &models.ApiKey{},
+		&models.AuditLog{}, &models.DefectStatusHistory{}, &models.WorkOrder{}, &models.Attachment{},
+		&models.DefectComment{}, &models.DefectMeasurement{}, &models.PressureRestriction{},
+		&models.ConditionSettings{}, &models.ConditionHistory{},
 	)
-	return db, err
+	if err != nil {
+		return nil, err
+	}
+
+	if err := protectAuditLog(db); err != nil {
+		return nil, err
+	}
+	if err := migrateDefectStatuses(db); err != nil {
+		return nil, err
+	}
+	if err := backfillDefectMeasurements(db); err != nil {
+		return nil, err
+	}
+	if err := seedQualityGrades(db); err != nil {
+		return nil, err
+	}
+	if err := ensureSearchIndexes(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrateDefectStatuses переводит статусы, записанные до появления жизненного цикла, в новые
+func migrateDefectStatuses(db *gorm.DB) error {
+	for legacy, status := range entities.LegacyDefectStatuses {
+		if err := db.Model(&models.Defect{}).
+			Where("status = ? OR (? = '' AND status IS NULL)", legacy, legacy).
+			Update("status", status).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillDefectMeasurements заводит первый замер для дефектов, созданных до появления треков
+func backfillDefectMeasurements(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO defect_measurements (defect_id, measured_at, depth, length, width, source, created_at)
+		SELECT d.defect_id, d.date, d.depth, d.length, d.width, ?, NOW()
+		FROM defects d
+		WHERE NOT EXISTS (SELECT 1 FROM defect_measurements m WHERE m.defect_id = d.defect_id)`,
+		entities.MeasurementImport).Error
+}
+
+// seedQualityGrades приводит справочник оценок к каталогу. Оценки, заведенные импортом
+// под старыми названиями ("требует мер"), получают код каталога; ранг, вес, цвет и подписи
+// заполняются, только пока оценка не настроена (rank = 0), чтобы не затирать правки из API.
+func seedQualityGrades(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, g := range entities.DefaultQualityGrades {
+			var rows []models.QualityGrade
+			if err := tx.Where("REGEXP_REPLACE(LOWER(TRIM(quality_grade)), '\\s+', '_', 'g') = ?", g.Code).
+				Find(&rows).Error; err != nil {
+				return err
+			}
+
+			catalog := map[string]interface{}{
+				"quality_grade": g.Code,
+				"rank":          g.Rank,
+				"heat_weight":   g.HeatWeight,
+				"color":         g.Color,
+				"label_ru":      g.Labels.Ru,
+				"label_en":      g.Labels.En,
+				"critical":      g.Critical,
+				"priority":      g.Priority,
+			}
+			if len(rows) == 0 {
+				if err := tx.Model(&models.QualityGrade{}).Create(catalog).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			for _, row := range rows {
+				fields := map[string]interface{}{"quality_grade": g.Code}
+				if row.Rank == 0 {
+					fields = catalog
+				}
+				if err := tx.Model(&models.QualityGrade{}).
+					Where("quality_grade_id = ?", row.QualityGradeId).
+					Updates(fields).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ensureSearchIndexes включает pg_trgm и создает GIN-индексы глобального поиска: полнотекстовые (_fts)
+// и триграммные (_trgm). Выражения индексов должны совпадать с условиями repository.SearchRepository
+// (repository.tsDoc и "? <% expr"), иначе планировщик их не возьмет.
+func ensureSearchIndexes(db *gorm.DB) error {
+	return db.Exec(`
+		CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+		CREATE INDEX IF NOT EXISTS idx_defects_description_fts ON defects USING GIN (
+			(to_tsvector('russian', coalesce(description, '')) || to_tsvector('english', coalesce(description, '')))
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_objects_search_fts ON objects USING GIN (
+			(to_tsvector('russian', coalesce(object_name || ' ' || coalesce(material, ''), ''))
+				|| to_tsvector('english', coalesce(object_name || ' ' || coalesce(material, ''), '')))
+		);
+		CREATE INDEX IF NOT EXISTS idx_objects_name_trgm ON objects USING GIN (object_name gin_trgm_ops);
+		CREATE INDEX IF NOT EXISTS idx_objects_material_trgm ON objects USING GIN (coalesce(material, '') gin_trgm_ops);
+
+		CREATE INDEX IF NOT EXISTS idx_pipelines_name_fts ON pipelines USING GIN (
+			(to_tsvector('russian', coalesce(name, '')) || to_tsvector('english', coalesce(name, '')))
+		);
+		CREATE INDEX IF NOT EXISTS idx_pipelines_name_trgm ON pipelines USING GIN (name gin_trgm_ops);
+
+		CREATE INDEX IF NOT EXISTS idx_defect_types_name_fts ON defect_types USING GIN (
+			(to_tsvector('russian', coalesce(name, '')) || to_tsvector('english', coalesce(name, '')))
+		);
+		CREATE INDEX IF NOT EXISTS idx_defect_types_name_trgm ON defect_types USING GIN (coalesce(name, '') gin_trgm_ops);
+	`).Error
+}
+
+// protectAuditLog делает журнал аудита append-only на уровне БД
+func protectAuditLog(db *gorm.DB) error {
+	return db.Exec(`
+		CREATE OR REPLACE FUNCTION audit_logs_immutable() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS audit_logs_no_modify ON audit_logs;
+		CREATE TRIGGER audit_logs_no_modify
+			BEFORE UPDATE OR DELETE ON audit_logs
+			FOR EACH ROW EXECUTE FUNCTION audit_logs_immutable();
+	`).Error
 }
 
 func GenerateContent(ctx context.Context, db *gorm.DB, client genai.Client) error {
@@ -389,7 +528,7 @@ func processChunk(ctx context.Context, db *gorm.DB, client genai.Client, cache *
 	for _, d := range aiResp.Defects {
 		if rid, ok := tempIdToRealId[d.ObjectTempID]; ok {
 			dtID, _ := getDefectTypeID(d.DefectType)
-			gID, _ := getGradeID(d.Grade)
+			gID, _ := getGradeID(entities.NormalizeGradeCode(d.Grade))
 			dt, _ := time.Parse(time.RFC3339, d.Date)
 			parent := realIdToObject[rid]
 			wkt := fmt.Sprintf("SRID=4326;POINT(%f %f)", parent.Lon, parent.Lat)
@@ -400,7 +539,7 @@ func processChunk(ctx context.Context, db *gorm.DB, client genai.Client, cache *
 			defectBatch = append(defectBatch, models.Defect{
 				ObjectId: rid, DefectTypeId: dtID, QualityGradeId: gID,
 				EmployeeId:  randomEmpID, // Привязываем к рандомному сотруднику
-				Description: d.Description, Status: "Open", Date: dt,
+				Description: d.Description, Status: entities.DefectNew, Date: dt,
 				Width: d.Width, Length: d.Length, Depth: d.Depth, Vibration: d.Vibration,
 				Lat: parent.Lat, Lon: parent.Lon, Location: wkt,
 			})
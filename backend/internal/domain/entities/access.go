@@ -0,0 +1,162 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	RoleAdmin           = "admin"
+	RoleChiefEngineer   = "chief_engineer"
+	RoleInspector       = "inspector"
+	RoleFieldTechnician = "field_technician"
+	RoleViewer          = "viewer"
+)
+
+const (
+	PermDashboardRead = "dashboard:read"
+	PermDefectsRead   = "defects:read"
+	PermDefectsWrite  = "defects:write"
+	PermObjectsRead   = "objects:read"
+	PermObjectsWrite  = "objects:write"
+	PermPipelinesRead = "pipelines:read"
+	PermReportsRead   = "reports:read"
+	PermReportsExport = "reports:export"
+	PermImportRun     = "import:run"
+	PermAIRun         = "ai:run"
+	PermUsersManage   = "users:manage"
+	PermRolesManage   = "roles:manage"
+	PermDataAll       = "data:all"
+	PermApiKeysManage = "apikeys:manage"
+	PermAuditRead     = "audit:read"
+
+	PermWorkOrdersRead     = "workorders:read"
+	PermWorkOrdersManage   = "workorders:manage"
+	PermWorkOrdersExecute  = "workorders:execute"
+	PermAttachmentsWrite   = "attachments:write"
+	PermCommentsModerate   = "comments:moderate"
+	PermRestrictionsManage = "restrictions:manage"
+	PermCatalogManage      = "catalog:manage"
+	PermPipelinesManage    = "pipelines:manage"
+	PermDistrictsManage    = "districts:manage"
+)
+
+type Role struct {
+	RoleId      uint     `json:"role_id"`
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Permissions []string `json:"permissions"`
+}
+
+type PermissionInfo struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// Permissions — справочник прав, который засевается в БД при старте
+var Permissions = []PermissionInfo{
+	{PermDashboardRead, "Просмотр дашборда"},
+	{PermDefectsRead, "Просмотр дефектов и тепловой карты"},
+	{PermDefectsWrite, "Создание и изменение дефектов"},
+	{PermObjectsRead, "Просмотр объектов"},
+	{PermObjectsWrite, "Создание и изменение объектов"},
+	{PermPipelinesRead, "Просмотр трубопроводов"},
+	{PermReportsRead, "Просмотр отчетов"},
+	{PermReportsExport, "Выгрузка отчетов в PDF"},
+	{PermImportRun, "Импорт CSV"},
+	{PermAIRun, "Запуск AI-прогноза"},
+	{PermUsersManage, "Управление пользователями"},
+	{PermRolesManage, "Управление ролями и правами"},
+	{PermDataAll, "Доступ к данным всех трубопроводов и регионов"},
+	{PermApiKeysManage, "Управление API-ключами"},
+	{PermAuditRead, "Просмотр журнала аудита"},
+	{PermWorkOrdersRead, "Просмотр нарядов на ремонт"},
+	{PermWorkOrdersManage, "Создание, назначение и отмена нарядов"},
+	{PermWorkOrdersExecute, "Начало и завершение работ по наряду"},
+	{PermAttachmentsWrite, "Загрузка и удаление вложений (фото, сканы, PDF)"},
+	{PermCommentsModerate, "Изменение и удаление чужих комментариев"},
+	{PermRestrictionsManage, "Проектное МДРД трубопроводов, введение и снятие ограничений давления"},
+	{PermCatalogManage, "Настройка справочников (каталог оценок качества)"},
+	{PermPipelinesManage, "Создание, изменение и архивирование трубопроводов"},
+	{PermDistrictsManage, "Загрузка границ районов и областей"},
+}
+
+// DefaultRoles — матрица прав по умолчанию. Администратор получает все права автоматически.
+var DefaultRoles = []Role{
+	{Name: RoleAdmin, Title: "Администратор"},
+	{Name: RoleChiefEngineer, Title: "Главный инженер", Permissions: []string{
+		PermDashboardRead, PermDefectsRead, PermDefectsWrite, PermObjectsRead, PermObjectsWrite,
+		PermPipelinesRead, PermReportsRead, PermReportsExport, PermImportRun, PermAIRun, PermDataAll,
+		PermWorkOrdersRead, PermWorkOrdersManage, PermWorkOrdersExecute, PermAttachmentsWrite,
+		PermCommentsModerate, PermRestrictionsManage, PermPipelinesManage, PermDistrictsManage,
+	}},
+	{Name: RoleInspector, Title: "Инспектор", Permissions: []string{
+		PermDashboardRead, PermDefectsRead, PermDefectsWrite, PermObjectsRead,
+		PermPipelinesRead, PermReportsRead, PermReportsExport, PermImportRun,
+		PermWorkOrdersRead, PermWorkOrdersManage, PermWorkOrdersExecute, PermAttachmentsWrite,
+	}},
+	{Name: RoleFieldTechnician, Title: "Полевой техник", Permissions: []string{
+		PermDashboardRead, PermDefectsRead, PermDefectsWrite, PermObjectsRead,
+		PermPipelinesRead, PermReportsRead, PermWorkOrdersRead, PermWorkOrdersExecute, PermAttachmentsWrite,
+	}},
+	{Name: RoleViewer, Title: "Наблюдатель", Permissions: []string{
+		PermDashboardRead, PermDefectsRead, PermObjectsRead, PermPipelinesRead, PermReportsRead,
+		PermWorkOrdersRead,
+	}},
+}
+
+// AccessScope — какие данные доступны пользователю. Репозитории читают его из контекста
+// и добавляют условие на objects.pipeline_id / objects.district_id.
+type AccessScope struct {
+	All         bool   `json:"all"`
+	PipelineIds []uint `json:"pipeline_ids"`
+	DistrictIds []uint `json:"district_ids"`
+}
+
+// CacheKey — стабильный ключ для кэшей, зависящих от области видимости
+func (s AccessScope) CacheKey() string {
+	if s.All {
+		return "all"
+	}
+	return fmt.Sprintf("p%s:d%s", joinIds(s.PipelineIds), joinIds(s.DistrictIds))
+}
+
+func joinIds(ids []uint) string {
+	sorted := append([]uint(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = fmt.Sprint(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+type scopeCtxKey struct{}
+
+func ContextWithScope(ctx context.Context, scope AccessScope) context.Context {
+	return context.WithValue(ctx, scopeCtxKey{}, scope)
+}
+
+// ScopeFromContext возвращает область видимости запроса. Если ее нет, не видно ничего:
+// системные задачи (импорт, пересчет индекса) явно кладут в контекст AccessScope{All: true}.
+func ScopeFromContext(ctx context.Context) AccessScope {
+	scope, _ := ctx.Value(scopeCtxKey{}).(AccessScope)
+	return scope
+}
+
+type UserAssignments struct {
+	PipelineIds []uint `json:"pipeline_ids"`
+	DistrictIds []uint `json:"district_ids"`
+}
+
+type District struct {
+	DistrictId  uint   `json:"district_id"`
+	Name        string `json:"name"`
+	Code        string `json:"code"`
+	Level       string `json:"level,omitempty"`
+	ParentId    *uint  `json:"parent_id,omitempty"`
+	HasBoundary bool   `json:"has_boundary"`
+}
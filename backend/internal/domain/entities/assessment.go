@@ -0,0 +1,43 @@
+package entities
+
+import "strings"
+
+// MetalLossDefectTypes — названия типов дефектов потери металла (в нижнем регистре).
+// B31G применим только к ним: трещины, вмятины и прочие дефекты этой методикой не оцениваются.
+var MetalLossDefectTypes = []string{"коррозия", "потеря металла", "эрозия", "corrosion", "metal loss", "erosion"}
+
+// IsMetalLoss сообщает, относится ли тип дефекта к потере металла
+func IsMetalLoss(defectType string) bool {
+	name := strings.ToLower(strings.TrimSpace(defectType))
+	for _, t := range MetalLossDefectTypes {
+		if name == t {
+			return true
+		}
+	}
+	return false
+}
+
+// PipeProperties — параметры трубы объекта для расчета остаточной прочности (мм, МПа)
+type PipeProperties struct {
+	OuterDiameter float64 `json:"outer_diameter"`
+	WallThickness float64 `json:"wall_thickness"`
+	Smys          float64 `json:"smys"`
+	Maop          float64 `json:"maop"`
+}
+
+// DefectAssessment — результат расчета допустимости дефекта потери металла (ASME B31G / Modified B31G)
+type DefectAssessment struct {
+	DefectId              uint           `json:"defect_id"`
+	ObjectId              uint           `json:"object_id"`
+	Method                string         `json:"method"`
+	Depth                 float64        `json:"depth"`
+	Length                float64        `json:"length"`
+	Pipe                  PipeProperties `json:"pipe"`
+	DepthRatio            float64        `json:"depth_ratio"`
+	FlowStress            float64        `json:"flow_stress"`
+	FoliasFactor          float64        `json:"folias_factor"`
+	FailurePressure       float64        `json:"failure_pressure"`
+	SafePressure          float64        `json:"safe_pressure"`
+	EstimatedRepairFactor float64        `json:"estimated_repair_factor"`
+	Verdict               string         `json:"verdict"`
+}
@@ -0,0 +1,25 @@
+package entities
+
+import "testing"
+
+func TestIsMetalLoss(t *testing.T) {
+	tests := []struct {
+		defectType string
+		want       bool
+	}{
+		{"Коррозия", true},
+		{"  коррозия ", true},
+		{"Потеря металла", true},
+		{"Corrosion", true},
+		{"Трещина", false},
+		{"Вмятина", false},
+		{"General", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsMetalLoss(tt.defectType); got != tt.want {
+			t.Errorf("IsMetalLoss(%q) = %v, want %v", tt.defectType, got, tt.want)
+		}
+	}
+}
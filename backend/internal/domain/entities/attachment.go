@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+// Сущности, к которым можно прикладывать файлы
+const (
+	AttachmentOwnerDefect     = "defect"
+	AttachmentOwnerObject     = "object"
+	AttachmentOwnerDiagnostic = "diagnostic"
+)
+
+type Attachment struct {
+	AttachmentId     uint      `json:"attachment_id"`
+	OwnerType        string    `json:"owner_type"`
+	OwnerId          uint      `json:"owner_id"`
+	FileName         string    `json:"file_name"`
+	ContentType      string    `json:"content_type"`
+	Size             int64     `json:"size"`
+	Checksum         string    `json:"checksum"`
+	HasThumbnail     bool      `json:"has_thumbnail"`
+	UploadedBy       *uint     `json:"uploaded_by"`
+	UploadedByApiKey *uint     `json:"uploaded_by_api_key"`
+	CreatedAt        time.Time `json:"created_at"`
+}
@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// Действия, которые пишутся в журнал аудита
+const (
+	AuditImportObjects      = "import.objects"
+	AuditImportDiagnostics  = "import.diagnostics"
+	AuditAIPrediction       = "ai.prediction"
+	AuditEmployeesAssign    = "employees.assign"
+	AuditDefectTransition   = "defect.transition"
+	AuditDefectCreate       = "defect.create"
+	AuditDefectUpdate       = "defect.update"
+	AuditDefectDelete       = "defect.delete"
+	AuditDefectMeasure      = "defect.measure"
+	AuditDefectBulk         = "defect.bulk"
+	AuditWorkOrderCreate    = "workorder.create"
+	AuditWorkOrderAssign    = "workorder.assign"
+	AuditWorkOrderStart     = "workorder.start"
+	AuditWorkOrderComplete  = "workorder.complete"
+	AuditWorkOrderCancel    = "workorder.cancel"
+	AuditWorkOrderAttach    = "workorder.attach"
+	AuditObjectPipe         = "object.pipe"
+	AuditObjectCreate       = "object.create"
+	AuditObjectUpdate       = "object.update"
+	AuditObjectDelete       = "object.delete"
+	AuditPipelineMaop       = "pipeline.maop"
+	AuditPipelineCreate     = "pipeline.create"
+	AuditPipelineUpdate     = "pipeline.update"
+	AuditPipelineArchive    = "pipeline.archive"
+	AuditPipelineRestore    = "pipeline.restore"
+	AuditPipelineRoute      = "pipeline.route"
+	AuditRestrictionImpose  = "restriction.impose"
+	AuditRestrictionLift    = "restriction.lift"
+	AuditAttachmentUpload   = "attachment.upload"
+	AuditAttachmentDelete   = "attachment.delete"
+	AuditCommentCreate      = "comment.create"
+	AuditCommentUpdate      = "comment.update"
+	AuditCommentDelete      = "comment.delete"
+	AuditReportGenerate     = "report.generate"
+	AuditUserRegister       = "user.register"
+	AuditUserRole           = "user.role"
+	AuditUserAssignments    = "user.assignments"
+	AuditUserEmployee       = "user.employee"
+	AuditUserSessionsKill   = "user.sessions_revoke"
+	AuditUserPasswordReset  = "user.password_reset"
+	AuditRolePermissions    = "role.permissions"
+	AuditDistrictCreate     = "district.create"
+	AuditDistrictImport     = "district.import"
+	AuditQualityGradeUpdate = "quality_grade.update"
+	AuditConditionSettings  = "condition.settings"
+	AuditApiKeyCreate       = "apikey.create"
+	AuditApiKeyRotate       = "apikey.rotate"
+	AuditApiKeyRevoke       = "apikey.revoke"
+)
+
+type AuditEntry struct {
+	AuditId       uint                   `json:"audit_id"`
+	ActorUserId   *uint                  `json:"actor_user_id"`
+	ActorApiKeyId *uint                  `json:"actor_api_key_id"`
+	Action        string                 `json:"action"`
+	EntityType    string                 `json:"entity_type"`
+	EntityId      string                 `json:"entity_id"`
+	Before        map[string]interface{} `json:"before,omitempty"`
+	After         map[string]interface{} `json:"after,omitempty"`
+	Ip            string                 `json:"ip"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+type AuditFilter struct {
+	ActorUserId   uint
+	ActorApiKeyId uint
+	Action        string
+	EntityType    string
+	EntityId      string
+	DateFrom      time.Time
+	DateTo        time.Time
+	Page          int
+	Limit         int
+}
+
+// Actor — кто выполняет запрос. Кладется в контекст транспортным слоем, читается сервисами при записи аудита.
+type Actor struct {
+	UserId   uint
+	ApiKeyId uint
+	Ip       string
+}
+
+type actorCtxKey struct{}
+
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// ActorFromContext возвращает пустого актора для системных действий (сидеры, фоновые задачи)
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorCtxKey{}).(Actor)
+	return actor
+}
@@ -0,0 +1,49 @@
+package entities
+
+// Массовые операции над дефектами
+const (
+	BulkTransition      = "transition"
+	BulkAssign          = "assign"
+	BulkSetGrade        = "set_grade"
+	BulkAttachWorkOrder = "attach_work_order"
+)
+
+var BulkOperations = []string{BulkTransition, BulkAssign, BulkSetGrade, BulkAttachWorkOrder}
+
+// BulkMaxItems — сколько дефектов можно изменить одним запросом
+const BulkMaxItems = 500
+
+// BulkDefectRequest — дефекты задаются либо списком id, либо фильтром списка дефектов.
+// Параметры операции: transition — Transition, assign — EmployeeIds,
+// set_grade — QualityGradeId, attach_work_order — WorkOrderId.
+type BulkDefectRequest struct {
+	Operation string        `json:"operation"`
+	DefectIds []uint        `json:"defect_ids"`
+	Filter    *DefectFilter `json:"filter"`
+	DryRun    bool          `json:"dry_run"`
+
+	Transition     *TransitionRequest `json:"transition"`
+	EmployeeIds    []uint             `json:"employee_ids"`
+	QualityGradeId uint               `json:"quality_grade_id"`
+	WorkOrderId    uint               `json:"work_order_id"`
+}
+
+type BulkItemResult struct {
+	DefectId   uint   `json:"defect_id"`
+	Ok         bool   `json:"ok"`
+	FromStatus string `json:"from_status,omitempty"`
+	ToStatus   string `json:"to_status,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BulkResult — отчет по каждому дефекту. Операция применяется целиком или не применяется вовсе:
+// Applied == false при dry-run или если хотя бы один дефект не прошел проверку.
+type BulkResult struct {
+	Operation string           `json:"operation"`
+	DryRun    bool             `json:"dry_run"`
+	Applied   bool             `json:"applied"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
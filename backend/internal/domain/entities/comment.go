@@ -0,0 +1,44 @@
+package entities
+
+import "time"
+
+// UserRef — краткая ссылка на пользователя (автор комментария, упоминание)
+type UserRef struct {
+	UserId uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+}
+
+// Comment — комментарий к дефекту. Ветки двухуровневые: ответ на ответ прикрепляется к корню ветки.
+// Удаленный комментарий остается в ветке без текста, чтобы не разрывать обсуждение.
+type Comment struct {
+	CommentId  uint       `json:"comment_id"`
+	DefectId   uint       `json:"defect_id"`
+	ParentId   *uint      `json:"parent_id"`
+	Author     UserRef    `json:"author"`
+	Body       string     `json:"body"`
+	IsDecision bool       `json:"is_decision"`
+	Mentions   []UserRef  `json:"mentions"`
+	Deleted    bool       `json:"deleted"`
+	EditedAt   *time.Time `json:"edited_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Replies    []Comment  `json:"replies,omitempty"`
+}
+
+type CommentInput struct {
+	Body       string `json:"body"`
+	ParentId   *uint  `json:"parent_id"`
+	IsDecision bool   `json:"is_decision"`
+}
+
+// CommentEvent — сообщение в топик дефекта в ws_hub
+type CommentEvent struct {
+	Type    string  `json:"type"`
+	Comment Comment `json:"comment"`
+}
+
+const (
+	CommentCreated = "comment.created"
+	CommentUpdated = "comment.updated"
+	CommentDeleted = "comment.deleted"
+)
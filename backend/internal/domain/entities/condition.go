@@ -0,0 +1,101 @@
+package entities
+
+import "time"
+
+// Сущности, для которых считается индекс состояния
+const (
+	ConditionEntityPipeline = "pipeline"
+	ConditionEntityObject   = "object"
+)
+
+// Полосы индекса состояния для сводок: от ConditionGoodFrom — хорошее, ниже ConditionFairFrom — плохое
+const (
+	ConditionGood = "good"
+	ConditionFair = "fair"
+	ConditionPoor = "poor"
+
+	ConditionGoodFrom = 70.0
+	ConditionFairFrom = 40.0
+)
+
+func ConditionBand(value float64) string {
+	switch {
+	case value >= ConditionGoodFrom:
+		return ConditionGood
+	case value >= ConditionFairFrom:
+		return ConditionFair
+	}
+	return ConditionPoor
+}
+
+// ConditionWeights — относительные веса составляющих; нормируются на сумму, поэтому масштаб не важен
+type ConditionWeights struct {
+	Severity   float64 `json:"severity"`
+	Density    float64 `json:"density"`
+	Risk       float64 `json:"risk"`
+	Inspection float64 `json:"inspection"`
+	Sensor     float64 `json:"sensor"`
+}
+
+type ConditionSettings struct {
+	Weights              ConditionWeights `json:"weights"`
+	DensityPerObject     float64          `json:"density_per_object"`
+	DensityPerKm         float64          `json:"density_per_km"`
+	InspectionMaxAgeDays int              `json:"inspection_max_age_days"`
+	VibrationLimit       float64          `json:"vibration_limit"`
+	UpdatedAt            *time.Time       `json:"updated_at,omitempty"`
+}
+
+var DefaultConditionSettings = ConditionSettings{
+	Weights: ConditionWeights{
+		Severity:   0.35,
+		Density:    0.15,
+		Risk:       0.25,
+		Inspection: 0.15,
+		Sensor:     0.10,
+	},
+	DensityPerObject:     10,
+	DensityPerKm:         2,
+	InspectionMaxAgeDays: 730,
+	VibrationLimit:       10,
+}
+
+// ConditionComponents — составляющие индекса, каждая от 0 (плохо) до 1 (хорошо).
+// Risk == nil — предсказаний не было, составляющая в индексе не участвует.
+type ConditionComponents struct {
+	Severity   float64  `json:"severity"`
+	Density    float64  `json:"density"`
+	Risk       *float64 `json:"risk"`
+	Inspection float64  `json:"inspection"`
+	Sensor     float64  `json:"sensor"`
+}
+
+type ConditionPoint struct {
+	Condition  float64             `json:"condition"`
+	Band       string              `json:"band"`
+	Components ConditionComponents `json:"components"`
+	ComputedAt time.Time           `json:"computed_at"`
+}
+
+// ConditionReport — текущий индекс и его история, новые записи первыми; Current == nil — еще не считался
+type ConditionReport struct {
+	EntityType string           `json:"entity_type"`
+	EntityId   uint             `json:"entity_id"`
+	Current    *ConditionPoint  `json:"current"`
+	History    []ConditionPoint `json:"history"`
+}
+
+type PipelineCondition struct {
+	PipelineId  uint      `json:"pipeline_id"`
+	Name        string    `json:"name"`
+	Condition   float64   `json:"condition"`
+	Band        string    `json:"band"`
+	ConditionAt time.Time `json:"condition_at"`
+}
+
+// ConditionOverview — сводка для дашборда по трубопроводам в области видимости
+type ConditionOverview struct {
+	Average        *float64            `json:"average"`
+	Distribution   map[string]int64    `json:"distribution"`
+	WorstPipelines []PipelineCondition `json:"worst_pipelines"`
+}
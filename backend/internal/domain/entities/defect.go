@@ -11,6 +11,7 @@ type Defect struct {
 	ObjectName    string
 	Description   string
 	QualityGrade  string
+	Status        string
 	Lat           float64
 	Lon           float64
 	Depth         float64
@@ -24,6 +25,8 @@ type Defect struct {
 	AnomalyScore  float32
 	Vibration     float64
 	Date          time.Time
+	ChainageKm    *float64
+	Growth        *DefectGrowth `json:",omitempty"`
 }
 
 type DefectStateMetrics struct {
@@ -41,16 +44,6 @@ type DefectsByYear struct {
 	Count int
 }
 
-type DefectFilter struct {
-	Page       int
-	Limit      int
-	Search     string // Поиск по типу
-	PipelineID uint
-	Severity   int
-	DateFrom   time.Time
-	DateTo     time.Time
-}
-
 type PipelineStats struct {
 	TotalObjects   int64 `json:"total_objects"`
 	TotalDefects   int64 `json:"total_defects"`
@@ -58,3 +51,18 @@ type PipelineStats struct {
 
 	StatusDistribution map[string]int64 `json:"status_distribution"`
 }
+
+// DefectInput — создание и частичное изменение дефекта. Пустые (nil) поля при изменении не трогаются.
+type DefectInput struct {
+	ObjectId       *uint      `json:"object_id"`
+	DefectTypeId   *uint      `json:"defect_type_id"`
+	QualityGradeId *uint      `json:"quality_grade_id"`
+	Description    *string    `json:"description"`
+	Date           *time.Time `json:"date"`
+	Depth          *float64   `json:"depth"`
+	Length         *float64   `json:"length"`
+	Width          *float64   `json:"width"`
+	Vibration      *float64   `json:"vibration"`
+	Lat            *float64   `json:"lat"`
+	Lon            *float64   `json:"lon"`
+}
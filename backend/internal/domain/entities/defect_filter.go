@@ -0,0 +1,117 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Поля сортировки списка дефектов; "-" перед полем — по убыванию (sort=-depth)
+const (
+	DefectSortDate      = "date"
+	DefectSortDepth     = "depth"
+	DefectSortLength    = "length"
+	DefectSortVibration = "vibration"
+	DefectSortSeverity  = "severity"
+	DefectSortId        = "id"
+	DefectSortChainage  = "chainage"
+)
+
+var DefectSortFields = []string{
+	DefectSortDate, DefectSortDepth, DefectSortLength, DefectSortVibration, DefectSortSeverity, DefectSortId, DefectSortChainage,
+}
+
+// FloatRange — числовой диапазон; nil-граница не ограничивает
+type FloatRange struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+func (r FloatRange) Empty() bool {
+	return r.Min == nil && r.Max == nil
+}
+
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// BBox — прямоугольник карты в градусах WGS84
+type BBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// DefectFilter — единый фильтр для списка дефектов, тепловой карты, выгрузок и массовых операций.
+// Множественные поля объединяются через ИЛИ внутри поля и через И между полями.
+type DefectFilter struct {
+	Page  int `json:"-"`
+	Limit int `json:"-"`
+	// Cursor — keyset-пагинация: при заданном курсоре Page не используется
+	Cursor string `json:"-"`
+	Sort   string `json:"sort,omitempty"`
+	Desc   bool   `json:"desc,omitempty"`
+
+	Search        string   `json:"search,omitempty"` // Полнотекстовый и нечеткий поиск по описанию и типу
+	PipelineIds   []uint   `json:"pipeline_ids,omitempty"`
+	ObjectIds     []uint   `json:"object_ids,omitempty"`
+	Severities    []int    `json:"severities,omitempty"` // ранги оценки из каталога (quality_grades.rank)
+	Statuses      []string `json:"statuses,omitempty"`
+	DefectTypeIds []uint   `json:"defect_type_ids,omitempty"`
+	Methods       []string `json:"methods,omitempty"`   // методы диагностики объекта (methods.method_name)
+	Materials     []string `json:"materials,omitempty"` // материал объекта
+
+	BBox    *BBox      `json:"bbox,omitempty"`
+	Polygon []GeoPoint `json:"polygon,omitempty"` // замыкается автоматически
+
+	Depth     FloatRange `json:"depth"`
+	Length    FloatRange `json:"length"`
+	Vibration FloatRange `json:"vibration"`
+	Chainage  FloatRange `json:"chainage"` // пикет на трассе, км
+
+	DateFrom time.Time `json:"date_from"`
+	DateTo   time.Time `json:"date_to"`
+}
+
+// Empty — в фильтре не задано ни одного условия
+func (f DefectFilter) Empty() bool {
+	return f.Search == "" && len(f.PipelineIds) == 0 && len(f.ObjectIds) == 0 && len(f.Severities) == 0 &&
+		len(f.Statuses) == 0 && len(f.DefectTypeIds) == 0 && len(f.Methods) == 0 && len(f.Materials) == 0 &&
+		f.BBox == nil && len(f.Polygon) == 0 && f.Depth.Empty() && f.Length.Empty() && f.Vibration.Empty() && f.Chainage.Empty() &&
+		f.DateFrom.IsZero() && f.DateTo.IsZero()
+}
+
+// DefectCursor — позиция в отсортированном списке: значение поля сортировки и id последнего дефекта
+type DefectCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Id    uint   `json:"id"`
+}
+
+func EncodeDefectCursor(c DefectCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeDefectCursor(raw string) (DefectCursor, error) {
+	var c DefectCursor
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return c, fmt.Errorf("malformed cursor")
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.Id == 0 {
+		return c, fmt.Errorf("malformed cursor")
+	}
+	return c, nil
+}
+
+// DefectPage — страница списка. Total считается только для первой страницы курсорной выдачи
+// и для обычной постраничной, чтобы не пересчитывать большие выборки на каждом шаге.
+type DefectPage struct {
+	Data       []Defect `json:"data"`
+	Total      *int64   `json:"total,omitempty"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,75 @@
+package entities
+
+import "time"
+
+// Жизненный цикл дефекта
+const (
+	DefectNew       = "New"
+	DefectTriaged   = "Triaged"
+	DefectScheduled = "Scheduled"
+	DefectInRepair  = "InRepair"
+	DefectVerified  = "Verified"
+	DefectClosed    = "Closed"
+	DefectRejected  = "Rejected"
+)
+
+// DefectStatuses — все статусы в порядке жизненного цикла
+var DefectStatuses = []string{
+	DefectNew, DefectTriaged, DefectScheduled, DefectInRepair, DefectVerified, DefectClosed, DefectRejected,
+}
+
+// DefectTransitions — из какого статуса в какие можно перейти.
+// Неудачная проверка возвращает дефект в ремонт, отклоненный дефект можно открыть заново.
+var DefectTransitions = map[string][]string{
+	DefectNew:       {DefectTriaged, DefectRejected},
+	DefectTriaged:   {DefectScheduled, DefectRejected},
+	DefectScheduled: {DefectInRepair, DefectTriaged},
+	DefectInRepair:  {DefectVerified, DefectScheduled},
+	DefectVerified:  {DefectClosed, DefectInRepair},
+	DefectClosed:    {},
+	DefectRejected:  {DefectNew},
+}
+
+// OpenDefectStatuses — статусы, в которых дефект еще требует работы
+var OpenDefectStatuses = []string{DefectNew, DefectTriaged, DefectScheduled, DefectInRepair, DefectVerified}
+
+// LegacyDefectStatuses — как старые статусы из импорта и сидера переводятся в новый цикл
+var LegacyDefectStatuses = map[string]string{
+	"":           DefectNew,
+	"Open":       DefectNew,
+	"Created":    DefectNew,
+	"Processing": DefectInRepair,
+	"Solved":     DefectClosed,
+	"Resolved":   DefectClosed,
+}
+
+func CanTransition(from, to string) bool {
+	for _, next := range DefectTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionRequest — запрос на смену статуса. Какие поля обязательны, зависит от целевого статуса:
+// Triaged — quality_grade_id, Scheduled — planned_date и employee_ids, Verified и Rejected — comment.
+type TransitionRequest struct {
+	To             string     `json:"to"`
+	Comment        string     `json:"comment"`
+	QualityGradeId uint       `json:"quality_grade_id"`
+	PlannedDate    *time.Time `json:"planned_date"`
+	EmployeeIds    []uint     `json:"employee_ids"`
+}
+
+type DefectStatusChange struct {
+	HistoryId       uint                   `json:"history_id"`
+	DefectId        uint                   `json:"defect_id"`
+	FromStatus      string                 `json:"from_status"`
+	ToStatus        string                 `json:"to_status"`
+	Comment         string                 `json:"comment"`
+	Details         map[string]interface{} `json:"details,omitempty"`
+	ChangedBy       *uint                  `json:"changed_by"`
+	ChangedByApiKey *uint                  `json:"changed_by_api_key"`
+	CreatedAt       time.Time              `json:"created_at"`
+}
@@ -0,0 +1,56 @@
+package entities
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{DefectNew, DefectTriaged, true},
+		{DefectNew, DefectRejected, true},
+		{DefectNew, DefectScheduled, false},
+		{DefectTriaged, DefectScheduled, true},
+		{DefectTriaged, DefectInRepair, false},
+		{DefectScheduled, DefectInRepair, true},
+		{DefectScheduled, DefectTriaged, true},
+		{DefectInRepair, DefectVerified, true},
+		{DefectInRepair, DefectClosed, false},
+		{DefectVerified, DefectClosed, true},
+		{DefectVerified, DefectInRepair, true},
+		{DefectClosed, DefectNew, false},
+		{DefectRejected, DefectNew, true},
+		{DefectRejected, DefectTriaged, false},
+		{DefectNew, DefectNew, false},
+		{"unknown", DefectNew, false},
+		{DefectNew, "unknown", false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+// Из любого статуса, кроме Closed, можно выйти, и каждый переход ведет в известный статус
+func TestDefectTransitionsMap(t *testing.T) {
+	for _, status := range DefectStatuses {
+		next, ok := DefectTransitions[status]
+		if !ok {
+			t.Errorf("status %q has no entry in DefectTransitions", status)
+			continue
+		}
+		if status != DefectClosed && len(next) == 0 {
+			t.Errorf("status %q is a dead end", status)
+		}
+		for _, to := range next {
+			if _, ok := DefectTransitions[to]; !ok {
+				t.Errorf("%q -> %q leads to an unknown status", status, to)
+			}
+		}
+	}
+	if len(DefectTransitions[DefectClosed]) != 0 {
+		t.Errorf("closed defect must be final, got %v", DefectTransitions[DefectClosed])
+	}
+}
@@ -1,11 +1,44 @@
 package entities
 
+// Уровни административного деления: область, город республиканского значения, район
+const (
+	DistrictLevelOblast   = "oblast"
+	DistrictLevelCity     = "city"
+	DistrictLevelDistrict = "district"
+)
+
+var DistrictLevels = []string{DistrictLevelOblast, DistrictLevelCity, DistrictLevelDistrict}
+
+// DistrictFullInfo — сводка по району вместе с вложенными районами: объекты, сотрудники в границах,
+// диагностики и дефекты в области видимости пользователя.
+// AvgSeverity — средний приоритет оценки дефектов; Condition — средний индекс состояния объектов,
+// nil — индекс еще не считался.
 type DistrictFullInfo struct {
-	EmployeesCount  int
-	ObjectCount     int
-	InspectionCount int
-	AvgSeverity     float64
-	DefectCount     int
+	District        District             `json:"district"`
+	EmployeesCount  int                  `json:"employees_count"`
+	ObjectCount     int                  `json:"object_count"`
+	InspectionCount int                  `json:"inspection_count"`
+	AvgSeverity     float64              `json:"avg_severity"`
+	DefectCount     int                  `json:"defect_count"`
+	OpenDefectCount int                  `json:"open_defect_count"`
+	TopDefectTypes  []DistrictDefectType `json:"top_defect_types"`
+	Condition       *float64             `json:"condition"`
+}
+
+type DistrictDefectType struct {
+	DefectType  string  `json:"defect_type"`
+	Count       int     `json:"count"`
+	AvgSeverity float64 `json:"avg_severity"`
+}
+
+type DistrictFilter struct {
+	Level    string
+	ParentId *uint
+}
 
-	Condition float64
+// DistrictImport — итог загрузки границ; AssignedObjects — сколько объектов сменили район
+type DistrictImport struct {
+	Created         int   `json:"created"`
+	Updated         int   `json:"updated"`
+	AssignedObjects int64 `json:"assigned_objects"`
 }
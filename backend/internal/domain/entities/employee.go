@@ -1,5 +1,7 @@
 package entities
 
+import "time"
+
 type Employee struct {
 	EmployeeId uint
 	FirstName  string
@@ -10,3 +12,23 @@ type Employee struct {
 	DefectId   uint
 	ObjectId   uint
 }
+
+// WorkItem — задача в очереди сотрудника: назначенный ему незакрытый дефект
+type WorkItem struct {
+	DefectId     uint      `json:"defect_id"`
+	ObjectId     uint      `json:"object_id"`
+	ObjectName   string    `json:"object_name"`
+	Pipeline     string    `json:"pipeline"`
+	DefectType   string    `json:"defect_type"`
+	QualityGrade string    `json:"quality_grade"`
+	Status       string    `json:"status"`
+	Priority     string    `json:"priority"`
+	Description  string    `json:"description"`
+	Date         time.Time `json:"date"`
+}
+
+// Workspace — профиль текущего пользователя вместе с привязанным сотрудником
+type Workspace struct {
+	User     User      `json:"user"`
+	Employee *Employee `json:"employee"`
+}
@@ -0,0 +1,49 @@
+package entities
+
+import "time"
+
+// Источник замера дефекта
+const (
+	MeasurementImport = "import"
+	MeasurementManual = "manual"
+)
+
+// Оценка развития дефекта по серии замеров
+const (
+	GrowthInsufficientData = "insufficient_data"
+	GrowthStable           = "stable"
+	GrowthGrowing          = "growing"
+	GrowthCritical         = "critical"
+)
+
+// DefectMeasurement — один замер дефекта при очередной диагностике
+type DefectMeasurement struct {
+	MeasurementId uint      `json:"measurement_id"`
+	DefectId      uint      `json:"defect_id"`
+	DiagnosticId  *uint     `json:"diagnostic_id"`
+	MeasuredAt    time.Time `json:"measured_at"`
+	Depth         float64   `json:"depth"`
+	Length        float64   `json:"length"`
+	Width         float64   `json:"width"`
+	Source        string    `json:"source"`
+}
+
+type MeasurementInput struct {
+	MeasuredAt   *time.Time `json:"measured_at"`
+	DiagnosticId *uint      `json:"diagnostic_id"`
+	Depth        *float64   `json:"depth"`
+	Length       *float64   `json:"length"`
+	Width        *float64   `json:"width"`
+}
+
+// DefectGrowth — трек дефекта: серия замеров, скорости роста (мм/год) и прогноз достижения критической глубины
+type DefectGrowth struct {
+	DefectId              uint                `json:"defect_id"`
+	Status                string              `json:"status"`
+	Measurements          []DefectMeasurement `json:"measurements"`
+	DepthRate             *float64            `json:"depth_rate"`
+	LengthRate            *float64            `json:"length_rate"`
+	WidthRate             *float64            `json:"width_rate"`
+	CriticalDepth         float64             `json:"critical_depth"`
+	ProjectedCriticalDate *time.Time          `json:"projected_critical_date"`
+}
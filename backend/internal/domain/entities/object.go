@@ -1,11 +1,22 @@
 package entities
 
+import "time"
+
 type Object struct {
-	ObjectId uint
-	Name     string
-	Lon      float64
-	Lat      float64
-	Material string
+	ObjectId         uint
+	Name             string
+	TypeId           uint
+	PipelineId       uint
+	Lon              float64
+	Lat              float64
+	Material         string
+	InstallationYear int
+	Pipe             PipeProperties
+	// ChainageKm — пикет на трассе трубопровода; nil, если трасса не загружена
+	ChainageKm *float64
+	// Condition — индекс состояния 0–100; nil, если еще не считался
+	Condition   *float64
+	ConditionAt *time.Time
 }
 
 type ObjectFullInfo struct {
@@ -15,4 +26,75 @@ type ObjectFullInfo struct {
 	Diagnostics []Diagnostic         `json:"diagnostics"`
 	History     []MonthlyProbability `json:"motnhly_probability"`
 	Sensors     []Sensor             `json:"sensors"`
+	Growth      []DefectGrowth       `json:"defect_growth"`
+}
+
+// Зоны риска по последней предсказанной вероятности отказа (доля 0..1)
+const (
+	RiskNone   = "none" // предсказаний еще не было
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+
+	RiskMediumFrom = 0.3
+	RiskHighFrom   = 0.7
+)
+
+var RiskBands = []string{RiskNone, RiskLow, RiskMedium, RiskHigh}
+
+func RiskBand(probability *float64) string {
+	switch {
+	case probability == nil:
+		return RiskNone
+	case *probability >= RiskHighFrom:
+		return RiskHigh
+	case *probability >= RiskMediumFrom:
+		return RiskMedium
+	}
+	return RiskLow
+}
+
+// Поля сортировки списка объектов; "-" перед полем — по убыванию
+const (
+	ObjectSortName        = "name"
+	ObjectSortProbability = "probability"
+	ObjectSortDefects     = "defects"
+	ObjectSortYear        = "year"
+	ObjectSortId          = "id"
+	ObjectSortCondition   = "condition"
+)
+
+var ObjectSortFields = []string{ObjectSortName, ObjectSortProbability, ObjectSortDefects, ObjectSortYear, ObjectSortId, ObjectSortCondition}
+
+// ObjectInput — создание и частичное изменение объекта. Пустые (nil) поля при изменении не трогаются.
+type ObjectInput struct {
+	Name             *string  `json:"name"`
+	TypeId           *uint    `json:"type_id"`
+	PipelineId       *uint    `json:"pipeline_id"`
+	Lat              *float64 `json:"lat"`
+	Lon              *float64 `json:"lon"`
+	Material         *string  `json:"material"`
+	InstallationYear *int     `json:"installation_year"`
+}
+
+type ObjectFilter struct {
+	PipelineIds []uint
+	TypeIds     []uint
+	Materials   []string
+	RiskBands   []string
+	BBox        *BBox
+	Sort        string
+	Desc        bool
+	Page        int
+	Limit       int
+}
+
+// ObjectSummary — строка списка объектов: объект, справочные имена, последняя вероятность отказа и число дефектов
+type ObjectSummary struct {
+	Object
+	TypeName     string   `json:"type_name"`
+	PipelineName string   `json:"pipeline_name"`
+	Probability  *float64 `json:"probability"`
+	RiskBand     string   `json:"risk_band"`
+	DefectCount  int64    `json:"defect_count"`
 }
@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Pipeline — паспорт трубопровода. DesignPressure — проектное МДРД (МПа), от него считается допустимое давление участков.
+// AvgImportance и Stats считаются по дефектам при каждом запросе.
+type Pipeline struct {
+	PipelineId        uint       `json:"pipeline_id"`
+	Name              string     `json:"name"`
+	Operator          string     `json:"operator"`
+	Product           string     `json:"product"`
+	CommissioningYear int        `json:"commissioning_year"`
+	LengthKm          float64    `json:"length_km"`
+	DesignPressure    float64    `json:"design_pressure"`
+	Condition         float64    `json:"condition"`
+	ConditionAt       *time.Time `json:"condition_at,omitempty"`
+	Archived          bool       `json:"archived"`
+	ArchivedAt        *time.Time `json:"archived_at,omitempty"`
+	HasRoute          bool       `json:"has_route"`
+	StartKm           float64    `json:"start_km"`
+
+	AvgImportance float64        `json:"avg_importance"`
+	Stats         *PipelineStats `json:"stats,omitempty"`
+}
+
+// PipelineInput — создание и частичное изменение. Пустые (nil) поля при изменении не трогаются.
+type PipelineInput struct {
+	Name              *string  `json:"name"`
+	Operator          *string  `json:"operator"`
+	Product           *string  `json:"product"`
+	CommissioningYear *int     `json:"commissioning_year"`
+	LengthKm          *float64 `json:"length_km"`
+	DesignPressure    *float64 `json:"design_pressure"`
+}
+
+type PipelineFilter struct {
+	Search          string
+	Operator        string
+	Product         string
+	IncludeArchived bool
+	Page            int
+	Limit           int
+}
+
+// PipelineRoute — трасса трубопровода; Geometry — GeoJSON LineString
+type PipelineRoute struct {
+	PipelineId uint            `json:"pipeline_id"`
+	StartKm    float64         `json:"start_km"`
+	EndKm      float64         `json:"end_km"`
+	LengthKm   float64         `json:"length_km"`
+	Geometry   json.RawMessage `json:"geometry"`
+}
+
+// RouteImport — итог загрузки трассы: сколько объектов и дефектов привязано заново
+type RouteImport struct {
+	Route          PipelineRoute `json:"route"`
+	SnappedObjects int64         `json:"snapped_objects"`
+	SnappedDefects int64         `json:"snapped_defects"`
+}
+
+// Chainage — привязка точки к трассе: пикет, расстояние до оси и ближайшая точка на оси
+type Chainage struct {
+	PipelineId uint     `json:"pipeline_id"`
+	Lat        float64  `json:"lat"`
+	Lon        float64  `json:"lon"`
+	ChainageKm float64  `json:"chainage_km"`
+	OffsetM    float64  `json:"offset_m"`
+	Snapped    GeoPoint `json:"snapped"`
+}
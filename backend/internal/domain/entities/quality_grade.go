@@ -0,0 +1,57 @@
+package entities
+
+import "strings"
+
+// Коды оценок качества (техсостояния) дефекта — в этом виде они приходят из диагностики
+const (
+	GradeSatisfactory   = "удовлетворительно"
+	GradeAcceptable     = "допустимо"
+	GradeActionRequired = "требует_мер"
+	GradeUnacceptable   = "недопустимо"
+)
+
+type GradeLabels struct {
+	Ru string `json:"ru"`
+	En string `json:"en"`
+}
+
+// QualityGrade — запись каталога оценок. Rank — каноническая тяжесть (1 — лучшая оценка),
+// HeatWeight — вес точки на тепловой карте, Priority — приоритет работ по дефекту с такой оценкой.
+// Запросы, тепловые карты и отчеты берут эти значения из каталога, а не из id оценки.
+type QualityGrade struct {
+	QualityGradeId uint        `json:"quality_grade_id"`
+	Code           string      `json:"code"`
+	Rank           int         `json:"rank"`
+	HeatWeight     float64     `json:"heat_weight"`
+	Color          string      `json:"color"`
+	Labels         GradeLabels `json:"labels"`
+	Critical       bool        `json:"critical"`
+	Priority       string      `json:"priority"`
+}
+
+type QualityGradeInput struct {
+	Rank       *int     `json:"rank"`
+	HeatWeight *float64 `json:"heat_weight"`
+	Color      *string  `json:"color"`
+	LabelRu    *string  `json:"label_ru"`
+	LabelEn    *string  `json:"label_en"`
+	Critical   *bool    `json:"critical"`
+	Priority   *string  `json:"priority"`
+}
+
+// DefaultQualityGrades — каталог по умолчанию; засевается при миграции, дальше настраивается через API
+var DefaultQualityGrades = []QualityGrade{
+	{Code: GradeSatisfactory, Rank: 1, HeatWeight: 0.2, Color: "#4CAF50",
+		Labels: GradeLabels{Ru: "Удовлетворительно", En: "Satisfactory"}, Priority: PriorityLow},
+	{Code: GradeAcceptable, Rank: 2, HeatWeight: 0.4, Color: "#FFC107",
+		Labels: GradeLabels{Ru: "Допустимо", En: "Acceptable"}, Priority: PriorityMedium},
+	{Code: GradeActionRequired, Rank: 3, HeatWeight: 0.7, Color: "#FF9800",
+		Labels: GradeLabels{Ru: "Требует мер", En: "Action required"}, Priority: PriorityHigh},
+	{Code: GradeUnacceptable, Rank: 4, HeatWeight: 1.0, Color: "#F44336",
+		Labels: GradeLabels{Ru: "Недопустимо", En: "Unacceptable"}, Critical: true, Priority: PriorityCritical},
+}
+
+// NormalizeGradeCode приводит название оценки из CSV или старых данных к коду каталога ("Требует мер" -> "требует_мер")
+func NormalizeGradeCode(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "_")
+}
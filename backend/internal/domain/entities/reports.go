@@ -40,6 +40,7 @@ type SingleDefectReport struct {
 	Metrics        DefectReportMetrics `json:"metrics"`
 	LlmSolution    string              `json:"llm_solution"`
 	MapImageBase64 string              `json:"map_image_base64"`
+	Assessment     *DefectAssessment   `json:"assessment,omitempty"`
 }
 
 type DefectReportMetrics struct {
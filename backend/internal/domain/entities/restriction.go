@@ -0,0 +1,70 @@
+package entities
+
+import "time"
+
+// PressureRestriction — запись реестра ограничений давления. ObjectId == nil — ограничение на весь трубопровод.
+type PressureRestriction struct {
+	RestrictionId uint       `json:"restriction_id"`
+	PipelineId    uint       `json:"pipeline_id"`
+	PipelineName  string     `json:"pipeline_name"`
+	ObjectId      *uint      `json:"object_id"`
+	ObjectName    string     `json:"object_name,omitempty"`
+	DefectId      *uint      `json:"defect_id"`
+	MaxPressure   float64    `json:"max_pressure"`
+	Reason        string     `json:"reason"`
+	ImposedBy     *UserRef   `json:"imposed_by"`
+	ImposedAt     time.Time  `json:"imposed_at"`
+	ValidUntil    *time.Time `json:"valid_until"`
+	LiftedBy      *UserRef   `json:"lifted_by,omitempty"`
+	LiftedAt      *time.Time `json:"lifted_at,omitempty"`
+	LiftReason    string     `json:"lift_reason,omitempty"`
+	Active        bool       `json:"active"`
+}
+
+type RestrictionInput struct {
+	PipelineId  uint       `json:"pipeline_id"`
+	ObjectId    *uint      `json:"object_id"`
+	DefectId    *uint      `json:"defect_id"`
+	MaxPressure float64    `json:"max_pressure"`
+	Reason      string     `json:"reason"`
+	ValidUntil  *time.Time `json:"valid_until"`
+}
+
+type RestrictionFilter struct {
+	PipelineId uint
+	ObjectId   uint
+	ActiveOnly bool
+	Page       int
+	Limit      int
+}
+
+// SegmentMaop — допустимое давление участка трубопровода (объекта).
+// EffectiveMaop — минимум из проектного МДРД и безопасных давлений открытых дефектов участка;
+// OperatingLimit дополнительно учитывает действующие ограничения из реестра.
+type SegmentMaop struct {
+	ObjectId         uint                  `json:"object_id"`
+	ObjectName       string                `json:"object_name"`
+	PipelineId       uint                  `json:"pipeline_id"`
+	PipelineName     string                `json:"pipeline_name"`
+	DesignMaop       float64               `json:"design_maop"`
+	EffectiveMaop    float64               `json:"effective_maop"`
+	OperatingLimit   float64               `json:"operating_limit"`
+	LimitingDefectId *uint                 `json:"limiting_defect_id"`
+	OpenDefects      int                   `json:"open_defects"`
+	Unassessed       []uint                `json:"unassessed_defect_ids"`
+	Derated          bool                  `json:"derated"`
+	Restrictions     []PressureRestriction `json:"restrictions"`
+}
+
+// Restricted — участок работает на пониженном давлении: снижен расчетом или ограничен вручную
+func (s SegmentMaop) Restricted() bool {
+	return s.Derated || len(s.Restrictions) > 0
+}
+
+type PipelineMaop struct {
+	PipelineId    uint          `json:"pipeline_id"`
+	Name          string        `json:"name"`
+	DesignMaop    float64       `json:"design_maop"`
+	EffectiveMaop float64       `json:"effective_maop"`
+	Segments      []SegmentMaop `json:"segments"`
+}
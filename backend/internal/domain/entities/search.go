@@ -0,0 +1,21 @@
+package entities
+
+// Типы результатов глобального поиска
+const (
+	SearchDefect   = "defect"
+	SearchObject   = "object"
+	SearchPipeline = "pipeline"
+)
+
+var SearchTypes = []string{SearchDefect, SearchObject, SearchPipeline}
+
+// SearchResult — строка выдачи глобального поиска. Highlight — фрагмент с совпадениями в <mark>,
+// остальной текст в нем экранирован.
+type SearchResult struct {
+	Type      string  `json:"type"`
+	Id        uint    `json:"id"`
+	Title     string  `json:"title"`
+	Subtitle  string  `json:"subtitle"`
+	Highlight string  `json:"highlight"`
+	Rank      float64 `json:"rank"`
+}
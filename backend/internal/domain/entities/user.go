@@ -0,0 +1,61 @@
+package entities
+
+import "time"
+
+type User struct {
+	UserId        uint      `json:"user_id"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	EmployeeId    *uint     `json:"employee_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const (
+	TokenPasswordReset = "password_reset"
+	TokenEmailVerify   = "email_verify"
+)
+
+// Principal — тот, от чьего имени выполняется запрос (извлекается из токена)
+type Principal struct {
+	UserId    uint      `json:"user_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	SessionId string    `json:"session_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Заполняются, если запрос пришел с API-ключом, а не от пользователя
+	ApiKeyId    uint     `json:"api_key_id,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	PipelineIds []uint   `json:"pipeline_ids,omitempty"`
+}
+
+type ApiKey struct {
+	ApiKeyId    uint       `json:"api_key_id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Permissions []string   `json:"permissions"`
+	PipelineIds []uint     `json:"pipeline_ids"`
+	CreatedBy   uint       `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+// Session — сессия входа, живет в Redis столько же, сколько refresh-токен
+type Session struct {
+	SessionId   string    `json:"session_id"`
+	UserId      uint      `json:"user_id"`
+	RefreshHash string    `json:"refresh_hash"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type TokenPair struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Role         string    `json:"role"`
+}
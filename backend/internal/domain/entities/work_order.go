@@ -0,0 +1,71 @@
+package entities
+
+import "time"
+
+// Статусы наряда на ремонт
+const (
+	WorkOrderPlanned    = "Planned"
+	WorkOrderAssigned   = "Assigned"
+	WorkOrderInProgress = "InProgress"
+	WorkOrderCompleted  = "Completed"
+	WorkOrderCancelled  = "Cancelled"
+)
+
+// ActiveWorkOrderStatuses — наряды, которые еще не завершены и не отменены
+var ActiveWorkOrderStatuses = []string{WorkOrderPlanned, WorkOrderAssigned, WorkOrderInProgress}
+
+const (
+	PriorityLow      = "low"
+	PriorityMedium   = "medium"
+	PriorityHigh     = "high"
+	PriorityCritical = "critical"
+)
+
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityCritical}
+
+type WorkOrder struct {
+	WorkOrderId      uint       `json:"work_order_id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	Status           string     `json:"status"`
+	Priority         string     `json:"priority"`
+	MethodId         *uint      `json:"method_id"`
+	Method           string     `json:"method"`
+	PlannedStart     *time.Time `json:"planned_start"`
+	PlannedFinish    *time.Time `json:"planned_finish"`
+	ActualStart      *time.Time `json:"actual_start"`
+	ActualFinish     *time.Time `json:"actual_finish"`
+	CompletionReport string     `json:"completion_report"`
+	CancelReason     string     `json:"cancel_reason"`
+	CreatedBy        *uint      `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	Defects          []Defect   `json:"defects"`
+	Employees        []Employee `json:"employees"`
+}
+
+type WorkOrderInput struct {
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Priority      string     `json:"priority"`
+	MethodId      *uint      `json:"method_id"`
+	PlannedStart  *time.Time `json:"planned_start"`
+	PlannedFinish *time.Time `json:"planned_finish"`
+	DefectIds     []uint     `json:"defect_ids"`
+}
+
+type WorkOrderAssignment struct {
+	EmployeeIds   []uint     `json:"employee_ids"`
+	PlannedStart  *time.Time `json:"planned_start"`
+	PlannedFinish *time.Time `json:"planned_finish"`
+}
+
+type WorkOrderFilter struct {
+	Status     string
+	ActiveOnly bool
+	Priority   string
+	EmployeeId uint
+	DefectId   uint
+	Page       int
+	Limit      int
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleNotFound      = fmt.Errorf("role not found")
+	ErrUnknownPermission = fmt.Errorf("unknown permission")
+	ErrUnknownPipeline   = fmt.Errorf("unknown pipeline")
+)
+
+type AccessRepo interface {
+	SeedDefaults(ctx context.Context, permissions []entities.PermissionInfo, roles []entities.Role) error
+	ListRoles(ctx context.Context) ([]entities.Role, error)
+	ListPermissions(ctx context.Context) ([]entities.PermissionInfo, error)
+	SetRolePermissions(ctx context.Context, roleName string, codes []string) error
+	ExistingPipelineIds(ctx context.Context, pipelineIds []uint) ([]uint, error)
+}
+
+type AccessRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessRepository(db *gorm.DB) *AccessRepository {
+	return &AccessRepository{db: db}
+}
+
+// SeedDefaults досоздает недостающие права и роли. Уже настроенные роли не перезаписываются:
+// им добавляются только права, которые появились в справочнике впервые. Админ всегда получает все права.
+func (r *AccessRepository) SeedDefaults(ctx context.Context, permissions []entities.PermissionInfo, roles []entities.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		byCode := make(map[string]models.Permission, len(permissions))
+		fresh := make(map[string]bool)
+
+		for _, p := range permissions {
+			var m models.Permission
+			res := tx.Where(models.Permission{Code: p.Code}).
+				Attrs(models.Permission{Description: p.Description}).
+				FirstOrCreate(&m)
+			if res.Error != nil {
+				return res.Error
+			}
+			byCode[p.Code] = m
+			fresh[p.Code] = res.RowsAffected > 0
+		}
+
+		for _, role := range roles {
+			var m models.Role
+			res := tx.Where(models.Role{Name: role.Name}).
+				Attrs(models.Role{Title: role.Title}).
+				FirstOrCreate(&m)
+			if res.Error != nil {
+				return res.Error
+			}
+			created := res.RowsAffected > 0
+
+			codes := role.Permissions
+			if role.Name == entities.RoleAdmin {
+				codes = codes[:0:0]
+				for _, p := range permissions {
+					codes = append(codes, p.Code)
+				}
+			}
+
+			var attach []models.Permission
+			for _, code := range codes {
+				if created || fresh[code] || role.Name == entities.RoleAdmin {
+					attach = append(attach, byCode[code])
+				}
+			}
+			if len(attach) == 0 {
+				continue
+			}
+			if err := tx.Model(&m).Association("Permissions").Append(attach); err != nil {
+				return err
+			}
+		}
+
+		// Пользователи со старыми/удаленными ролями становятся наблюдателями
+		return tx.Model(&models.User{}).
+			Where("role NOT IN (?)", tx.Model(&models.Role{}).Select("name")).
+			Update("role", entities.RoleViewer).Error
+	})
+}
+
+func (r *AccessRepository) ListRoles(ctx context.Context) ([]entities.Role, error) {
+	var models []models.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").Order("role_id ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	roles := make([]entities.Role, 0, len(models))
+	for _, m := range models {
+		roles = append(roles, RoleToEntity(m))
+	}
+	return roles, nil
+}
+
+func (r *AccessRepository) ListPermissions(ctx context.Context) ([]entities.PermissionInfo, error) {
+	var models []models.Permission
+	if err := r.db.WithContext(ctx).Order("code ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	perms := make([]entities.PermissionInfo, 0, len(models))
+	for _, m := range models {
+		perms = append(perms, entities.PermissionInfo{Code: m.Code, Description: m.Description})
+	}
+	return perms, nil
+}
+
+// ExistingPipelineIds — какие из переданных трубопроводов существуют; для проверки областей доступа
+func (r *AccessRepository) ExistingPipelineIds(ctx context.Context, pipelineIds []uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.Pipeline{}).
+		Where("pipeline_id IN ?", pipelineIds).
+		Pluck("pipeline_id", &ids).Error
+	return ids, err
+}
+
+func (r *AccessRepository) SetRolePermissions(ctx context.Context, roleName string, codes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role models.Role
+		if err := tx.First(&role, "name = ?", roleName).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+
+		var perms []models.Permission
+		if len(codes) > 0 {
+			if err := tx.Where("code IN ?", codes).Find(&perms).Error; err != nil {
+				return err
+			}
+		}
+		if len(perms) != len(uniqueStrings(codes)) {
+			return ErrUnknownPermission
+		}
+
+		return tx.Model(&role).Association("Permissions").Replace(perms)
+	})
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
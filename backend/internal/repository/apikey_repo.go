@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var ErrApiKeyNotFound = fmt.Errorf("api key not found")
+
+type ApiKeyRepo interface {
+	CreateApiKey(ctx context.Context, key *models.ApiKey) error
+	ListApiKeys(ctx context.Context) ([]entities.ApiKey, error)
+	GetActiveApiKey(ctx context.Context, keyHash string) (*entities.ApiKey, error)
+	RotateApiKey(ctx context.Context, apiKeyId uint, prefix, keyHash string) (*entities.ApiKey, error)
+	RevokeApiKey(ctx context.Context, apiKeyId uint) error
+	TouchApiKey(ctx context.Context, apiKeyId uint) error
+}
+
+type ApiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) *ApiKeyRepository {
+	return &ApiKeyRepository{db: db}
+}
+
+func (r *ApiKeyRepository) CreateApiKey(ctx context.Context, key *models.ApiKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *ApiKeyRepository) ListApiKeys(ctx context.Context) ([]entities.ApiKey, error) {
+	var rows []models.ApiKey
+	if err := r.db.WithContext(ctx).Order("api_key_id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]entities.ApiKey, 0, len(rows))
+	for _, m := range rows {
+		keys = append(keys, ApiKeyToEntity(m))
+	}
+	return keys, nil
+}
+
+// GetActiveApiKey ищет не отозванный и не просроченный ключ по хэшу
+func (r *ApiKeyRepository) GetActiveApiKey(ctx context.Context, keyHash string) (*entities.ApiKey, error) {
+	var model models.ApiKey
+	if err := r.db.WithContext(ctx).
+		Where("key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", keyHash, time.Now()).
+		First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApiKeyNotFound
+		}
+		return nil, err
+	}
+
+	key := ApiKeyToEntity(model)
+	return &key, nil
+}
+
+func (r *ApiKeyRepository) RotateApiKey(ctx context.Context, apiKeyId uint, prefix, keyHash string) (*entities.ApiKey, error) {
+	res := r.db.WithContext(ctx).Model(&models.ApiKey{}).
+		Where("api_key_id = ? AND revoked_at IS NULL", apiKeyId).
+		Updates(map[string]interface{}{"prefix": prefix, "key_hash": keyHash, "last_used_at": nil})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrApiKeyNotFound
+	}
+
+	var model models.ApiKey
+	if err := r.db.WithContext(ctx).First(&model, "api_key_id = ?", apiKeyId).Error; err != nil {
+		return nil, err
+	}
+	key := ApiKeyToEntity(model)
+	return &key, nil
+}
+
+func (r *ApiKeyRepository) RevokeApiKey(ctx context.Context, apiKeyId uint) error {
+	res := r.db.WithContext(ctx).Model(&models.ApiKey{}).
+		Where("api_key_id = ? AND revoked_at IS NULL", apiKeyId).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrApiKeyNotFound
+	}
+	return nil
+}
+
+// TouchApiKey обновляет last_used_at не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+func (r *ApiKeyRepository) TouchApiKey(ctx context.Context, apiKeyId uint) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&models.ApiKey{}).
+		Where("api_key_id = ? AND (last_used_at IS NULL OR last_used_at < ?)", apiKeyId, now.Add(-time.Minute)).
+		Update("last_used_at", now).Error
+}
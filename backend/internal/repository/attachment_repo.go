@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAttachmentNotFound      = fmt.Errorf("attachment not found")
+	ErrAttachmentOwnerNotFound = fmt.Errorf("attachment owner not found")
+	ErrUnknownAttachmentOwner  = fmt.Errorf("unknown attachment owner type")
+)
+
+type AttachmentRepo interface {
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+	GetAttachment(ctx context.Context, attachmentId uint) (*models.Attachment, error)
+	ListAttachments(ctx context.Context, ownerType string, ownerId uint) ([]entities.Attachment, error)
+	DeleteAttachment(ctx context.Context, attachmentId uint) error
+	OwnerExists(ctx context.Context, ownerType string, ownerId uint) error
+}
+
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	return r.db.WithContext(ctx).Create(attachment).Error
+}
+
+// GetAttachment не применяет область видимости — доступ к владельцу проверяет OwnerExists
+func (r *AttachmentRepository) GetAttachment(ctx context.Context, attachmentId uint) (*models.Attachment, error) {
+	var model models.Attachment
+	if err := r.db.WithContext(ctx).First(&model, "attachment_id = ?", attachmentId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *AttachmentRepository) ListAttachments(ctx context.Context, ownerType string, ownerId uint) ([]entities.Attachment, error) {
+	var rows []models.Attachment
+	if err := r.db.WithContext(ctx).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerId).
+		Order("created_at DESC, attachment_id DESC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	attachments := make([]entities.Attachment, 0, len(rows))
+	for _, m := range rows {
+		attachments = append(attachments, AttachmentToEntity(m))
+	}
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, attachmentId uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Attachment{}, "attachment_id = ?", attachmentId).Error
+}
+
+// OwnerExists проверяет, что сущность-владелец существует и входит в область видимости пользователя
+func (r *AttachmentRepository) OwnerExists(ctx context.Context, ownerType string, ownerId uint) error {
+	query := r.db.WithContext(ctx)
+	switch ownerType {
+	case entities.AttachmentOwnerObject:
+		query = query.Table("objects").Where("objects.object_id = ?", ownerId)
+	case entities.AttachmentOwnerDefect:
+		query = query.Table("defects").
+			Joins("JOIN objects ON objects.object_id = defects.object_id").
+			Where("defects.defect_id = ?", ownerId)
+	case entities.AttachmentOwnerDiagnostic:
+		query = query.Table("diagnostics").
+			Joins("JOIN objects ON objects.object_id = diagnostics.object_id").
+			Where("diagnostics.diagnostic_id = ?", ownerId)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownAttachmentOwner, ownerType)
+	}
+
+	var count int64
+	if err := query.Scopes(ScopeObjects(ctx, "objects")).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrAttachmentOwnerNotFound
+	}
+	return nil
+}
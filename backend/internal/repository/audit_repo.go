@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+type AuditRepo interface {
+	AddEntry(ctx context.Context, entry *models.AuditLog) error
+	ListEntries(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, int64, error)
+}
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) AddEntry(ctx context.Context, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *AuditRepository) ListEntries(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditLog{})
+
+	if filter.ActorUserId != 0 {
+		query = query.Where("actor_user_id = ?", filter.ActorUserId)
+	}
+	if filter.ActorApiKeyId != 0 {
+		query = query.Where("actor_api_key_id = ?", filter.ActorApiKeyId)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityId != "" {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+	if !filter.DateFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.DateFrom)
+	}
+	if !filter.DateTo.IsZero() {
+		query = query.Where("created_at < ?", filter.DateTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.AuditLog
+	if err := query.Scopes(Paginate(filter.Page, filter.Limit)).
+		Order("created_at DESC, audit_id DESC").
+		Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]entities.AuditEntry, 0, len(rows))
+	for _, m := range rows {
+		entries = append(entries, AuditToEntity(m))
+	}
+	return entries, total, nil
+}
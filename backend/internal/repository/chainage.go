@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var ErrPipelineNoRoute = fmt.Errorf("pipeline route is not loaded")
+
+// chainageSQL — пикет точки на трассе: пикет начала + геодезическая длина трассы до ближайшей к точке позиции, км.
+// offsetSQL — расстояние от точки до оси трассы, м. Аргументы: трасса (geometry) и точка (geometry).
+func chainageSQL(route, point string) string {
+	return fmt.Sprintf("ST_Length(ST_LineSubstring(%[1]s, 0, ST_LineLocatePoint(%[1]s, %[2]s))::geography) / 1000", route, point)
+}
+
+func offsetSQL(route, point string) string {
+	return fmt.Sprintf("ST_Distance(%s::geography, %s::geography)", route, point)
+}
+
+func pointSQL(alias string) string {
+	return fmt.Sprintf("ST_SetSRID(ST_MakePoint(%[1]s.lon, %[1]s.lat), 4326)", alias)
+}
+
+// MergeRoute склеивает участки трассы и возвращает тип результата и длину, км.
+// Несвязные участки остаются MULTILINESTRING — такую трассу привязать нельзя.
+func (r *PipelineRepository) MergeRoute(ctx context.Context, wkt string) (string, float64, error) {
+	var row struct {
+		Kind     string
+		LengthKm float64
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT GeometryType(g) AS kind, ST_Length(g::geography) / 1000 AS length_km
+		FROM (SELECT ST_LineMerge(ST_GeomFromText(?, 4326)) AS g) merged`, wkt).Scan(&row).Error
+	return row.Kind, row.LengthKm, err
+}
+
+func (r *PipelineRepository) SetRoute(ctx context.Context, pipelineId uint, wkt string, startKm float64) error {
+	res := r.db.WithContext(ctx).Model(&models.Pipeline{}).
+		Scopes(scopePipelines(ctx)).
+		Where("pipelines.pipeline_id = ?", pipelineId).
+		Updates(map[string]interface{}{
+			"route":    gorm.Expr("ST_LineMerge(ST_GeomFromText(?, 4326))", wkt),
+			"start_km": startKm,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPipelineNotFound
+	}
+	return nil
+}
+
+// GetRoute возвращает трассу как GeoJSON-геометрию
+func (r *PipelineRepository) GetRoute(ctx context.Context, pipelineId uint) (*entities.PipelineRoute, error) {
+	var row struct {
+		StartKm  float64
+		LengthKm *float64
+		Geometry *string
+	}
+	err := r.db.WithContext(ctx).Model(&models.Pipeline{}).
+		Select("pipelines.start_km, ST_Length(pipelines.route::geography) / 1000 AS length_km, ST_AsGeoJSON(pipelines.route) AS geometry").
+		Scopes(scopePipelines(ctx)).
+		Where("pipelines.pipeline_id = ?", pipelineId).
+		Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPipelineNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if row.Geometry == nil {
+		return nil, ErrPipelineNoRoute
+	}
+	return &entities.PipelineRoute{
+		PipelineId: pipelineId,
+		StartKm:    row.StartKm,
+		EndKm:      row.StartKm + *row.LengthKm,
+		LengthKm:   *row.LengthKm,
+		Geometry:   []byte(*row.Geometry),
+	}, nil
+}
+
+// SnapPipeline пересчитывает пикеты объектов и дефектов трубопровода; pipelineId == 0 — всех трубопроводов с трассой.
+// Дефект привязывается к трассе своего объекта.
+func (r *PipelineRepository) SnapPipeline(ctx context.Context, pipelineId uint) (int64, int64, error) {
+	filter, args := "", []interface{}{}
+	if pipelineId != 0 {
+		filter, args = " AND p.pipeline_id = ?", []interface{}{pipelineId}
+	}
+
+	objects := r.db.WithContext(ctx).Exec(fmt.Sprintf(`
+		UPDATE objects o SET chainage_km = p.start_km + %s, route_offset_m = %s
+		FROM pipelines p
+		WHERE p.pipeline_id = o.pipeline_id AND p.route IS NOT NULL%s`,
+		chainageSQL("p.route", pointSQL("o")), offsetSQL("p.route", pointSQL("o")), filter), args...)
+	if objects.Error != nil {
+		return 0, 0, objects.Error
+	}
+
+	defects := r.db.WithContext(ctx).Exec(fmt.Sprintf(`
+		UPDATE defects d SET chainage_km = p.start_km + %s, route_offset_m = %s
+		FROM objects o JOIN pipelines p ON p.pipeline_id = o.pipeline_id
+		WHERE o.object_id = d.object_id AND p.route IS NOT NULL%s`,
+		chainageSQL("p.route", pointSQL("d")), offsetSQL("p.route", pointSQL("d")), filter), args...)
+	if defects.Error != nil {
+		return 0, 0, defects.Error
+	}
+	return objects.RowsAffected, defects.RowsAffected, nil
+}
+
+// Locate переводит координаты в пикет трассы и ближайшую к ним точку на оси
+func (r *PipelineRepository) Locate(ctx context.Context, pipelineId uint, lat, lon float64) (*entities.Chainage, error) {
+	route, err := r.GetRoute(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+
+	point := "ST_SetSRID(ST_MakePoint(?, ?), 4326)"
+	var row struct {
+		ChainageKm float64
+		OffsetM    float64
+		SnappedLat float64
+		SnappedLon float64
+	}
+	err = r.db.WithContext(ctx).Raw(fmt.Sprintf(`
+		SELECT p.start_km + %s AS chainage_km, %s AS offset_m,
+			ST_Y(ST_ClosestPoint(p.route, %s)) AS snapped_lat, ST_X(ST_ClosestPoint(p.route, %s)) AS snapped_lon
+		FROM pipelines p WHERE p.pipeline_id = ?`,
+		chainageSQL("p.route", point), offsetSQL("p.route", point), point, point),
+		lon, lat, lon, lat, lon, lat, lon, lat, pipelineId).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.Chainage{
+		PipelineId: route.PipelineId,
+		Lat:        lat,
+		Lon:        lon,
+		ChainageKm: row.ChainageKm,
+		OffsetM:    row.OffsetM,
+		Snapped:    entities.GeoPoint{Lat: row.SnappedLat, Lon: row.SnappedLon},
+	}, nil
+}
+
+// ListObjectsAlong — объекты трубопровода по порядку пикетов, при необходимости в диапазоне км
+func (r *PipelineRepository) ListObjectsAlong(ctx context.Context, pipelineId uint, km entities.FloatRange) ([]entities.Object, error) {
+	query := r.db.WithContext(ctx).Model(&models.Object{}).
+		Where("objects.pipeline_id = ?", pipelineId).
+		Scopes(ScopeObjects(ctx, "objects"))
+	query = applyRange(query, "objects.chainage_km", km)
+
+	var rows []models.Object
+	if err := query.Order("objects.chainage_km ASC NULLS LAST, objects.object_id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	objects := make([]entities.Object, 0, len(rows))
+	for _, m := range rows {
+		objects = append(objects, ObjectToEntity(m))
+	}
+	return objects, nil
+}
+
+// SnapObject привязывает один объект к трассе его трубопровода (после создания или смены координат)
+func (r *ObjectRepository) SnapObject(ctx context.Context, objectId uint) error {
+	// сначала сбрасываем: объект мог перейти на трубопровод без трассы
+	if err := r.db.WithContext(ctx).Model(&models.Object{}).Where("object_id = ?", objectId).
+		Updates(map[string]interface{}{"chainage_km": nil, "route_offset_m": nil}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Exec(fmt.Sprintf(`
+		UPDATE objects o SET chainage_km = p.start_km + %s, route_offset_m = %s
+		FROM pipelines p
+		WHERE p.pipeline_id = o.pipeline_id AND p.route IS NOT NULL AND o.object_id = ?`,
+		chainageSQL("p.route", pointSQL("o")), offsetSQL("p.route", pointSQL("o"))), objectId).Error
+}
+
+// SnapDefect привязывает один дефект к трассе его трубопровода (после создания или смены координат)
+func (r *DefectRepository) SnapDefect(ctx context.Context, defectId uint) error {
+	// сначала сбрасываем: дефект мог переехать на объект трубопровода без трассы
+	if err := r.db.WithContext(ctx).Model(&models.Defect{}).Where("defect_id = ?", defectId).
+		Updates(map[string]interface{}{"chainage_km": nil, "route_offset_m": nil}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Exec(fmt.Sprintf(`
+		UPDATE defects d SET chainage_km = p.start_km + %s, route_offset_m = %s
+		FROM objects o JOIN pipelines p ON p.pipeline_id = o.pipeline_id
+		WHERE o.object_id = d.object_id AND p.route IS NOT NULL AND d.defect_id = ?`,
+		chainageSQL("p.route", pointSQL("d")), offsetSQL("p.route", pointSQL("d"))), defectId).Error
+}
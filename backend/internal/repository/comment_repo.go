@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCommentNotFound = fmt.Errorf("comment not found")
+
+type CommentRepo interface {
+	CreateComment(ctx context.Context, comment *models.DefectComment, mentionIds []uint) error
+	GetComment(ctx context.Context, commentId uint) (*models.DefectComment, error)
+	UpdateComment(ctx context.Context, commentId uint, body string, isDecision bool, mentionIds []uint) error
+	DeleteComment(ctx context.Context, commentId uint) error
+	ListThreads(ctx context.Context, defectId uint, page, limit int) ([]entities.Comment, int64, error)
+	ResolveMentions(ctx context.Context, handles []string) ([]uint, error)
+}
+
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+func (r *CommentRepository) CreateComment(ctx context.Context, comment *models.DefectComment, mentionIds []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(comment).Error; err != nil {
+			return err
+		}
+		return insertLinks(tx, "defect_comment_mentions", "comment_id", comment.CommentId, "user_id", mentionIds)
+	})
+}
+
+func (r *CommentRepository) GetComment(ctx context.Context, commentId uint) (*models.DefectComment, error) {
+	var model models.DefectComment
+	if err := r.db.WithContext(ctx).
+		Preload("Author").Preload("Mentions").
+		First(&model, "comment_id = ?", commentId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *CommentRepository) UpdateComment(ctx context.Context, commentId uint, body string, isDecision bool, mentionIds []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.DefectComment{}).Where("comment_id = ?", commentId).Updates(map[string]interface{}{
+			"body":        body,
+			"is_decision": isDecision,
+			"edited_at":   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM defect_comment_mentions WHERE comment_id = ?", commentId).Error; err != nil {
+			return err
+		}
+		return insertLinks(tx, "defect_comment_mentions", "comment_id", commentId, "user_id", mentionIds)
+	})
+}
+
+// DeleteComment стирает текст и упоминания, но оставляет запись, чтобы ответы не потеряли ветку
+func (r *CommentRepository) DeleteComment(ctx context.Context, commentId uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.DefectComment{}).Where("comment_id = ?", commentId).Updates(map[string]interface{}{
+			"body":        "",
+			"is_decision": false,
+			"deleted_at":  time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM defect_comment_mentions WHERE comment_id = ?", commentId).Error
+	})
+}
+
+// ListThreads отдает страницу корневых комментариев (новые сверху) вместе со всеми ответами на них
+func (r *CommentRepository) ListThreads(ctx context.Context, defectId uint, page, limit int) ([]entities.Comment, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.DefectComment{}).
+		Where("defect_id = ? AND parent_id IS NULL", defectId)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var roots []models.DefectComment
+	if err := query.
+		Preload("Author").Preload("Mentions").
+		Scopes(Paginate(page, limit)).
+		Order("created_at DESC, comment_id DESC").
+		Find(&roots).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(roots) == 0 {
+		return []entities.Comment{}, total, nil
+	}
+
+	rootIds := make([]uint, 0, len(roots))
+	for _, m := range roots {
+		rootIds = append(rootIds, m.CommentId)
+	}
+	var replies []models.DefectComment
+	if err := r.db.WithContext(ctx).
+		Preload("Author").Preload("Mentions").
+		Where("parent_id IN ?", rootIds).
+		Order("created_at ASC, comment_id ASC").
+		Find(&replies).Error; err != nil {
+		return nil, 0, err
+	}
+
+	byParent := make(map[uint][]entities.Comment, len(roots))
+	for _, m := range replies {
+		byParent[*m.ParentId] = append(byParent[*m.ParentId], CommentToEntity(m))
+	}
+
+	threads := make([]entities.Comment, 0, len(roots))
+	for _, m := range roots {
+		thread := CommentToEntity(m)
+		thread.Replies = byParent[m.CommentId]
+		threads = append(threads, thread)
+	}
+	return threads, total, nil
+}
+
+// ResolveMentions сопоставляет @-упоминания пользователям: по полному email или по его части до @.
+// Часть до @ засчитывается, только если она однозначна.
+func (r *CommentRepository) ResolveMentions(ctx context.Context, handles []string) ([]uint, error) {
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	var users []models.User
+	if err := r.db.WithContext(ctx).
+		Select("user_id", "email").
+		Where("LOWER(email) IN ? OR LOWER(SPLIT_PART(email, '@', 1)) IN ?", handles, handles).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	byEmail := make(map[string]uint, len(users))
+	byLocal := make(map[string][]uint, len(users))
+	for _, u := range users {
+		email := strings.ToLower(u.Email)
+		byEmail[email] = u.UserId
+		local, _, _ := strings.Cut(email, "@")
+		byLocal[local] = append(byLocal[local], u.UserId)
+	}
+
+	seen := make(map[uint]bool, len(handles))
+	ids := make([]uint, 0, len(handles))
+	for _, h := range handles {
+		id, ok := byEmail[h]
+		if !ok && len(byLocal[h]) == 1 {
+			id, ok = byLocal[h][0], true
+		}
+		if ok && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
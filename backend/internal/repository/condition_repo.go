@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+// conditionSettingsId — настройки индекса хранятся одной строкой
+const conditionSettingsId = 1
+
+// conditionEpsilon — изменение индекса меньше этого в историю не пишется
+const conditionEpsilon = 0.05
+
+// ConditionInput — исходные данные индекса состояния одного объекта
+type ConditionInput struct {
+	ObjectId       uint
+	PipelineId     uint
+	MaxOpenRank    int
+	OpenDefects    int
+	Anomalies      int // открытые дефекты с вибрацией от порога
+	Probability    *float64
+	LastInspection *time.Time
+}
+
+type ConditionRepo interface {
+	GetSettings(ctx context.Context) (entities.ConditionSettings, error)
+	SaveSettings(ctx context.Context, settings entities.ConditionSettings) error
+	MaxRank(ctx context.Context) (int, error)
+	ListInputs(ctx context.Context, pipelineId uint, vibrationLimit float64) ([]ConditionInput, error)
+	ListPipelineLengths(ctx context.Context, pipelineId uint) (map[uint]float64, error)
+	SaveConditions(ctx context.Context, entityType string, points map[uint]entities.ConditionPoint) error
+	History(ctx context.Context, entityType string, entityId uint, limit int) ([]entities.ConditionPoint, error)
+	Overview(ctx context.Context, worst int) (*entities.ConditionOverview, error)
+}
+
+type ConditionRepository struct {
+	db *gorm.DB
+}
+
+func NewConditionRepository(db *gorm.DB) *ConditionRepository {
+	return &ConditionRepository{db: db}
+}
+
+func (r *ConditionRepository) GetSettings(ctx context.Context) (entities.ConditionSettings, error) {
+	var m models.ConditionSettings
+	if err := r.db.WithContext(ctx).First(&m, conditionSettingsId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.DefaultConditionSettings, nil
+		}
+		return entities.ConditionSettings{}, err
+	}
+	return ConditionSettingsToEntity(m), nil
+}
+
+func (r *ConditionRepository) SaveSettings(ctx context.Context, settings entities.ConditionSettings) error {
+	m := ConditionSettingsToModel(settings)
+	m.ConditionSettingsId = conditionSettingsId
+	return r.db.WithContext(ctx).Save(&m).Error
+}
+
+// MaxRank — наибольший приоритет в каталоге оценок; 0 — каталог не настроен
+func (r *ConditionRepository) MaxRank(ctx context.Context) (int, error) {
+	var rank int
+	err := r.db.WithContext(ctx).Model(&models.QualityGrade{}).Select("COALESCE(MAX(rank), 0)").Scan(&rank).Error
+	return rank, err
+}
+
+// ListInputs собирает данные для индекса по всем объектам трубопровода (0 — по всем объектам).
+// Считается без области видимости: индекс хранится один на всех пользователей.
+func (r *ConditionRepository) ListInputs(ctx context.Context, pipelineId uint, vibrationLimit float64) ([]ConditionInput, error) {
+	filter, args := "", []interface{}{vibrationLimit, entities.OpenDefectStatuses}
+	if pipelineId != 0 {
+		filter, args = " WHERE objects.pipeline_id = ?", append(args, pipelineId)
+	}
+
+	var rows []ConditionInput
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT objects.object_id, objects.pipeline_id,
+			COALESCE(d.max_rank, 0) AS max_open_rank,
+			COALESCE(d.open_defects, 0) AS open_defects,
+			COALESCE(d.anomalies, 0) AS anomalies,
+			lp.probability,
+			GREATEST(dg.last_date, ins.last_date) AS last_inspection
+		FROM objects
+		LEFT JOIN (
+			SELECT defects.object_id, MAX(COALESCE(qg.rank, 0)) AS max_rank, COUNT(*) AS open_defects,
+				COUNT(*) FILTER (WHERE defects.vibration >= ?) AS anomalies
+			FROM defects
+			LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id
+			WHERE defects.status IN ?
+			GROUP BY defects.object_id) d ON d.object_id = objects.object_id
+		`+latestProbabilityJoin+`
+		LEFT JOIN (SELECT object_id, MAX(date) AS last_date FROM diagnostics GROUP BY object_id) dg
+			ON dg.object_id = objects.object_id
+		LEFT JOIN (SELECT object_id, MAX(date) AS last_date FROM inspections GROUP BY object_id) ins
+			ON ins.object_id = objects.object_id`+filter+`
+		ORDER BY objects.object_id`, args...).Scan(&rows).Error
+	return rows, err
+}
+
+// ListPipelineLengths — длины трубопроводов из паспорта, км; 0 — не задана
+func (r *ConditionRepository) ListPipelineLengths(ctx context.Context, pipelineId uint) (map[uint]float64, error) {
+	var rows []models.Pipeline
+	query := r.db.WithContext(ctx).Select("pipeline_id", "length_km")
+	if pipelineId != 0 {
+		query = query.Where("pipeline_id = ?", pipelineId)
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	lengths := make(map[uint]float64, len(rows))
+	for _, p := range rows {
+		lengths[p.PipelineId] = p.LengthKm
+	}
+	return lengths, nil
+}
+
+// SaveConditions обновляет текущий индекс и пишет в историю только заметные изменения
+func (r *ConditionRepository) SaveConditions(ctx context.Context, entityType string, points map[uint]entities.ConditionPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	table, key := "objects", "object_id"
+	if entityType == entities.ConditionEntityPipeline {
+		table, key = "pipelines", "pipeline_id"
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := make([]uint, 0, len(points))
+		for id := range points {
+			ids = append(ids, id)
+		}
+		var current []struct {
+			Id          uint
+			Condition   *float64
+			ConditionAt *time.Time
+		}
+		if err := tx.Table(table).Select(key+" AS id, condition, condition_at").
+			Where(key+" IN ?", ids).Scan(&current).Error; err != nil {
+			return err
+		}
+		previous := make(map[uint]*float64, len(current))
+		for _, c := range current {
+			if c.ConditionAt != nil {
+				previous[c.Id] = c.Condition
+			}
+		}
+
+		var history []models.ConditionHistory
+		for id, p := range points {
+			if err := tx.Table(table).Where(key+" = ?", id).
+				Updates(map[string]interface{}{"condition": p.Condition, "condition_at": p.ComputedAt}).Error; err != nil {
+				return err
+			}
+			if old := previous[id]; old != nil && math.Abs(*old-p.Condition) < conditionEpsilon {
+				continue
+			}
+			history = append(history, models.ConditionHistory{
+				EntityType: entityType,
+				EntityId:   id,
+				Condition:  p.Condition,
+				Severity:   p.Components.Severity,
+				Density:    p.Components.Density,
+				Risk:       p.Components.Risk,
+				Inspection: p.Components.Inspection,
+				Sensor:     p.Components.Sensor,
+				CreatedAt:  p.ComputedAt,
+			})
+		}
+		if len(history) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&history, 500).Error
+	})
+}
+
+// History — последние записи истории индекса, новые первыми
+func (r *ConditionRepository) History(ctx context.Context, entityType string, entityId uint, limit int) ([]entities.ConditionPoint, error) {
+	var rows []models.ConditionHistory
+	if err := r.db.WithContext(ctx).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityId).
+		Order("created_at DESC, condition_history_id DESC").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	points := make([]entities.ConditionPoint, 0, len(rows))
+	for _, m := range rows {
+		points = append(points, ConditionHistoryToEntity(m))
+	}
+	return points, nil
+}
+
+// Overview — средний индекс, распределение по полосам и худшие трубопроводы в области видимости.
+// Учитываются только действующие трубопроводы с уже посчитанным индексом.
+func (r *ConditionRepository) Overview(ctx context.Context, worst int) (*entities.ConditionOverview, error) {
+	base := func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&models.Pipeline{}).
+			Scopes(scopePipelines(ctx)).
+			Where("pipelines.archived_at IS NULL AND pipelines.condition_at IS NOT NULL")
+	}
+
+	overview := &entities.ConditionOverview{
+		Distribution:   map[string]int64{entities.ConditionGood: 0, entities.ConditionFair: 0, entities.ConditionPoor: 0},
+		WorstPipelines: []entities.PipelineCondition{},
+	}
+	if err := base().Select("AVG(pipelines.condition)").Row().Scan(&overview.Average); err != nil {
+		return nil, err
+	}
+
+	var bands []struct {
+		Band  string
+		Count int64
+	}
+	if err := base().Select(`CASE WHEN pipelines.condition >= ? THEN ? WHEN pipelines.condition >= ? THEN ? ELSE ? END AS band, COUNT(*) AS count`,
+		entities.ConditionGoodFrom, entities.ConditionGood, entities.ConditionFairFrom, entities.ConditionFair, entities.ConditionPoor).
+		Group("band").Scan(&bands).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range bands {
+		overview.Distribution[b.Band] = b.Count
+	}
+
+	var rows []models.Pipeline
+	if err := base().Order("pipelines.condition ASC, pipelines.pipeline_id ASC").Limit(worst).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range rows {
+		overview.WorstPipelines = append(overview.WorstPipelines, entities.PipelineCondition{
+			PipelineId:  p.PipelineId,
+			Name:        p.Name,
+			Condition:   p.Condition,
+			Band:        entities.ConditionBand(p.Condition),
+			ConditionAt: *p.ConditionAt,
+		})
+	}
+	return overview, nil
+}
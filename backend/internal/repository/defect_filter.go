@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = fmt.Errorf("invalid cursor")
+
+// defectSortColumns — SQL-выражения полей сортировки; severity ожидает LEFT JOIN quality_grades qg
+var defectSortColumns = map[string]string{
+	entities.DefectSortDate:      "defects.date",
+	entities.DefectSortDepth:     "defects.depth",
+	entities.DefectSortLength:    "defects.length",
+	entities.DefectSortVibration: "defects.vibration",
+	entities.DefectSortSeverity:  "COALESCE(qg.rank, 0)",
+	entities.DefectSortId:        "defects.defect_id",
+	// дефекты без привязки к трассе — в начале по возрастанию
+	entities.DefectSortChainage: "COALESCE(defects.chainage_km, -1)",
+}
+
+// applyDefectFilter — условия DefectFilter без сортировки и пагинации.
+// Ожидает JOIN objects и LEFT JOIN quality_grades qg; join типов дефектов добавляет сам, если нужен поиск.
+func applyDefectFilter(query *gorm.DB, f entities.DefectFilter) *gorm.DB {
+	if f.Search != "" {
+		match, args := DefectMatch(f.Search)
+		query = query.Joins("LEFT JOIN defect_types dt ON dt.defect_type_id = defects.defect_type_id").
+			Where(match, args...)
+	}
+	if len(f.PipelineIds) > 0 {
+		query = query.Where("objects.pipeline_id IN ?", f.PipelineIds)
+	}
+	if len(f.ObjectIds) > 0 {
+		query = query.Where("defects.object_id IN ?", f.ObjectIds)
+	}
+	if len(f.Severities) > 0 {
+		query = query.Where("COALESCE(qg.rank, 0) IN ?", f.Severities)
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("defects.status IN ?", f.Statuses)
+	}
+	if len(f.DefectTypeIds) > 0 {
+		query = query.Where("defects.defect_type_id IN ?", f.DefectTypeIds)
+	}
+	if len(f.Methods) > 0 {
+		query = query.Where(`EXISTS (
+			SELECT 1 FROM diagnostics dg JOIN methods m ON m.method_id = dg.method_id
+			WHERE dg.object_id = defects.object_id AND m.method_name IN ?)`, f.Methods)
+	}
+	if len(f.Materials) > 0 {
+		query = query.Where("objects.material IN ?", f.Materials)
+	}
+
+	if f.BBox != nil {
+		query = query.Where("defects.lon BETWEEN ? AND ? AND defects.lat BETWEEN ? AND ?",
+			f.BBox.MinLon, f.BBox.MaxLon, f.BBox.MinLat, f.BBox.MaxLat)
+	}
+	if len(f.Polygon) >= 3 {
+		query = query.Where("ST_Covers(ST_GeomFromText(?, 4326), ST_SetSRID(ST_MakePoint(defects.lon, defects.lat), 4326))",
+			polygonWKT(f.Polygon))
+	}
+
+	query = applyRange(query, "defects.depth", f.Depth)
+	query = applyRange(query, "defects.length", f.Length)
+	query = applyRange(query, "defects.vibration", f.Vibration)
+	query = applyRange(query, "defects.chainage_km", f.Chainage)
+
+	if !f.DateFrom.IsZero() {
+		query = query.Where("defects.date >= ?", f.DateFrom)
+	}
+	if !f.DateTo.IsZero() {
+		query = query.Where("defects.date <= ?", f.DateTo)
+	}
+	return query
+}
+
+func applyRange(query *gorm.DB, column string, r entities.FloatRange) *gorm.DB {
+	if r.Min != nil {
+		query = query.Where(column+" >= ?", *r.Min)
+	}
+	if r.Max != nil {
+		query = query.Where(column+" <= ?", *r.Max)
+	}
+	return query
+}
+
+// polygonWKT собирает WKT из точек и замыкает кольцо, если клиент этого не сделал
+func polygonWKT(points []entities.GeoPoint) string {
+	if points[0] != points[len(points)-1] {
+		points = append(points, points[0])
+	}
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = strconv.FormatFloat(p.Lon, 'f', -1, 64) + " " + strconv.FormatFloat(p.Lat, 'f', -1, 64)
+	}
+	return "POLYGON((" + strings.Join(coords, ", ") + "))"
+}
+
+// defectSort — поле сортировки по умолчанию: сначала свежие
+func defectSort(f entities.DefectFilter) (string, bool) {
+	if f.Sort == "" {
+		return entities.DefectSortDate, true
+	}
+	return f.Sort, f.Desc
+}
+
+// defectOrder — ORDER BY с defect_id вторым ключом, чтобы порядок был строгим и курсор однозначным
+func defectOrder(f entities.DefectFilter) string {
+	sort, desc := defectSort(f)
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("%s %s, defects.defect_id %s", defectSortColumns[sort], dir, dir)
+}
+
+// defectKeyset — условие "после курсора" в порядке defectOrder
+func defectKeyset(f entities.DefectFilter) (string, []interface{}, error) {
+	cursor, err := entities.DecodeDefectCursor(f.Cursor)
+	if err != nil {
+		return "", nil, ErrInvalidCursor
+	}
+	sort, desc := defectSort(f)
+	if cursor.Sort != sort {
+		return "", nil, ErrInvalidCursor
+	}
+
+	var value interface{}
+	if sort == entities.DefectSortDate {
+		value, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	} else {
+		value, err = strconv.ParseFloat(cursor.Value, 64)
+	}
+	if err != nil {
+		return "", nil, ErrInvalidCursor
+	}
+
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	cond := fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND defects.defect_id %[2]s ?))", defectSortColumns[sort], op)
+	return cond, []interface{}{value, value, cursor.Id}, nil
+}
+
+// defectCursor — курсор на дефект d для следующей страницы
+func defectCursor(f entities.DefectFilter, d models.Defect) string {
+	sort, _ := defectSort(f)
+	var value string
+	switch sort {
+	case entities.DefectSortDate:
+		value = d.Date.UTC().Format(time.RFC3339Nano)
+	case entities.DefectSortDepth:
+		value = strconv.FormatFloat(d.Depth, 'g', -1, 64)
+	case entities.DefectSortLength:
+		value = strconv.FormatFloat(d.Length, 'g', -1, 64)
+	case entities.DefectSortVibration:
+		value = strconv.FormatFloat(d.Vibration, 'g', -1, 64)
+	case entities.DefectSortSeverity:
+		value = strconv.Itoa(d.QualityGrade.Rank)
+	case entities.DefectSortId:
+		value = strconv.FormatUint(uint64(d.DefectId), 10)
+	case entities.DefectSortChainage:
+		value = "-1"
+		if d.ChainageKm != nil {
+			value = strconv.FormatFloat(*d.ChainageKm, 'g', -1, 64)
+		}
+	}
+	return entities.EncodeDefectCursor(entities.DefectCursor{Sort: sort, Value: value, Id: d.DefectId})
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+)
+
+func TestDefectCursorRoundTrip(t *testing.T) {
+	chainage := 12.375
+	date := time.Date(2024, 3, 5, 10, 30, 15, 123456789, time.FixedZone("ALMT", 5*3600))
+	defect := models.Defect{
+		DefectId:     42,
+		Date:         date,
+		Depth:        3.81,
+		Length:       152.4,
+		Vibration:    0.1,
+		ChainageKm:   &chainage,
+		QualityGrade: models.QualityGrade{Rank: 3},
+	}
+
+	tests := []struct {
+		sort string
+		desc bool
+		want interface{}
+	}{
+		{"", true, date.UTC()},
+		{entities.DefectSortDate, false, date.UTC()},
+		{entities.DefectSortDepth, true, 3.81},
+		{entities.DefectSortLength, false, 152.4},
+		{entities.DefectSortVibration, false, 0.1},
+		{entities.DefectSortSeverity, true, 3.0},
+		{entities.DefectSortId, false, 42.0},
+		{entities.DefectSortChainage, false, 12.375},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			f := entities.DefectFilter{Sort: tt.sort, Desc: tt.desc}
+			f.Cursor = defectCursor(f, defect)
+
+			cond, args, err := defectKeyset(f)
+			if err != nil {
+				t.Fatalf("defectKeyset: %v", err)
+			}
+			op := ">"
+			if tt.desc {
+				op = "<"
+			}
+			if !strings.Contains(cond, op+" ?") {
+				t.Errorf("cond %q does not use %s", cond, op)
+			}
+			if len(args) != 3 {
+				t.Fatalf("args = %v, want value, value, id", args)
+			}
+			if got, ok := args[0].(time.Time); ok {
+				if !got.Equal(tt.want.(time.Time)) {
+					t.Errorf("value = %v, want %v", got, tt.want)
+				}
+			} else if args[0] != tt.want {
+				t.Errorf("value = %v, want %v", args[0], tt.want)
+			}
+			if args[2] != defect.DefectId {
+				t.Errorf("id = %v, want %d", args[2], defect.DefectId)
+			}
+		})
+	}
+}
+
+// Дефект без пикета при сортировке по пикету кодируется как -1 — так же, как COALESCE в ORDER BY
+func TestDefectCursorNoChainage(t *testing.T) {
+	f := entities.DefectFilter{Sort: entities.DefectSortChainage}
+	f.Cursor = defectCursor(f, models.Defect{DefectId: 7})
+
+	_, args, err := defectKeyset(f)
+	if err != nil {
+		t.Fatalf("defectKeyset: %v", err)
+	}
+	if args[0] != -1.0 {
+		t.Errorf("value = %v, want -1", args[0])
+	}
+}
+
+func TestDefectKeysetInvalidCursor(t *testing.T) {
+	depthCursor := defectCursor(entities.DefectFilter{Sort: entities.DefectSortDepth}, models.Defect{DefectId: 1, Depth: 2})
+
+	tests := []struct {
+		name   string
+		filter entities.DefectFilter
+	}{
+		{"not base64", entities.DefectFilter{Cursor: "%%%"}},
+		{"not json", entities.DefectFilter{Cursor: "bm90IGpzb24"}},
+		{"no id", entities.DefectFilter{Cursor: entities.EncodeDefectCursor(entities.DefectCursor{Sort: entities.DefectSortDate, Value: "2024-01-01T00:00:00Z"})}},
+		{"other sort", entities.DefectFilter{Sort: entities.DefectSortLength, Cursor: depthCursor}},
+		{"bad value", entities.DefectFilter{Sort: entities.DefectSortDepth, Cursor: entities.EncodeDefectCursor(entities.DefectCursor{Sort: entities.DefectSortDepth, Value: "deep", Id: 1})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := defectKeyset(tt.filter); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -2,10 +2,19 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
 	"github.com/rwrrioe/integrity/backend/internal/repository/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDefectNotFound = fmt.Errorf("defect not found")
+	ErrDefectInUse    = fmt.Errorf("defect has attachments or pressure restrictions")
 )
 
 func Paginate(page, limit int) func(db *gorm.DB) *gorm.DB {
@@ -13,17 +22,22 @@ func Paginate(page, limit int) func(db *gorm.DB) *gorm.DB {
 		if page <= 0 {
 			page = 1
 		}
-		switch {
-		case limit > 100:
-			limit = 100
-		case limit <= 0:
-			limit = 10
-		}
+		limit = clampLimit(limit)
 		offset := (page - 1) * limit
 		return db.Offset(offset).Limit(limit)
 	}
 }
 
+func clampLimit(limit int) int {
+	switch {
+	case limit > 100:
+		return 100
+	case limit <= 0:
+		return 10
+	}
+	return limit
+}
+
 type DefectRepo interface {
 	ListByDate(ctx context.Context, date1 string, date2 string) (*[]entities.Defect, error)
 	ListByYear(ctx context.Context, year int) (*[]entities.Defect, error)
@@ -39,7 +53,23 @@ type DefectRepo interface {
 	CountCriticality(ctx context.Context) (*[]entities.DefectStateMetrics, error)
 	PrepareHeatmap(ctx context.Context) (*entities.Heatmap, error)
 	CountByStatus(ctx context.Context, pipelineId uint, status string) (*PipeCount, error)
-	List(ctx context.Context, f entities.DefectFilter) ([]entities.Defect, int64, error)
+	CountStatuses(ctx context.Context, pipelineId uint) (map[string]int64, error)
+	LockDefect(ctx context.Context, defectId uint) (*models.Defect, error)
+	UpdateDefectFields(ctx context.Context, defectId uint, fields map[string]interface{}) error
+	AddStatusHistory(ctx context.Context, change *models.DefectStatusHistory) error
+	ListStatusHistory(ctx context.Context, defectId uint) ([]entities.DefectStatusChange, error)
+	CreateDefect(ctx context.Context, defect *models.Defect) error
+	DeleteDefect(ctx context.Context, defectId uint) error
+	ObjectExists(ctx context.Context, objectId uint) (bool, error)
+	DefectTypeExists(ctx context.Context, defectTypeId uint) (bool, error)
+	QualityGradeExists(ctx context.Context, qualityGradeId uint) (bool, error)
+	CountEmployees(ctx context.Context, employeeIds []uint) (int64, error)
+	WallThickness(ctx context.Context, objectId uint) (float64, error)
+	AddMeasurement(ctx context.Context, measurement *models.DefectMeasurement) error
+	ListMeasurements(ctx context.Context, defectIds []uint) (map[uint][]entities.DefectMeasurement, error)
+	FindTrack(ctx context.Context, objectId, defectTypeId uint, lat, lon, radius float64, measuredAt time.Time) (*models.Defect, error)
+	List(ctx context.Context, f entities.DefectFilter) (*entities.DefectPage, error)
+	ListIds(ctx context.Context, f entities.DefectFilter, max int) ([]uint, int64, error)
 	GetPipelineStats(ctx context.Context, pipelineId uint) (*entities.PipelineStats, error)
 	ListByPipeline(ctx context.Context, pipelineId uint, page, limit int) ([]entities.Defect, int64, error)
 	ListObjectsByPipeline(ctx context.Context, pipelineId uint, page, limit int) ([]entities.Object, int64, error)
@@ -67,35 +97,25 @@ func (r *DefectRepository) GetHeatmapPoints(ctx context.Context, f entities.Defe
 	var results []entities.HeatPoint
 
 	// Используем кастомный SELECT для скорости и сразу маппим в структуру
-	// Нам нужны координаты и severity (quality_grade_id) для расчета веса
+	// Вес точки берется из каталога оценок (quality_grades.heat_weight)
 	// В GORM можно сканировать в структуру, если имена полей совпадают (или через alias)
 
 	query := r.db.WithContext(ctx).Table("defects").
-		Select("defects.defect_id, defects.lat, defects.lon, defects.quality_grade_id").
-		Joins("JOIN objects ON defects.object_id = objects.object_id")
+		Select("defects.defect_id, defects.defect_type_id, defects.lat, defects.lon, " + gradeWeight + " AS weight").
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Scopes(ScopeObjects(ctx, "objects"))
 
-	// --- КОПИРУЕМ ФИЛЬТРЫ (как в List) ---
-	if f.PipelineID > 0 {
-		query = query.Where("objects.pipeline_id = ?", f.PipelineID)
-	}
-	if !f.DateFrom.IsZero() {
-		query = query.Where("defects.date >= ?", f.DateFrom)
-	}
-	if !f.DateTo.IsZero() {
-		query = query.Where("defects.date <= ?", f.DateTo)
-	}
-	if f.Severity != 0 {
-		query = query.Where("defects.quality_grade_id = ?", f.Severity)
-	}
+	// Те же фильтры, что у списка; сортировка и пагинация карте не нужны
+	query = applyDefectFilter(query, f)
 
 	// Выполняем запрос без Limit/Offset (нам нужны все точки для карты)
-	// Сканируем во временную структуру, чтобы потом рассчитать Weight в Go
 	type tempPoint struct {
-		DefectId       uint
-		DefectTypeId   uint
-		Lat            float64
-		Lon            float64
-		QualityGradeId uint
+		DefectId     uint
+		DefectTypeId uint
+		Lat          float64
+		Lon          float64
+		Weight       float64
 	}
 	var rows []tempPoint
 
@@ -103,29 +123,12 @@ func (r *DefectRepository) GetHeatmapPoints(ctx context.Context, f entities.Defe
 		return nil, err
 	}
 
-	// Преобразуем в HeatPoint и считаем вес
 	for _, row := range rows {
-		var weight float64
-
-		// Логика веса в зависимости от severity (IDs из твоего списка)
-		switch row.QualityGradeId {
-		case 5: // "недопустимо" -> Максимально красный
-			weight = 1.0
-		case 4: // "требует_мер" -> Оранжевый
-			weight = 0.7
-		case 6: // "допустимо" -> Желтый
-			weight = 0.4
-		case 7: // "удовлетворительно" -> Зеленоватый/Тусклый
-			weight = 0.2
-		default:
-			weight = 0.1
-		}
-
 		results = append(results, entities.HeatPoint{
 			Id:     row.DefectId,
 			Lat:    row.Lat,
 			Lon:    row.Lon,
-			Weight: weight,
+			Weight: row.Weight,
 			Class:  row.DefectTypeId,
 		})
 	}
@@ -142,6 +145,7 @@ func (r *DefectRepository) ListByPipeline(ctx context.Context, pipelineId uint,
 		Model(&models.Defect{}).
 		Joins("JOIN objects ON defects.object_id = objects.object_id").
 		Where("objects.pipeline_id = ?", pipelineId).
+		Scopes(ScopeObjects(ctx, "objects")).
 		Preload("Object").      // Подгружаем данные объекта
 		Preload("DefectType").  // Подгружаем тип
 		Preload("QualityGrade") // Подгружаем степень серьезности
@@ -173,7 +177,8 @@ func (r *DefectRepository) ListObjectsByPipeline(ctx context.Context, pipelineId
 
 	query := r.db.WithContext(ctx).
 		Model(&models.Object{}).
-		Where("pipeline_id = ?", pipelineId)
+		Where("pipeline_id = ?", pipelineId).
+		Scopes(ScopeObjects(ctx, "objects"))
 
 	// 1. Считаем общее количество
 	if err := query.Count(&total).Error; err != nil {
@@ -204,6 +209,7 @@ func (r *DefectRepository) GetPipelineStats(ctx context.Context, pipelineId uint
 	// 1. Total Objects
 	if err := r.db.WithContext(ctx).Model(&models.Object{}).
 		Where("pipeline_id = ?", pipelineId).
+		Scopes(ScopeObjects(ctx, "objects")).
 		Count(&stats.TotalObjects).Error; err != nil {
 		return nil, err
 	}
@@ -212,107 +218,124 @@ func (r *DefectRepository) GetPipelineStats(ctx context.Context, pipelineId uint
 	if err := r.db.WithContext(ctx).Model(&models.Defect{}).
 		Joins("JOIN objects ON defects.object_id = objects.object_id").
 		Where("objects.pipeline_id = ?", pipelineId).
+		Scopes(ScopeObjects(ctx, "objects")).
 		Count(&stats.TotalDefects).Error; err != nil {
 		return nil, err
 	}
 
-	// 3. Critical Issues (Critical + High)
-	// Проверь ID статусов в своей БД. Обычно это 3 и 4.
-	criticalIDs := []int{4, 5}
+	// 3. Critical Issues (Critical + High) — по приоритету оценки из каталога
 	if err := r.db.WithContext(ctx).Model(&models.Defect{}).
 		Joins("JOIN objects ON defects.object_id = objects.object_id").
-		Where("objects.pipeline_id = ? AND defects.quality_grade_id IN (?)", pipelineId, criticalIDs).
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Where("objects.pipeline_id = ? AND ("+gradeCritical+" OR "+gradePriority+" = ?)", pipelineId, entities.PriorityHigh).
+		Scopes(ScopeObjects(ctx, "objects")).
 		Count(&stats.CriticalIssues).Error; err != nil {
 		return nil, err
 	}
 
-	// 4. Распределение по статусам (Created, Processing, Solved)
-	// Используем GROUP BY для эффективности
-	type statusResult struct {
-		Status string
-		Cnt    int64
-	}
-	var results []statusResult
-
-	err := r.db.WithContext(ctx).Model(&models.Defect{}).
-		Select("defects.status, count(*) as cnt").
-		Joins("JOIN objects ON defects.object_id = objects.object_id").
-		Where("objects.pipeline_id = ?", pipelineId).
-		Group("defects.status").
-		Scan(&results).Error
-
+	// 4. Распределение по статусам жизненного цикла
+	distribution, err := r.CountStatuses(ctx, pipelineId)
 	if err != nil {
 		return nil, err
 	}
-
-	// Заполняем map. Если статуса нет в БД, он просто не попадет в map (или можно инициализировать нулями)
-	// Инициализируем нулями, чтобы на фронте не было undefined
-	stats.StatusDistribution["Created"] = 0
-	stats.StatusDistribution["Processing"] = 0
-	stats.StatusDistribution["Solved"] = 0
-
-	for _, res := range results {
-		stats.StatusDistribution[res.Status] = res.Cnt
-	}
+	stats.StatusDistribution = distribution
 
 	return stats, nil
 }
 
-func (r *DefectRepository) List(ctx context.Context, f entities.DefectFilter) ([]entities.Defect, int64, error) {
-	var defects []entities.Defect
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&entities.Defect{}).
-		Preload("Object").
-		Preload("Pipeline")
+// List — страница дефектов по фильтру: постранично (Page) или по курсору (Cursor)
+func (r *DefectRepository) List(ctx context.Context, f entities.DefectFilter) (*entities.DefectPage, error) {
+	var dbDefects []models.Defect
+	limit := clampLimit(f.Limit)
 
-	// Применяем фильтры
-	if f.Search != "" {
-		query = query.Where("defect_type_id = ?", "%"+f.Search+"%")
+	query := r.db.WithContext(ctx).Model(&models.Defect{}).
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Scopes(ScopeObjects(ctx, "objects"))
+	query = applyDefectFilter(query, f)
+
+	page := &entities.DefectPage{}
+	if f.Cursor == "" {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		page.Total = &total
 	}
-	if f.PipelineID != 0 {
-		query = query.Where("pipeline_id = ?", f.PipelineID)
+
+	if f.Cursor != "" {
+		cond, args, err := defectKeyset(f)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(cond, args...).Limit(limit + 1)
+	} else {
+		query = query.Scopes(Paginate(f.Page, limit)).Limit(limit + 1)
 	}
 
-	if !f.DateFrom.IsZero() {
-		query = query.Where("date >= ?", f.DateFrom)
+	// лишняя запись только показывает, что есть следующая страница
+	if err := query.Preload("Object").Preload("DefectType").Preload("QualityGrade").
+		Order(defectOrder(f)).
+		Find(&dbDefects).Error; err != nil {
+		return nil, err
 	}
-	if !f.DateTo.IsZero() {
-		query = query.Where("date <= ?", f.DateTo)
+	if len(dbDefects) > limit {
+		dbDefects = dbDefects[:limit]
+		page.NextCursor = defectCursor(f, dbDefects[limit-1])
 	}
 
-	// Считаем общее количество (для пагинации)
-	query.Count(&total)
+	page.Data = make([]entities.Defect, 0, len(dbDefects))
+	for _, d := range dbDefects {
+		page.Data = append(page.Data, DefectToEntity(d))
+	}
+	return page, nil
+}
+
+// ListIds — id дефектов по фильтру в порядке его сортировки, не больше max; total — сколько подходит всего
+func (r *DefectRepository) ListIds(ctx context.Context, f entities.DefectFilter, max int) ([]uint, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Defect{}).
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Scopes(ScopeObjects(ctx, "objects"))
+	query = applyDefectFilter(query, f)
 
-	// Пагинация
-	offset := (f.Page - 1) * f.Limit
-	err := query.Order("date DESC").Offset(offset).Limit(f.Limit).Find(&defects).Error
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
-	return defects, total, err
+	var ids []uint
+	if err := query.Order(defectOrder(f)).Limit(max).Pluck("defects.defect_id", &ids).Error; err != nil {
+		return nil, 0, err
+	}
+	return ids, total, nil
 }
 
 func (r *DefectRepository) PrepareHeatmap(ctx context.Context) (*entities.Heatmap, error) {
-	var defects []models.Defect
+	type heatRow struct {
+		DefectId     uint
+		DefectTypeId uint
+		Lat          float64
+		Lon          float64
+		Depth        float64
+		Vibration    float64
+		Weight       float64
+	}
+	var rows []heatRow
 
-	if err := r.db.WithContext(ctx).Select("defect_id,lat, lon, defect_type_id, quality_grade_id").Find(&defects).Error; err != nil {
+	if err := r.db.WithContext(ctx).Table("defects").
+		Select("defects.defect_id, defects.lat, defects.lon, defects.defect_type_id, defects.depth, defects.vibration, " + gradeWeight + " AS weight").
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		Scan(&rows).Error; err != nil {
 		return nil, err
 	}
 
-	points := make([]entities.HeatPoint, 0, len(defects))
-
-	for _, d := range defects {
-		var weight float64
-		switch d.QualityGradeId {
-		case 4:
-			weight = 1.0
-		case 3:
-			weight = 0.8
-		case 2:
-			weight = 0.5
-		default:
-			weight = 0.2
-		}
+	points := make([]entities.HeatPoint, 0, len(rows))
 
+	for _, d := range rows {
+		weight := d.Weight
 		if d.Depth > 3.0 || d.Vibration > 8.0 {
 			weight = 1.0
 		}
@@ -336,6 +359,8 @@ func (r *DefectRepository) ListImportantTypes(ctx context.Context, num int) (*[]
 	var stats []DefectStateModel
 	if err := r.db.WithContext(ctx).Table("defects").
 		Select("defect_type_id, COUNT(*) as count").
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
 		Group("defect_type_id").
 		Order("count DESC").
 		Limit(5).
@@ -359,7 +384,10 @@ func (r *DefectRepository) ListImportantTypes(ctx context.Context, num int) (*[]
 func (r *DefectRepository) ListByDate(ctx context.Context, date1 string, date2 string) (*[]entities.Defect, error) {
 	var models []models.Defect
 
-	if err := r.db.WithContext(ctx).Where("date >=? AND date<=?", date1, date2).Limit(10).Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		Where("defects.date >=? AND defects.date<=?", date1, date2).Limit(10).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -374,7 +402,10 @@ func (r *DefectRepository) ListByDate(ctx context.Context, date1 string, date2 s
 func (r *DefectRepository) ListByType(ctx context.Context, defectTypeId uint) (*[]entities.Defect, error) {
 	var models []models.Defect
 
-	if err := r.db.WithContext(ctx).Where("defect_type_id=?", defectTypeId).Limit(10).Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		Where("defects.defect_type_id = ?", defectTypeId).Limit(10).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -386,10 +417,15 @@ func (r *DefectRepository) ListByType(ctx context.Context, defectTypeId uint) (*
 	return &defects, nil
 }
 
+// ListByMethod — дефекты объектов, обследованных методом с заданным названием
 func (r *DefectRepository) ListByMethod(ctx context.Context, method string) (*[]entities.Defect, error) {
 	var models []models.Defect
 
-	if err := r.db.WithContext(ctx).Where("method=?", method).Limit(10).Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		Where(`EXISTS (SELECT 1 FROM diagnostics dg JOIN methods m ON m.method_id = dg.method_id
+			WHERE dg.object_id = defects.object_id AND m.method_name = ?)`, method).Limit(10).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -401,10 +437,36 @@ func (r *DefectRepository) ListByMethod(ctx context.Context, method string) (*[]
 	return &defects, nil
 }
 
+// ListByDepth — объекты, у которых есть дефект заданной глубины
+func (r *DefectRepository) ListByDepth(ctx context.Context, depth int) (*[]entities.Object, error) {
+	var models []models.Object
+
+	if err := r.db.WithContext(ctx).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Where("EXISTS (SELECT 1 FROM defects d WHERE d.object_id = objects.object_id AND d.depth = ?)", depth).
+		Limit(10).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	var objects []entities.Object
+	for _, model := range models {
+		object := ObjectToEntity(model)
+		objects = append(objects, object)
+	}
+	return &objects, nil
+}
+
 func (r *DefectRepository) GetAvgImportanceByObject(ctx context.Context, objectId uint) (float64, error) {
 	var avgImp float64
 
-	if err := r.db.Select("ROUND(AVG(quality_grade_id), 2)").Where("object_id=?", objectId).Table("defects").Find(&avgImp).Error; err != nil {
+	// средняя тяжесть — по рангу оценки из каталога; оценки вне каталога (rank 0) не учитываются
+	if err := r.db.WithContext(ctx).Table("defects").
+		Select("COALESCE(ROUND(AVG(NULLIF(qg.rank, 0)), 2), 0)").
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Where("defects.object_id = ?", objectId).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Scan(&avgImp).Error; err != nil {
 		return 0.0, err
 	}
 	return avgImp, nil
@@ -413,11 +475,12 @@ func (r *DefectRepository) GetAvgImportanceByObject(ctx context.Context, objectI
 func (r *DefectRepository) GetAvgImportanceByPipeline(ctx context.Context, pipelineId uint) (float64, error) {
 	var avgImp float64
 
-	if err := r.db.Model(&models.Defect{}).
+	if err := r.db.WithContext(ctx).Model(&models.Defect{}).
 		Joins("JOIN objects ON defects.object_id = objects.object_id").
-		Joins("JOIN pipelines ON objects.pipeline_id = pipelines.pipelined_id").
-		Where("pipelines.pipeline_id=?", pipelineId).
-		Select("ROUND(AVG(defects.quality_grade_id, 2)").
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Where("objects.pipeline_id = ?", pipelineId).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Select("COALESCE(ROUND(AVG(NULLIF(qg.rank, 0)), 2), 0)").
 		Scan(&avgImp).Error; err != nil {
 		return 0.0, err
 	}
@@ -427,11 +490,12 @@ func (r *DefectRepository) GetAvgImportanceByPipeline(ctx context.Context, pipel
 func (r *DefectRepository) CountByStatus(ctx context.Context, pipelineId uint, status string) (*PipeCount, error) {
 	var counts PipeCount
 
-	if err := r.db.Model(&models.Defect{}).
-		Select("pipelines.name AS pipe_name, COUNT(status) AS count").
+	if err := r.db.WithContext(ctx).Model(&models.Defect{}).
+		Select("pipelines.name AS pipe_name, COUNT(*) AS count").
 		Joins("JOIN objects ON defects.object_id = objects.object_id").
-		Joins("JOIN pipelines ON objects.pipeline_id = pipelines.pipelined_id").
-		Where("defects.status=?", status).
+		Joins("JOIN pipelines ON objects.pipeline_id = pipelines.pipeline_id").
+		Where("objects.pipeline_id = ? AND defects.status = ?", pipelineId, status).
+		Scopes(ScopeObjects(ctx, "objects")).
 		Group("pipelines.name").
 		Scan(&counts).Error; err != nil {
 		return nil, err
@@ -439,19 +503,33 @@ func (r *DefectRepository) CountByStatus(ctx context.Context, pipelineId uint, s
 	return &counts, nil
 }
 
-func (r *DefectRepository) ListByDepth(ctx context.Context, depth int) (*[]entities.Object, error) {
-	var models []models.Object
+// CountStatuses считает дефекты трубопровода по статусам; отсутствующие статусы возвращаются с нулем,
+// чтобы на фронте не было undefined
+func (r *DefectRepository) CountStatuses(ctx context.Context, pipelineId uint) (map[string]int64, error) {
+	type statusResult struct {
+		Status string
+		Cnt    int64
+	}
+	var results []statusResult
 
-	if err := r.db.WithContext(ctx).Where("depth=?", depth).Limit(10).Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&models.Defect{}).
+		Select("defects.status, count(*) as cnt").
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Where("objects.pipeline_id = ?", pipelineId).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Group("defects.status").
+		Scan(&results).Error; err != nil {
 		return nil, err
 	}
 
-	var objects []entities.Object
-	for _, model := range models {
-		defect := ObjectToEntity(model)
-		objects = append(objects, defect)
+	distribution := make(map[string]int64, len(entities.DefectStatuses))
+	for _, status := range entities.DefectStatuses {
+		distribution[status] = 0
 	}
-	return &objects, nil
+	for _, res := range results {
+		distribution[res.Status] = res.Cnt
+	}
+	return distribution, nil
 }
 
 func (r *DefectRepository) AssignEmployees(ctx context.Context, defectId uint, employeeIds []uint) error {
@@ -461,12 +539,19 @@ func (r *DefectRepository) AssignEmployees(ctx context.Context, defectId uint, e
 		employees[i] = models.Employee{EmployeeId: id}
 	}
 
-	return r.db.WithContext(ctx).Model(&models.Defect{DefectId: defectId}).Association("Employees").Replace(employeeIds)
+	return r.db.WithContext(ctx).Model(&models.Defect{DefectId: defectId}).Association("Employees").Replace(employees)
 }
 
 func (r *DefectRepository) GetDefect(ctx context.Context, defectId uint) (*entities.Defect, error) {
 	var model models.Defect
-	if err := r.db.WithContext(ctx).First(&model, "defect_id=?", defectId).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		Preload("Object").Preload("DefectType").Preload("QualityGrade").
+		First(&model, "defects.defect_id=?", defectId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDefectNotFound
+		}
 		return nil, err
 	}
 
@@ -474,9 +559,16 @@ func (r *DefectRepository) GetDefect(ctx context.Context, defectId uint) (*entit
 	return &defect, nil
 }
 
+// FindNearestEmployees — ближайшие к дефекту сотрудники; дефект вне области видимости считается несуществующим
 func (r *DefectRepository) FindNearestEmployees(ctx context.Context, defectId uint, num int) (*[]entities.Employee, error) {
 	var defect models.Defect
-	if err := r.db.WithContext(ctx).First(&defect, "defect_id=?", defectId).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		First(&defect, "defects.defect_id = ?", defectId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDefectNotFound
+		}
 		return nil, err
 	}
 
@@ -505,9 +597,12 @@ func (r *DefectRepository) CountCriticality(ctx context.Context) (*[]entities.De
 
 	if err := r.db.WithContext(ctx).
 		Table("defects").
-		Select("quality_grade_id AS name, COUNT(*) AS count").
-		Group("quality_grade_id").
-		Order("count DESC").
+		Select("COALESCE(qg.quality_grade, '') AS name, COUNT(*) AS count").
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		Group("qg.quality_grade, qg.rank").
+		Order("qg.rank DESC").
 		Scan(&metrics).Error; err != nil {
 		return nil, err
 	}
@@ -522,3 +617,195 @@ func (r *DefectRepository) CountCriticality(ctx context.Context) (*[]entities.De
 	}
 	return &byCriticality, nil
 }
+
+// Transaction выполняет fn в одной транзакции: все вызовы репозитория tx внутри fn идут через нее
+func (r *DefectRepository) Transaction(ctx context.Context, fn func(tx *DefectRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(r.WithTx(tx))
+	})
+}
+
+// WithTx возвращает репозиторий, работающий внутри уже открытой транзакции
+func (r *DefectRepository) WithTx(tx *gorm.DB) *DefectRepository {
+	return &DefectRepository{db: tx}
+}
+
+// LockDefect читает дефект с блокировкой строки (SELECT ... FOR UPDATE) — вызывать внутри Transaction
+func (r *DefectRepository) LockDefect(ctx context.Context, defectId uint) (*models.Defect, error) {
+	var model models.Defect
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "defects"}}).
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		First(&model, "defects.defect_id = ?", defectId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDefectNotFound
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *DefectRepository) UpdateDefectFields(ctx context.Context, defectId uint, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.Defect{}).Where("defect_id = ?", defectId).Updates(fields).Error
+}
+
+func (r *DefectRepository) AddStatusHistory(ctx context.Context, change *models.DefectStatusHistory) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+func (r *DefectRepository) ListStatusHistory(ctx context.Context, defectId uint) ([]entities.DefectStatusChange, error) {
+	var rows []models.DefectStatusHistory
+	if err := r.db.WithContext(ctx).
+		Where("defect_id = ?", defectId).
+		Order("created_at ASC, history_id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	changes := make([]entities.DefectStatusChange, 0, len(rows))
+	for _, m := range rows {
+		changes = append(changes, StatusHistoryToEntity(m))
+	}
+	return changes, nil
+}
+
+func (r *DefectRepository) CreateDefect(ctx context.Context, defect *models.Defect) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(defect).Error
+}
+
+// DeleteDefect удаляет дефект вместе с назначениями, связями с нарядами, замерами, обсуждением и историей статусов.
+// Дефект с вложениями или ограничениями давления не удаляется (ErrDefectInUse) — как и объект в DeleteObject.
+func (r *DefectRepository) DeleteDefect(ctx context.Context, defectId uint) error {
+	return r.Transaction(ctx, func(tx *DefectRepository) error {
+		var linked bool
+		if err := tx.db.WithContext(ctx).Raw(`SELECT EXISTS (SELECT 1 FROM attachments WHERE owner_type = ? AND owner_id = ?)
+			OR EXISTS (SELECT 1 FROM pressure_restrictions WHERE defect_id = ?)`,
+			entities.AttachmentOwnerDefect, defectId, defectId).
+			Scan(&linked).Error; err != nil {
+			return err
+		}
+		if linked {
+			return ErrDefectInUse
+		}
+
+		if err := tx.db.WithContext(ctx).Exec(
+			"DELETE FROM defect_comment_mentions WHERE comment_id IN (SELECT comment_id FROM defect_comments WHERE defect_id = ?)", defectId).Error; err != nil {
+			return err
+		}
+		for _, table := range []string{"defect_employees", "work_order_defects", "defect_measurements", "defect_comments"} {
+			if err := tx.db.WithContext(ctx).Exec("DELETE FROM "+table+" WHERE defect_id = ?", defectId).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.db.WithContext(ctx).Where("defect_id = ?", defectId).Delete(&models.DefectStatusHistory{}).Error; err != nil {
+			return err
+		}
+
+		res := tx.db.WithContext(ctx).Where("defect_id = ?", defectId).Delete(&models.Defect{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrDefectNotFound
+		}
+		return nil
+	})
+}
+
+// WallThickness — толщина стенки трубы объекта, мм; 0 — паспорт трубы не заполнен
+func (r *DefectRepository) WallThickness(ctx context.Context, objectId uint) (float64, error) {
+	var wall float64
+	err := r.db.WithContext(ctx).Model(&models.Object{}).
+		Where("object_id = ?", objectId).
+		Select("COALESCE(wall_thickness, 0)").
+		Scan(&wall).Error
+	return wall, err
+}
+
+// ObjectExists учитывает область видимости: чужой объект считается несуществующим
+func (r *DefectRepository) ObjectExists(ctx context.Context, objectId uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Object{}).
+		Where("objects.object_id = ?", objectId).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *DefectRepository) DefectTypeExists(ctx context.Context, defectTypeId uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.DefectType{}).Where("defect_type_id = ?", defectTypeId).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *DefectRepository) QualityGradeExists(ctx context.Context, qualityGradeId uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.QualityGrade{}).Where("quality_grade_id = ?", qualityGradeId).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *DefectRepository) CountEmployees(ctx context.Context, employeeIds []uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Employee{}).Where("employee_id IN ?", employeeIds).Count(&count).Error
+	return count, err
+}
+
+// AddMeasurement сохраняет замер; если он самый свежий в треке, размеры дефекта обновляются до него
+func (r *DefectRepository) AddMeasurement(ctx context.Context, measurement *models.DefectMeasurement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(measurement).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Defect{}).
+			Where("defect_id = ?", measurement.DefectId).
+			Where("NOT EXISTS (SELECT 1 FROM defect_measurements m WHERE m.defect_id = ? AND m.measured_at > ?)",
+				measurement.DefectId, measurement.MeasuredAt).
+			Updates(map[string]interface{}{
+				"depth":  measurement.Depth,
+				"length": measurement.Length,
+				"width":  measurement.Width,
+			}).Error
+	})
+}
+
+// ListMeasurements возвращает серии замеров по дефектам, каждая — по возрастанию даты
+func (r *DefectRepository) ListMeasurements(ctx context.Context, defectIds []uint) (map[uint][]entities.DefectMeasurement, error) {
+	series := make(map[uint][]entities.DefectMeasurement, len(defectIds))
+	if len(defectIds) == 0 {
+		return series, nil
+	}
+
+	var rows []models.DefectMeasurement
+	if err := r.db.WithContext(ctx).
+		Where("defect_id IN ?", defectIds).
+		Order("defect_id ASC, measured_at ASC, measurement_id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range rows {
+		series[m.DefectId] = append(series[m.DefectId], MeasurementToEntity(m))
+	}
+	return series, nil
+}
+
+// FindTrack ищет незакрытый дефект того же типа на том же объекте не дальше radius метров от точки —
+// повторный замер той же аномалии. Треки, у которых уже есть замер за дату measuredAt, пропускаются:
+// две аномалии одного обследования — разные дефекты, а не рост одного. Возвращает nil, если такого нет.
+func (r *DefectRepository) FindTrack(ctx context.Context, objectId, defectTypeId uint, lat, lon, radius float64, measuredAt time.Time) (*models.Defect, error) {
+	var model models.Defect
+	err := r.db.WithContext(ctx).
+		Where("object_id = ? AND defect_type_id = ? AND status IN ?", objectId, defectTypeId, entities.OpenDefectStatuses).
+		Where("ST_DWithin(location, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)", lon, lat, radius).
+		Where(`NOT EXISTS (SELECT 1 FROM defect_measurements m
+			WHERE m.defect_id = defects.defect_id AND DATE(m.measured_at) = DATE(?))`, measuredAt).
+		Order(clause.Expr{SQL: "location <-> ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, date DESC", Vars: []interface{}{lon, lat}}).
+		First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
@@ -51,7 +51,10 @@ func (r *DiagnosticRepository) ListByObject(ctx context.Context, objectId uint)
 func (r *DiagnosticRepository) ListByYear(ctx context.Context, year int) (*[]entities.Diagnostic, error) {
 	var models []models.Diagnostic
 
-	if err := r.db.WithContext(ctx).Where("date >%d-01-01 00:00:00+02", year).Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN objects ON diagnostics.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		Where("date >%d-01-01 00:00:00+02", year).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -68,6 +71,8 @@ func (r *DiagnosticRepository) CountByType(ctx context.Context, defectTypeId uin
 
 	if err := r.db.WithContext(ctx).Table("diagnostics").
 		Select("method_id, COUNT(*) as count").
+		Joins("JOIN objects ON diagnostics.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
 		Where("method_id=?", defectTypeId).
 		Group("method_id").
 		Scan(&metric).Error; err != nil {
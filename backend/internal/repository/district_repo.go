@@ -0,0 +1,364 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var ErrDistrictNotFound = fmt.Errorf("district not found")
+
+// districtColumns — все поля, кроме самой границы
+const districtColumns = "districts.district_id, districts.name, districts.code, districts.level, districts.parent_id, districts.boundary IS NOT NULL AS has_boundary"
+
+// boundaryTolerance — упрощение границ для карты, градусы (~100 м)
+const boundaryTolerance = 0.001
+
+// districtTree — пары (root_id, district_id): район и все вложенные в него районы, включая его самого.
+// UNION без ALL не дает зациклиться, если родители заданы с ошибкой по кругу.
+const districtTree = `WITH RECURSIVE tree(root_id, district_id) AS (
+		SELECT district_id, district_id FROM districts
+		UNION
+		SELECT t.root_id, d.district_id FROM tree t JOIN districts d ON d.parent_id = t.district_id)`
+
+// districtAssignSQL — район объекта: самый мелкий из районов, в границы которого попадает объект.
+// Объекты, вручную привязанные к району без границы, не трогаются. where — условие на objects x.
+func districtAssignSQL(where string) string {
+	return `UPDATE objects o SET district_id = n.district_id
+		FROM (
+			SELECT x.object_id, (
+				SELECT d.district_id FROM districts d
+				WHERE d.boundary IS NOT NULL
+				  AND ST_Within(ST_SetSRID(ST_MakePoint(x.lon, x.lat), 4326), d.boundary)
+				ORDER BY ST_Area(d.boundary) ASC, d.district_id ASC
+				LIMIT 1) AS district_id
+			FROM objects x
+			WHERE TRUE` + where + `) n
+		WHERE n.object_id = o.object_id
+		  AND o.district_id IS DISTINCT FROM n.district_id
+		  AND NOT EXISTS (
+			SELECT 1 FROM districts cur WHERE cur.district_id = o.district_id AND cur.boundary IS NULL)`
+}
+
+// DistrictBoundary — граница района из импорта; WKT — MULTIPOLYGON
+type DistrictBoundary struct {
+	Name       string
+	Code       string
+	Level      string
+	ParentCode string
+	WKT        string
+}
+
+type DistrictRepo interface {
+	ListDistricts(ctx context.Context) ([]entities.District, error)
+	FindDistricts(ctx context.Context, filter entities.DistrictFilter) ([]entities.District, error)
+	GetDistrict(ctx context.Context, districtId uint) (*entities.District, error)
+	AddDistrict(ctx context.Context, district *entities.District) error
+	GetBoundaries(ctx context.Context, filter entities.DistrictFilter) (json.RawMessage, error)
+	ImportBoundaries(ctx context.Context, boundaries []DistrictBoundary) (int, int, error)
+	AssignObjects(ctx context.Context) (int64, error)
+	WithDescendants(ctx context.Context, districtIds []uint) ([]uint, error)
+	GetDistrictInfo(ctx context.Context, districtIds []uint) (map[uint]*entities.DistrictFullInfo, error)
+}
+
+type DistrictRepository struct {
+	db *gorm.DB
+}
+
+func NewDistrictRepository(db *gorm.DB) *DistrictRepository {
+	return &DistrictRepository{db: db}
+}
+
+func (r *DistrictRepository) ListDistricts(ctx context.Context) ([]entities.District, error) {
+	return r.FindDistricts(ctx, entities.DistrictFilter{})
+}
+
+func (r *DistrictRepository) FindDistricts(ctx context.Context, filter entities.DistrictFilter) ([]entities.District, error) {
+	var rows []models.District
+	if err := r.filtered(ctx, filter).Select(districtColumns).Order("name ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	districts := make([]entities.District, 0, len(rows))
+	for _, m := range rows {
+		districts = append(districts, DistrictToEntity(m))
+	}
+	return districts, nil
+}
+
+func (r *DistrictRepository) filtered(ctx context.Context, filter entities.DistrictFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.District{})
+	if filter.Level != "" {
+		query = query.Where("districts.level = ?", filter.Level)
+	}
+	if filter.ParentId != nil {
+		query = query.Where("districts.parent_id = ?", *filter.ParentId)
+	}
+	return query
+}
+
+func (r *DistrictRepository) GetDistrict(ctx context.Context, districtId uint) (*entities.District, error) {
+	var m models.District
+	if err := r.db.WithContext(ctx).Select(districtColumns).
+		First(&m, "districts.district_id = ?", districtId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDistrictNotFound
+		}
+		return nil, err
+	}
+	district := DistrictToEntity(m)
+	return &district, nil
+}
+
+func (r *DistrictRepository) AddDistrict(ctx context.Context, district *entities.District) error {
+	model := models.District{Name: district.Name, Code: district.Code}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+
+	district.DistrictId = model.DistrictId
+	return nil
+}
+
+// GetBoundaries — границы районов одним GeoJSON FeatureCollection для карты
+func (r *DistrictRepository) GetBoundaries(ctx context.Context, filter entities.DistrictFilter) (json.RawMessage, error) {
+	var rows []struct {
+		DistrictId  uint
+		Name        string
+		Code        string
+		Level       string
+		ParentId    *uint
+		HasBoundary bool
+		Geometry    string
+	}
+	if err := r.filtered(ctx, filter).
+		Select(districtColumns+", ST_AsGeoJSON(ST_SimplifyPreserveTopology(districts.boundary, ?)) AS geometry", boundaryTolerance).
+		Where("districts.boundary IS NOT NULL").
+		Order("districts.district_id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	type feature struct {
+		Type       string            `json:"type"`
+		Properties entities.District `json:"properties"`
+		Geometry   json.RawMessage   `json:"geometry"`
+	}
+	features := make([]feature, 0, len(rows))
+	for _, row := range rows {
+		features = append(features, feature{
+			Type: "Feature",
+			Properties: entities.District{
+				DistrictId:  row.DistrictId,
+				Name:        row.Name,
+				Code:        row.Code,
+				Level:       row.Level,
+				ParentId:    row.ParentId,
+				HasBoundary: row.HasBoundary,
+			},
+			Geometry: json.RawMessage(row.Geometry),
+		})
+	}
+	return json.Marshal(map[string]interface{}{"type": "FeatureCollection", "features": features})
+}
+
+// ImportBoundaries создает или обновляет районы по коду, затем проставляет родителей:
+// по parent_code, а без него — область, в которую попадает район
+func (r *DistrictRepository) ImportBoundaries(ctx context.Context, boundaries []DistrictBoundary) (int, int, error) {
+	var created, updated int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, b := range boundaries {
+			geometry := gorm.Expr("ST_Multi(ST_CollectionExtract(ST_MakeValid(ST_GeomFromText(?, 4326)), 3))", b.WKT)
+
+			var existing models.District
+			err := tx.Select("district_id").First(&existing, "code = ?", b.Code).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				model := models.District{Name: b.Name, Code: b.Code, Level: b.Level}
+				if err := tx.Omit("Boundary").Create(&model).Error; err != nil {
+					return err
+				}
+				existing.DistrictId = model.DistrictId
+				created++
+			case err != nil:
+				return err
+			default:
+				updated++
+			}
+
+			if err := tx.Model(&models.District{}).Where("district_id = ?", existing.DistrictId).
+				Updates(map[string]interface{}{"name": b.Name, "level": b.Level, "boundary": geometry}).Error; err != nil {
+				return err
+			}
+			if b.ParentCode != "" {
+				if err := tx.Exec(`UPDATE districts SET parent_id = (SELECT p.district_id FROM districts p WHERE p.code = ?)
+					WHERE district_id = ?`, b.ParentCode, existing.DistrictId).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Exec(`UPDATE districts d SET parent_id = (
+				SELECT p.district_id FROM districts p
+				WHERE p.level = ? AND p.boundary IS NOT NULL AND p.district_id <> d.district_id
+				  AND ST_Within(ST_PointOnSurface(d.boundary), p.boundary)
+				ORDER BY ST_Area(p.boundary) ASC
+				LIMIT 1)
+			WHERE d.parent_id IS NULL AND d.level <> ? AND d.boundary IS NOT NULL`,
+			entities.DistrictLevelOblast, entities.DistrictLevelOblast).Error
+	})
+	return created, updated, err
+}
+
+// AssignObjects пересчитывает район у всех объектов; возвращает, сколько объектов сменили район
+func (r *DistrictRepository) AssignObjects(ctx context.Context) (int64, error) {
+	res := r.db.WithContext(ctx).Exec(districtAssignSQL(""))
+	return res.RowsAffected, res.Error
+}
+
+// AssignDistrict пересчитывает район одного объекта (после создания или смены координат)
+func (r *ObjectRepository) AssignDistrict(ctx context.Context, objectId uint) error {
+	return r.db.WithContext(ctx).Exec(districtAssignSQL(" AND x.object_id = ?"), objectId).Error
+}
+
+// WithDescendants дополняет список районов вложенными: назначение на область дает доступ к ее районам
+func (r *DistrictRepository) WithDescendants(ctx context.Context, districtIds []uint) ([]uint, error) {
+	if len(districtIds) == 0 {
+		return districtIds, nil
+	}
+	var ids []uint
+	err := r.db.WithContext(ctx).Raw(districtTree+`
+		SELECT DISTINCT district_id FROM tree WHERE root_id IN ?`, districtIds).Scan(&ids).Error
+	return ids, err
+}
+
+// GetDistrictInfo считает сводку по районам вместе с вложенными. Объекты, диагностики и дефекты —
+// в области видимости пользователя; сотрудники — по попаданию их координат в границу района.
+func (r *DistrictRepository) GetDistrictInfo(ctx context.Context, districtIds []uint) (map[uint]*entities.DistrictFullInfo, error) {
+	infos := make(map[uint]*entities.DistrictFullInfo, len(districtIds))
+	if len(districtIds) == 0 {
+		return infos, nil
+	}
+	for _, id := range districtIds {
+		infos[id] = &entities.DistrictFullInfo{TopDefectTypes: []entities.DistrictDefectType{}}
+	}
+	scope, scopeArgs := scopeSQL(ctx, "objects")
+	withScope := func(args ...interface{}) []interface{} {
+		return append(args, scopeArgs...)
+	}
+
+	// 1. Объекты и средний индекс состояния
+	var objects []struct {
+		RootId       uint
+		ObjectCount  int
+		AvgCondition *float64
+	}
+	if err := r.db.WithContext(ctx).Raw(districtTree+`
+		SELECT t.root_id, COUNT(*) AS object_count, ROUND(AVG(objects.condition)::numeric, 1) AS avg_condition
+		FROM tree t
+		JOIN objects ON objects.district_id = t.district_id
+		WHERE t.root_id IN ?`+scope+`
+		GROUP BY t.root_id`, withScope(districtIds)...).Scan(&objects).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range objects {
+		info := infos[row.RootId]
+		info.ObjectCount = row.ObjectCount
+		info.Condition = row.AvgCondition
+	}
+
+	// 2. Диагностики
+	var inspections []struct {
+		RootId uint
+		Count  int
+	}
+	if err := r.db.WithContext(ctx).Raw(districtTree+`
+		SELECT t.root_id, COUNT(*) AS count
+		FROM tree t
+		JOIN objects ON objects.district_id = t.district_id
+		JOIN diagnostics dg ON dg.object_id = objects.object_id
+		WHERE t.root_id IN ?`+scope+`
+		GROUP BY t.root_id`, withScope(districtIds)...).Scan(&inspections).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range inspections {
+		infos[row.RootId].InspectionCount = row.Count
+	}
+
+	// 3. Дефекты: всего, открытые и средний приоритет оценки
+	var defects []struct {
+		RootId      uint
+		Total       int
+		Open        int
+		AvgSeverity float64
+	}
+	if err := r.db.WithContext(ctx).Raw(districtTree+`
+		SELECT t.root_id, COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE defects.status IN ?) AS open,
+			COALESCE(ROUND(AVG(NULLIF(qg.rank, 0)), 2), 0) AS avg_severity
+		FROM tree t
+		JOIN objects ON objects.district_id = t.district_id
+		JOIN defects ON defects.object_id = objects.object_id
+		LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id
+		WHERE t.root_id IN ?`+scope+`
+		GROUP BY t.root_id`, withScope(entities.OpenDefectStatuses, districtIds)...).Scan(&defects).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range defects {
+		info := infos[row.RootId]
+		info.DefectCount, info.OpenDefectCount, info.AvgSeverity = row.Total, row.Open, row.AvgSeverity
+	}
+
+	// 4. Самые частые типы дефектов, по три на район
+	var types []struct {
+		RootId      uint
+		DefectType  string
+		Count       int
+		AvgSeverity float64
+	}
+	if err := r.db.WithContext(ctx).Raw(districtTree+`
+		SELECT root_id, defect_type, count, avg_severity FROM (
+			SELECT t.root_id, COALESCE(dt.name, '') AS defect_type, COUNT(*) AS count,
+				COALESCE(ROUND(AVG(NULLIF(qg.rank, 0)), 2), 0) AS avg_severity,
+				ROW_NUMBER() OVER (PARTITION BY t.root_id ORDER BY COUNT(*) DESC, dt.name) AS pos
+			FROM tree t
+			JOIN objects ON objects.district_id = t.district_id
+			JOIN defects ON defects.object_id = objects.object_id
+			LEFT JOIN defect_types dt ON dt.defect_type_id = defects.defect_type_id
+			LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id
+			WHERE t.root_id IN ?`+scope+`
+			GROUP BY t.root_id, dt.name) ranked
+		WHERE pos <= 3
+		ORDER BY root_id, pos`, withScope(districtIds)...).Scan(&types).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range types {
+		info := infos[row.RootId]
+		info.TopDefectTypes = append(info.TopDefectTypes, entities.DistrictDefectType{
+			DefectType: row.DefectType, Count: row.Count, AvgSeverity: row.AvgSeverity,
+		})
+	}
+
+	// 5. Сотрудники в границах района
+	var employees []struct {
+		RootId uint
+		Count  int
+	}
+	if err := r.db.WithContext(ctx).Raw(`
+		SELECT d.district_id AS root_id, COUNT(*) AS count
+		FROM districts d
+		JOIN employees e ON ST_Within(ST_SetSRID(ST_MakePoint(e.lon, e.lat), 4326), d.boundary)
+		WHERE d.district_id IN ? AND d.boundary IS NOT NULL
+		GROUP BY d.district_id`, districtIds).Scan(&employees).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range employees {
+		infos[row.RootId].EmployeesCount = row.Count
+	}
+	return infos, nil
+}
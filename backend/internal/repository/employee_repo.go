@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var ErrEmployeeNotFound = fmt.Errorf("employee not found")
+
+type EmployeeRepo interface {
+	GetEmployee(ctx context.Context, employeeId uint) (*entities.Employee, error)
+	ListDefects(ctx context.Context, employeeId uint, page, limit int) ([]entities.Defect, int64, error)
+	ListObjects(ctx context.Context, employeeId uint, page, limit int) ([]entities.Object, int64, error)
+	WorkQueue(ctx context.Context, employeeId uint) ([]entities.WorkItem, error)
+}
+
+type EmployeeRepository struct {
+	db *gorm.DB
+}
+
+func NewEmployeeRepository(db *gorm.DB) *EmployeeRepository {
+	return &EmployeeRepository{db: db}
+}
+
+func (r *EmployeeRepository) GetEmployee(ctx context.Context, employeeId uint) (*entities.Employee, error) {
+	var model models.Employee
+	if err := r.db.WithContext(ctx).First(&model, "employee_id = ?", employeeId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmployeeNotFound
+		}
+		return nil, err
+	}
+
+	employee := EmployeeToEntity(model)
+	return &employee, nil
+}
+
+// ListDefects — дефекты, назначенные сотруднику через defect_employees
+func (r *EmployeeRepository) ListDefects(ctx context.Context, employeeId uint, page, limit int) ([]entities.Defect, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Defect{}).
+		Joins("JOIN defect_employees ON defect_employees.defect_id = defects.defect_id").
+		Joins("JOIN objects ON objects.object_id = defects.object_id").
+		Where("defect_employees.employee_id = ?", employeeId).
+		Scopes(ScopeObjects(ctx, "objects"))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.Defect
+	if err := query.Preload("Object").Preload("DefectType").Preload("QualityGrade").
+		Scopes(Paginate(page, limit)).
+		Order("defects.date DESC").
+		Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	defects := make([]entities.Defect, 0, len(rows))
+	for _, m := range rows {
+		defects = append(defects, DefectToEntity(m))
+	}
+	return defects, total, nil
+}
+
+// ListObjects — объекты, закрепленные за сотрудником через object_employees
+func (r *EmployeeRepository) ListObjects(ctx context.Context, employeeId uint, page, limit int) ([]entities.Object, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Object{}).
+		Joins("JOIN object_employees ON object_employees.object_id = objects.object_id").
+		Where("object_employees.employee_id = ?", employeeId).
+		Scopes(ScopeObjects(ctx, "objects"))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.Object
+	if err := query.Scopes(Paginate(page, limit)).
+		Order("objects.object_name ASC").
+		Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	objects := make([]entities.Object, 0, len(rows))
+	for _, m := range rows {
+		objects = append(objects, ObjectToEntity(m))
+	}
+	return objects, total, nil
+}
+
+// WorkQueue — незакрытые дефекты сотрудника, самые опасные и давние сверху
+func (r *EmployeeRepository) WorkQueue(ctx context.Context, employeeId uint) ([]entities.WorkItem, error) {
+	query := `
+		SELECT
+			d.defect_id, d.object_id, o.object_name, p.name AS pipeline,
+			dt.name AS defect_type, qg.quality_grade, d.status, d.description, d.date,
+			` + gradePriority + ` AS priority
+		FROM defect_employees de
+		JOIN defects d ON d.defect_id = de.defect_id
+		JOIN objects o ON o.object_id = d.object_id
+		LEFT JOIN pipelines p ON p.pipeline_id = o.pipeline_id
+		LEFT JOIN defect_types dt ON dt.defect_type_id = d.defect_type_id
+		LEFT JOIN quality_grades qg ON qg.quality_grade_id = d.quality_grade_id
+		WHERE de.employee_id = ? AND d.status IN ?%s
+		ORDER BY COALESCE(qg.rank, 0) DESC, d.date ASC
+	`
+	scope, scopeArgs := scopeSQL(ctx, "o")
+	args := append([]interface{}{employeeId, entities.OpenDefectStatuses}, scopeArgs...)
+
+	var items []entities.WorkItem
+	err := r.db.WithContext(ctx).Raw(fmt.Sprintf(query, scope), args...).Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
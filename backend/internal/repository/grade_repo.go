@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var ErrQualityGradeNotFound = fmt.Errorf("quality grade not found")
+
+// Фрагменты SQL для запросов, в которые заджойнен каталог: LEFT JOIN quality_grades qg.
+// Дефект без оценки весит на карте как самая легкая точка и не считается критичным.
+const (
+	gradeWeight   = "COALESCE(qg.heat_weight, 0.1)"
+	gradeCritical = "COALESCE(qg.critical, false)"
+	gradePriority = "COALESCE(qg.priority, 'low')"
+)
+
+type QualityGradeRepo interface {
+	ListGrades(ctx context.Context) ([]entities.QualityGrade, error)
+	GetGrade(ctx context.Context, qualityGradeId uint) (*entities.QualityGrade, error)
+	UpdateGrade(ctx context.Context, qualityGradeId uint, fields map[string]interface{}) error
+}
+
+type QualityGradeRepository struct {
+	db *gorm.DB
+}
+
+func NewQualityGradeRepository(db *gorm.DB) *QualityGradeRepository {
+	return &QualityGradeRepository{db: db}
+}
+
+// ListGrades — каталог от лучшей оценки к худшей; оценки вне каталога (rank 0) в конце
+func (r *QualityGradeRepository) ListGrades(ctx context.Context) ([]entities.QualityGrade, error) {
+	var rows []models.QualityGrade
+	if err := r.db.WithContext(ctx).
+		Order("rank = 0, rank, quality_grade_id").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	grades := make([]entities.QualityGrade, 0, len(rows))
+	for _, m := range rows {
+		grades = append(grades, QualityGradeToEntity(m))
+	}
+	return grades, nil
+}
+
+func (r *QualityGradeRepository) GetGrade(ctx context.Context, qualityGradeId uint) (*entities.QualityGrade, error) {
+	var m models.QualityGrade
+	if err := r.db.WithContext(ctx).First(&m, "quality_grade_id = ?", qualityGradeId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQualityGradeNotFound
+		}
+		return nil, err
+	}
+
+	grade := QualityGradeToEntity(m)
+	return &grade, nil
+}
+
+func (r *QualityGradeRepository) UpdateGrade(ctx context.Context, qualityGradeId uint, fields map[string]interface{}) error {
+	res := r.db.WithContext(ctx).Model(&models.QualityGrade{}).
+		Where("quality_grade_id = ?", qualityGradeId).
+		Updates(fields)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrQualityGradeNotFound
+	}
+	return nil
+}
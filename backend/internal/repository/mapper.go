@@ -2,7 +2,7 @@ package repository
 
 import (
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
 	"github.com/rwrrioe/integrity/backend/internal/repository/models"
@@ -11,36 +11,23 @@ import (
 func DefectToEntity(m models.Defect) entities.Defect {
 	return entities.Defect{
 		DefectId:     m.DefectId,
+		ObjectId:     m.ObjectId,
 		ObjectName:   m.Object.ObjectName,
 		DefectType:   m.DefectType.Name,
 		Description:  m.Description,
 		QualityGrade: m.QualityGrade.QualityGrade,
+		Status:       m.Status,
 		Depth:        m.Depth,
 		Length:       m.Length,
 		Width:        m.Width,
 		Vibration:    m.Vibration,
+		Lat:          m.Lat,
+		Lon:          m.Lon,
 		Date:         m.Date,
+		ChainageKm:   m.ChainageKm,
 	}
 }
 
-func DefectToModel(e entities.Defect) (models.Defect, bool) {
-	id, ok := qualityGradeToId(e.QualityGrade)
-	if !ok {
-		return models.Defect{}, false
-	}
-
-	return models.Defect{
-		DefectId:       e.DefectId,
-		Description:    e.Description,
-		QualityGradeId: uint(id),
-		Depth:          e.Depth,
-		Length:         e.Length,
-		Width:          e.Width,
-		Vibration:      e.Vibration,
-		Date:           e.Date,
-	}, true
-}
-
 func DiagnosticToEntity(m models.Diagnostic) entities.Diagnostic {
 	return entities.Diagnostic{
 		DiagnosticId: m.DiagnosticId,
@@ -67,21 +54,41 @@ func DiagnosticToModel(e entities.Diagnostic) models.Diagnostic {
 
 func ObjectToEntity(m models.Object) entities.Object {
 	return entities.Object{
-		ObjectId: m.ObjectId,
-		Name:     m.ObjectName,
-		Lat:      m.Lat,
-		Lon:      m.Lon,
-		Material: m.Material,
+		ObjectId:         m.ObjectId,
+		Name:             m.ObjectName,
+		TypeId:           m.ObjectTypeId,
+		PipelineId:       m.PipelineId,
+		Lat:              m.Lat,
+		Lon:              m.Lon,
+		Material:         m.Material,
+		InstallationYear: m.InstallationYear,
+		ChainageKm:       m.ChainageKm,
+		Condition:        m.Condition,
+		ConditionAt:      m.ConditionAt,
+		Pipe: entities.PipeProperties{
+			OuterDiameter: m.OuterDiameter,
+			WallThickness: m.WallThickness,
+			Smys:          m.Smys,
+			Maop:          m.Maop,
+		},
 	}
 }
 
 func ObjectToModel(e entities.Object) models.Object {
 	return models.Object{
-		ObjectId:   e.ObjectId,
-		ObjectName: e.Name,
-		Lat:        e.Lat,
-		Lon:        e.Lon,
-		Material:   e.Material,
+		ObjectId:         e.ObjectId,
+		ObjectName:       e.Name,
+		ObjectTypeId:     e.TypeId,
+		PipelineId:       e.PipelineId,
+		Lat:              e.Lat,
+		Lon:              e.Lon,
+		Material:         e.Material,
+		InstallationYear: e.InstallationYear,
+
+		OuterDiameter: e.Pipe.OuterDiameter,
+		WallThickness: e.Pipe.WallThickness,
+		Smys:          e.Pipe.Smys,
+		Maop:          e.Pipe.Maop,
 	}
 }
 
@@ -128,15 +135,279 @@ func EmployeeToEntity(m models.Employee) entities.Employee {
 	}
 }
 
-var qualityGradeMap = map[string]int{
-	"удовлетворительно": 1,
-	"допустимо":         2,
-	"требует мер":       3,
-	"недопустимо":       4,
+func QualityGradeToEntity(m models.QualityGrade) entities.QualityGrade {
+	return entities.QualityGrade{
+		QualityGradeId: m.QualityGradeId,
+		Code:           m.QualityGrade,
+		Rank:           m.Rank,
+		HeatWeight:     m.HeatWeight,
+		Color:          m.Color,
+		Labels:         entities.GradeLabels{Ru: m.LabelRu, En: m.LabelEn},
+		Critical:       m.Critical,
+		Priority:       m.Priority,
+	}
 }
 
-func qualityGradeToId(s string) (int, bool) {
-	s = strings.TrimSpace(strings.ToLower(s))
-	id, ok := qualityGradeMap[s]
-	return id, ok
+func UserToEntity(m models.User) entities.User {
+	return entities.User{
+		UserId:        m.UserId,
+		Email:         m.Email,
+		Name:          m.Name,
+		Role:          m.Role,
+		EmailVerified: m.EmailVerifiedAt != nil,
+		EmployeeId:    m.EmployeeId,
+		CreatedAt:     m.CreatedAt,
+	}
+}
+
+func RoleToEntity(m models.Role) entities.Role {
+	perms := make([]string, 0, len(m.Permissions))
+	for _, p := range m.Permissions {
+		perms = append(perms, p.Code)
+	}
+
+	return entities.Role{
+		RoleId:      m.RoleId,
+		Name:        m.Name,
+		Title:       m.Title,
+		Permissions: perms,
+	}
+}
+
+func DistrictToEntity(m models.District) entities.District {
+	return entities.District{
+		DistrictId:  m.DistrictId,
+		Name:        m.Name,
+		Code:        m.Code,
+		Level:       m.Level,
+		ParentId:    m.ParentId,
+		HasBoundary: m.HasBoundary || m.Boundary != nil,
+	}
+}
+
+func ApiKeyToEntity(m models.ApiKey) entities.ApiKey {
+	return entities.ApiKey{
+		ApiKeyId:    m.ApiKeyId,
+		Name:        m.Name,
+		Prefix:      m.Prefix,
+		Permissions: m.Permissions,
+		PipelineIds: m.PipelineIds,
+		CreatedBy:   m.CreatedBy,
+		CreatedAt:   m.CreatedAt,
+		LastUsedAt:  m.LastUsedAt,
+		ExpiresAt:   m.ExpiresAt,
+		RevokedAt:   m.RevokedAt,
+	}
+}
+
+func AuditToEntity(m models.AuditLog) entities.AuditEntry {
+	return entities.AuditEntry{
+		AuditId:       m.AuditId,
+		ActorUserId:   m.ActorUserId,
+		ActorApiKeyId: m.ActorApiKeyId,
+		Action:        m.Action,
+		EntityType:    m.EntityType,
+		EntityId:      m.EntityId,
+		Before:        m.Before,
+		After:         m.After,
+		Ip:            m.Ip,
+		CreatedAt:     m.CreatedAt,
+	}
+}
+
+func StatusHistoryToEntity(m models.DefectStatusHistory) entities.DefectStatusChange {
+	return entities.DefectStatusChange{
+		HistoryId:       m.HistoryId,
+		DefectId:        m.DefectId,
+		FromStatus:      m.FromStatus,
+		ToStatus:        m.ToStatus,
+		Comment:         m.Comment,
+		Details:         m.Details,
+		ChangedBy:       m.ChangedBy,
+		ChangedByApiKey: m.ChangedByApiKey,
+		CreatedAt:       m.CreatedAt,
+	}
+}
+
+func WorkOrderToEntity(m models.WorkOrder) entities.WorkOrder {
+	order := entities.WorkOrder{
+		WorkOrderId:      m.WorkOrderId,
+		Title:            m.Title,
+		Description:      m.Description,
+		Status:           m.Status,
+		Priority:         m.Priority,
+		MethodId:         m.MethodId,
+		PlannedStart:     m.PlannedStart,
+		PlannedFinish:    m.PlannedFinish,
+		ActualStart:      m.ActualStart,
+		ActualFinish:     m.ActualFinish,
+		CompletionReport: m.CompletionReport,
+		CancelReason:     m.CancelReason,
+		CreatedBy:        m.CreatedBy,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+		Defects:          make([]entities.Defect, 0, len(m.Defects)),
+		Employees:        make([]entities.Employee, 0, len(m.Employees)),
+	}
+	if m.Method != nil {
+		order.Method = m.Method.MethodName
+	}
+	for _, d := range m.Defects {
+		order.Defects = append(order.Defects, DefectToEntity(d))
+	}
+	for _, e := range m.Employees {
+		order.Employees = append(order.Employees, EmployeeToEntity(e))
+	}
+	return order
+}
+
+func AttachmentToEntity(m models.Attachment) entities.Attachment {
+	return entities.Attachment{
+		AttachmentId:     m.AttachmentId,
+		OwnerType:        m.OwnerType,
+		OwnerId:          m.OwnerId,
+		FileName:         m.FileName,
+		ContentType:      m.ContentType,
+		Size:             m.Size,
+		Checksum:         m.Checksum,
+		HasThumbnail:     m.ThumbnailKey != "",
+		UploadedBy:       m.UploadedBy,
+		UploadedByApiKey: m.UploadedByApiKey,
+		CreatedAt:        m.CreatedAt,
+	}
+}
+
+func UserToRef(m models.User) entities.UserRef {
+	return entities.UserRef{
+		UserId: m.UserId,
+		Name:   m.Name,
+		Email:  m.Email,
+	}
+}
+
+func CommentToEntity(m models.DefectComment) entities.Comment {
+	comment := entities.Comment{
+		CommentId:  m.CommentId,
+		DefectId:   m.DefectId,
+		ParentId:   m.ParentId,
+		Author:     UserToRef(m.Author),
+		Body:       m.Body,
+		IsDecision: m.IsDecision,
+		Mentions:   make([]entities.UserRef, 0, len(m.Mentions)),
+		Deleted:    m.DeletedAt != nil,
+		EditedAt:   m.EditedAt,
+		CreatedAt:  m.CreatedAt,
+	}
+	for _, u := range m.Mentions {
+		comment.Mentions = append(comment.Mentions, UserToRef(u))
+	}
+	return comment
+}
+
+func MeasurementToEntity(m models.DefectMeasurement) entities.DefectMeasurement {
+	return entities.DefectMeasurement{
+		MeasurementId: m.MeasurementId,
+		DefectId:      m.DefectId,
+		DiagnosticId:  m.DiagnosticId,
+		MeasuredAt:    m.MeasuredAt,
+		Depth:         m.Depth,
+		Length:        m.Length,
+		Width:         m.Width,
+		Source:        m.Source,
+	}
+}
+
+// RestrictionToEntity вычисляет Active на момент now: ограничение не снято и срок его не истек
+func RestrictionToEntity(m models.PressureRestriction, now time.Time) entities.PressureRestriction {
+	restriction := entities.PressureRestriction{
+		RestrictionId: m.RestrictionId,
+		PipelineId:    m.PipelineId,
+		PipelineName:  m.Pipeline.Name,
+		ObjectId:      m.ObjectId,
+		DefectId:      m.DefectId,
+		MaxPressure:   m.MaxPressure,
+		Reason:        m.Reason,
+		ImposedAt:     m.ImposedAt,
+		ValidUntil:    m.ValidUntil,
+		LiftedAt:      m.LiftedAt,
+		LiftReason:    m.LiftReason,
+		Active:        m.LiftedAt == nil && (m.ValidUntil == nil || m.ValidUntil.After(now)),
+	}
+	if m.Object != nil {
+		restriction.ObjectName = m.Object.ObjectName
+	}
+	if m.Imposer != nil {
+		ref := UserToRef(*m.Imposer)
+		restriction.ImposedBy = &ref
+	}
+	if m.Lifter != nil {
+		ref := UserToRef(*m.Lifter)
+		restriction.LiftedBy = &ref
+	}
+	return restriction
+}
+
+func PipelineToEntity(m models.Pipeline) entities.Pipeline {
+	return entities.Pipeline{
+		PipelineId:        m.PipelineId,
+		Name:              m.Name,
+		Operator:          m.Operator,
+		Product:           m.Product,
+		CommissioningYear: m.CommissioningYear,
+		LengthKm:          m.LengthKm,
+		DesignPressure:    m.DesignMaop,
+		Condition:         m.Condition,
+		ConditionAt:       m.ConditionAt,
+		Archived:          m.ArchivedAt != nil,
+		ArchivedAt:        m.ArchivedAt,
+		HasRoute:          m.Route != nil,
+		StartKm:           m.StartKm,
+	}
+}
+
+func ConditionSettingsToEntity(m models.ConditionSettings) entities.ConditionSettings {
+	updatedAt := m.UpdatedAt
+	return entities.ConditionSettings{
+		Weights: entities.ConditionWeights{
+			Severity:   m.WeightSeverity,
+			Density:    m.WeightDensity,
+			Risk:       m.WeightRisk,
+			Inspection: m.WeightInspection,
+			Sensor:     m.WeightSensor,
+		},
+		DensityPerObject:     m.DensityPerObject,
+		DensityPerKm:         m.DensityPerKm,
+		InspectionMaxAgeDays: m.InspectionMaxAgeDays,
+		VibrationLimit:       m.VibrationLimit,
+		UpdatedAt:            &updatedAt,
+	}
+}
+
+func ConditionSettingsToModel(e entities.ConditionSettings) models.ConditionSettings {
+	return models.ConditionSettings{
+		WeightSeverity:       e.Weights.Severity,
+		WeightDensity:        e.Weights.Density,
+		WeightRisk:           e.Weights.Risk,
+		WeightInspection:     e.Weights.Inspection,
+		WeightSensor:         e.Weights.Sensor,
+		DensityPerObject:     e.DensityPerObject,
+		DensityPerKm:         e.DensityPerKm,
+		InspectionMaxAgeDays: e.InspectionMaxAgeDays,
+		VibrationLimit:       e.VibrationLimit,
+	}
+}
+
+func ConditionHistoryToEntity(m models.ConditionHistory) entities.ConditionPoint {
+	return entities.ConditionPoint{
+		Condition: m.Condition,
+		Band:      entities.ConditionBand(m.Condition),
+		Components: entities.ConditionComponents{
+			Severity:   m.Severity,
+			Density:    m.Density,
+			Risk:       m.Risk,
+			Inspection: m.Inspection,
+			Sensor:     m.Sensor,
+		},
+		ComputedAt: m.CreatedAt,
+	}
 }
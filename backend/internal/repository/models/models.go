@@ -16,11 +16,40 @@ type ObjectType struct {
 type Pipeline struct {
 	PipelineId uint `gorm:"primaryKey"`
 	Name       string
-	Condition  float64
+	// Индекс состояния 0–100; ConditionAt == nil — еще не считался
+	Condition   float64
+	ConditionAt *time.Time
+	DesignMaop  float64 // проектное МДРД, МПа; 0 — не задано
+
+	// Паспорт трубопровода
+	Operator          string
+	Product           string // перекачиваемая среда
+	CommissioningYear int
+	LengthKm          float64
+	ArchivedAt        *time.Time `gorm:"index"`
+
+	// Трасса для линейной привязки; StartKm — пикет начала трассы, км
+	Route   *string `gorm:"type:geometry(LINESTRING,4326)"`
+	StartKm float64
 
 	Objects []Object `gorm:"foreignKey:PipelineId"`
 }
 
+type District struct {
+	DistrictId uint   `gorm:"primaryKey"`
+	Name       string `gorm:"not null"`
+	Code       string `gorm:"uniqueIndex"`
+	Level      string
+	ParentId   *uint `gorm:"index"`
+
+	// Граница района; объекты привязываются к району по попаданию в нее (ST_Within)
+	Boundary *string `gorm:"type:geometry(MULTIPOLYGON,4326)"`
+	// HasBoundary только читается: выбирается вместо самой границы, чтобы не тянуть полигоны в списки
+	HasBoundary bool `gorm:"->;-:migration"`
+
+	Objects []Object `gorm:"foreignKey:DistrictId"`
+}
+
 type Method struct {
 	MethodId   uint `gorm:"primaryKey"`
 	MethodName string
@@ -36,9 +65,17 @@ type DefectType struct {
 	Defects []Defect `gorm:"foreignKey:DefectTypeId"`
 }
 
+// QualityGrade — каталог оценок; QualityGrade хранит код оценки (entities.Grade*)
 type QualityGrade struct {
 	QualityGradeId uint `gorm:"primaryKey"`
 	QualityGrade   string
+	Rank           int     `gorm:"not null;default:0"` // 0 — оценка не настроена в каталоге
+	HeatWeight     float64 `gorm:"not null;default:0.1"`
+	Color          string
+	LabelRu        string
+	LabelEn        string
+	Critical       bool   `gorm:"not null;default:false"`
+	Priority       string `gorm:"not null;default:low"`
 
 	Defects []Defect `gorm:"foreignKey:QualityGradeId"`
 }
@@ -74,11 +111,27 @@ type Object struct {
 	ObjectName   string `gorm:"not null"`
 	ObjectTypeId uint
 	PipelineId   uint
+	DistrictId   *uint `gorm:"index"`
 
 	Lat      float64
 	Lon      float64
 	Location string `gorm:"type:geography(POINT, 4326)"`
 	Material string
+	// год ввода в эксплуатацию; 0 — не задан
+	InstallationYear int
+	// индекс состояния 0–100; nil — еще не считался
+	Condition   *float64
+	ConditionAt *time.Time
+
+	// Привязка к трассе трубопровода: пикет (км) и расстояние от оси трассы (м); nil — трасса не загружена
+	ChainageKm   *float64 `gorm:"index"`
+	RouteOffsetM *float64
+
+	// Параметры трубы для расчета остаточной прочности; 0 — не задано
+	OuterDiameter float64 // мм
+	WallThickness float64 // мм
+	Smys          float64 // МПа
+	Maop          float64 // МПа
 
 	// Belongs To
 	ObjectType ObjectType `gorm:"foreignKey:ObjectTypeId;references:ObjectTypeId"`
@@ -115,8 +168,9 @@ type Defect struct {
 	EmployeeId     uint
 
 	Description string
-	Status      string
+	Status      string `gorm:"index;default:New"`
 	Date        time.Time
+	PlannedDate *time.Time
 
 	Width     float64
 	Length    float64
@@ -127,6 +181,9 @@ type Defect struct {
 	Lon      float64
 	Location string `gorm:"type:geography(POINT,4326)"`
 
+	ChainageKm   *float64 `gorm:"index"`
+	RouteOffsetM *float64
+
 	// Associations
 	Object       Object       `gorm:"foreignKey:ObjectId;references:ObjectId"`
 	DefectType   DefectType   `gorm:"foreignKey:DefectTypeId;references:DefectTypeId"`
@@ -166,3 +223,228 @@ type ProbabilityHistory struct {
 
 	Object Object `gorm:"foreignKey:ObjectId;references:ObjectId"`
 }
+
+type User struct {
+	UserId       uint   `gorm:"primaryKey"`
+	Email        string `gorm:"uniqueIndex;not null"`
+	PasswordHash string `gorm:"not null"`
+	Name         string
+	Role         string `gorm:"not null"`
+
+	EmailVerifiedAt *time.Time
+
+	// Сотрудник, которым является пользователь (для рабочего места /me)
+	EmployeeId *uint     `gorm:"uniqueIndex"`
+	Employee   *Employee `gorm:"foreignKey:EmployeeId;references:EmployeeId"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Зона ответственности: трубопроводы и районы, данные которых видит пользователь
+	Pipelines []Pipeline `gorm:"many2many:user_pipelines;joinForeignKey:UserId;joinReferences:PipelineId"`
+	Districts []District `gorm:"many2many:user_districts;joinForeignKey:UserId;joinReferences:DistrictId"`
+}
+
+// UserToken — одноразовый токен для сброса пароля или подтверждения почты. Хранится только хэш.
+type UserToken struct {
+	TokenId   uint   `gorm:"primaryKey"`
+	UserId    uint   `gorm:"index;not null"`
+	Purpose   string `gorm:"not null"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+
+	User User `gorm:"foreignKey:UserId;references:UserId"`
+}
+
+type Role struct {
+	RoleId uint   `gorm:"primaryKey"`
+	Name   string `gorm:"uniqueIndex;not null"`
+	Title  string
+
+	Permissions []Permission `gorm:"many2many:role_permissions;joinForeignKey:RoleId;joinReferences:PermissionId"`
+}
+
+type Permission struct {
+	PermissionId uint   `gorm:"primaryKey"`
+	Code         string `gorm:"uniqueIndex;not null"`
+	Description  string
+}
+
+// ApiKey — ключ для машинных клиентов (шлюзы датчиков, ETL). Хранится только хэш ключа.
+type ApiKey struct {
+	ApiKeyId    uint     `gorm:"primaryKey"`
+	Name        string   `gorm:"not null"`
+	Prefix      string   `gorm:"index;not null"`
+	KeyHash     string   `gorm:"uniqueIndex;not null"`
+	Permissions []string `gorm:"serializer:json"`
+	PipelineIds []uint   `gorm:"serializer:json"`
+	CreatedBy   uint
+
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+}
+
+// AuditLog — журнал аудита. Только вставка: UPDATE и DELETE запрещены триггером в БД.
+type AuditLog struct {
+	AuditId       uint                   `gorm:"primaryKey"`
+	ActorUserId   *uint                  `gorm:"index"`
+	ActorApiKeyId *uint                  `gorm:"index"`
+	Action        string                 `gorm:"index;not null"`
+	EntityType    string                 `gorm:"index:idx_audit_entity"`
+	EntityId      string                 `gorm:"index:idx_audit_entity"`
+	Before        map[string]interface{} `gorm:"type:jsonb;serializer:json"`
+	After         map[string]interface{} `gorm:"type:jsonb;serializer:json"`
+	Ip            string
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+// DefectStatusHistory — кто, когда и из какого статуса перевел дефект
+type DefectStatusHistory struct {
+	HistoryId       uint   `gorm:"primaryKey"`
+	DefectId        uint   `gorm:"index;not null"`
+	FromStatus      string `gorm:"not null"`
+	ToStatus        string `gorm:"not null"`
+	Comment         string
+	Details         map[string]interface{} `gorm:"type:jsonb;serializer:json"`
+	ChangedBy       *uint
+	ChangedByApiKey *uint
+	CreatedAt       time.Time
+
+	Defect Defect `gorm:"foreignKey:DefectId;references:DefectId"`
+}
+
+func (DefectStatusHistory) TableName() string {
+	return "defect_status_history"
+}
+
+// WorkOrder — наряд на ремонт одного или нескольких дефектов
+type WorkOrder struct {
+	WorkOrderId uint   `gorm:"primaryKey"`
+	Title       string `gorm:"not null"`
+	Description string
+	Status      string `gorm:"index;not null"`
+	Priority    string `gorm:"index;not null"`
+	MethodId    *uint
+
+	PlannedStart  *time.Time
+	PlannedFinish *time.Time
+	ActualStart   *time.Time
+	ActualFinish  *time.Time
+
+	CompletionReport string
+	CancelReason     string
+
+	CreatedBy *uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Method    *Method    `gorm:"foreignKey:MethodId;references:MethodId"`
+	Defects   []Defect   `gorm:"many2many:work_order_defects;joinForeignKey:WorkOrderId;joinReferences:DefectId"`
+	Employees []Employee `gorm:"many2many:work_order_employees;joinForeignKey:WorkOrderId;joinReferences:EmployeeId"`
+}
+
+// Attachment — метаданные вложения (фото, скан, PDF). Само содержимое хранится в BlobStore по StorageKey.
+type Attachment struct {
+	AttachmentId     uint   `gorm:"primaryKey"`
+	OwnerType        string `gorm:"index:idx_attachments_owner;not null"`
+	OwnerId          uint   `gorm:"index:idx_attachments_owner;not null"`
+	FileName         string `gorm:"not null"`
+	ContentType      string `gorm:"not null"`
+	Size             int64
+	Checksum         string `gorm:"index"`
+	StorageKey       string `gorm:"uniqueIndex;not null"`
+	ThumbnailKey     string
+	UploadedBy       *uint
+	UploadedByApiKey *uint
+
+	CreatedAt time.Time
+}
+
+// DefectComment — комментарий в обсуждении дефекта; Mentions — пользователи, упомянутые через @
+type DefectComment struct {
+	CommentId  uint   `gorm:"primaryKey"`
+	DefectId   uint   `gorm:"index;not null"`
+	ParentId   *uint  `gorm:"index"`
+	AuthorId   uint   `gorm:"index;not null"`
+	Body       string `gorm:"type:text;not null"`
+	IsDecision bool   `gorm:"not null;default:false"`
+	EditedAt   *time.Time
+	DeletedAt  *time.Time
+	CreatedAt  time.Time
+
+	Author   User   `gorm:"foreignKey:AuthorId;references:UserId"`
+	Mentions []User `gorm:"many2many:defect_comment_mentions;joinForeignKey:CommentId;joinReferences:UserId"`
+}
+
+// DefectMeasurement — замер дефекта при диагностике; серия замеров одного дефекта образует его трек
+type DefectMeasurement struct {
+	MeasurementId uint      `gorm:"primaryKey"`
+	DefectId      uint      `gorm:"index:idx_defect_measurements_track;not null"`
+	DiagnosticId  *uint     `gorm:"index"`
+	MeasuredAt    time.Time `gorm:"index:idx_defect_measurements_track;not null"`
+	Depth         float64
+	Length        float64
+	Width         float64
+	Source        string `gorm:"not null"`
+	CreatedAt     time.Time
+}
+
+// PressureRestriction — ограничение рабочего давления, введенное на трубопроводе или на одном его участке (объекте)
+type PressureRestriction struct {
+	RestrictionId uint    `gorm:"primaryKey"`
+	PipelineId    uint    `gorm:"index;not null"`
+	ObjectId      *uint   `gorm:"index"` // nil — ограничение на весь трубопровод
+	DefectId      *uint   `gorm:"index"`
+	MaxPressure   float64 `gorm:"not null"` // МПа
+	Reason        string  `gorm:"type:text;not null"`
+	ImposedBy     *uint
+	ImposedAt     time.Time `gorm:"not null"`
+	ValidUntil    *time.Time
+	LiftedBy      *uint
+	LiftedAt      *time.Time `gorm:"index"`
+	LiftReason    string
+
+	Pipeline Pipeline `gorm:"foreignKey:PipelineId;references:PipelineId"`
+	Object   *Object  `gorm:"foreignKey:ObjectId;references:ObjectId"`
+	Imposer  *User    `gorm:"foreignKey:ImposedBy;references:UserId"`
+	Lifter   *User    `gorm:"foreignKey:LiftedBy;references:UserId"`
+}
+
+// ConditionSettings — веса составляющих и пороги индекса состояния. Хранится одной строкой;
+// пока ее нет, действуют значения по умолчанию из entities.DefaultConditionSettings.
+type ConditionSettings struct {
+	ConditionSettingsId uint `gorm:"primaryKey"`
+
+	WeightSeverity   float64 `gorm:"not null"`
+	WeightDensity    float64 `gorm:"not null"`
+	WeightRisk       float64 `gorm:"not null"`
+	WeightInspection float64 `gorm:"not null"`
+	WeightSensor     float64 `gorm:"not null"`
+
+	DensityPerObject     float64 `gorm:"not null"` // открытых дефектов на объект, при которых составляющая обнуляется
+	DensityPerKm         float64 `gorm:"not null"` // то же на километр трубопровода
+	InspectionMaxAgeDays int     `gorm:"not null"`
+	VibrationLimit       float64 `gorm:"not null"` // вибрация, с которой замер считается аномалией
+
+	UpdatedAt time.Time
+}
+
+// ConditionHistory — история индекса состояния трубопроводов и объектов вместе с составляющими
+type ConditionHistory struct {
+	ConditionHistoryId uint   `gorm:"primaryKey"`
+	EntityType         string `gorm:"index:idx_condition_history_entity;not null"`
+	EntityId           uint   `gorm:"index:idx_condition_history_entity;not null"`
+
+	Condition  float64
+	Severity   float64
+	Density    float64
+	Risk       *float64 // nil — предсказаний не было, составляющая не учитывалась
+	Inspection float64
+	Sensor     float64
+
+	CreatedAt time.Time `gorm:"index:idx_condition_history_entity"`
+}
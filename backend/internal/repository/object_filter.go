@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+// Последняя вероятность отказа и число дефектов объекта; подключаются к objects как lp и dc
+const (
+	latestProbabilityJoin = `LEFT JOIN LATERAL (
+		SELECT ph.probability FROM probability_histories ph
+		WHERE ph.object_id = objects.object_id
+		ORDER BY ph.timestamp DESC NULLS LAST, ph.probability_id DESC
+		LIMIT 1) lp ON TRUE`
+	defectCountJoin = `LEFT JOIN (
+		SELECT object_id, COUNT(*) AS cnt FROM defects GROUP BY object_id) dc ON dc.object_id = objects.object_id`
+)
+
+// objectSortColumns — объекты без предсказаний при сортировке по вероятности идут ниже нулевого риска (-1):
+// по убыванию — в конце, после объектов с предсказанной вероятностью 0
+var objectSortColumns = map[string]string{
+	entities.ObjectSortName:        "objects.object_name",
+	entities.ObjectSortProbability: "COALESCE(lp.probability, -1)",
+	entities.ObjectSortDefects:     "COALESCE(dc.cnt, 0)",
+	entities.ObjectSortYear:        "objects.installation_year",
+	entities.ObjectSortId:          "objects.object_id",
+	// еще не посчитанные — как наихудшее состояние
+	entities.ObjectSortCondition: "COALESCE(objects.condition, -1)",
+}
+
+// riskBandSQL — условие зоны риска по lp.probability
+func riskBandSQL(band string) (string, []interface{}) {
+	switch band {
+	case entities.RiskNone:
+		return "lp.probability IS NULL", nil
+	case entities.RiskLow:
+		return "lp.probability < ?", []interface{}{entities.RiskMediumFrom}
+	case entities.RiskMedium:
+		return "(lp.probability >= ? AND lp.probability < ?)", []interface{}{entities.RiskMediumFrom, entities.RiskHighFrom}
+	case entities.RiskHigh:
+		return "lp.probability >= ?", []interface{}{entities.RiskHighFrom}
+	}
+	return "1 = 0", nil
+}
+
+func applyObjectFilter(query *gorm.DB, f entities.ObjectFilter) *gorm.DB {
+	if len(f.PipelineIds) > 0 {
+		query = query.Where("objects.pipeline_id IN ?", f.PipelineIds)
+	}
+	if len(f.TypeIds) > 0 {
+		query = query.Where("objects.object_type_id IN ?", f.TypeIds)
+	}
+	if len(f.Materials) > 0 {
+		query = query.Where("objects.material IN ?", f.Materials)
+	}
+	if len(f.RiskBands) > 0 {
+		conds := make([]string, 0, len(f.RiskBands))
+		var args []interface{}
+		for _, band := range f.RiskBands {
+			cond, condArgs := riskBandSQL(band)
+			conds = append(conds, cond)
+			args = append(args, condArgs...)
+		}
+		query = query.Where("("+strings.Join(conds, " OR ")+")", args...)
+	}
+	if f.BBox != nil {
+		query = query.Where("objects.lon BETWEEN ? AND ? AND objects.lat BETWEEN ? AND ?",
+			f.BBox.MinLon, f.BBox.MaxLon, f.BBox.MinLat, f.BBox.MaxLat)
+	}
+	return query
+}
+
+func objectOrder(f entities.ObjectFilter) string {
+	sort, desc := f.Sort, f.Desc
+	if sort == "" {
+		sort = entities.ObjectSortName
+	}
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("%s %s, objects.object_id %s", objectSortColumns[sort], dir, dir)
+}
+
+// ListObjects — страница объектов в области видимости с последней вероятностью отказа и числом дефектов
+func (r *ObjectRepository) ListObjects(ctx context.Context, f entities.ObjectFilter) ([]entities.ObjectSummary, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Object{}).
+		Joins(latestProbabilityJoin).
+		Joins(defectCountJoin).
+		Scopes(ScopeObjects(ctx, "objects"))
+	query = applyObjectFilter(query, f)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		ObjectId    uint
+		Probability *float64
+		DefectCount int64
+	}
+	if err := query.Select("objects.object_id, lp.probability, COALESCE(dc.cnt, 0) AS defect_count").
+		Scopes(Paginate(f.Page, f.Limit)).
+		Order(objectOrder(f)).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(rows) == 0 {
+		return []entities.ObjectSummary{}, total, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ObjectId
+	}
+	var objects []models.Object
+	if err := r.db.WithContext(ctx).Preload("ObjectType").Preload("Pipeline").
+		Find(&objects, "object_id IN ?", ids).Error; err != nil {
+		return nil, 0, err
+	}
+	byId := make(map[uint]models.Object, len(objects))
+	for _, m := range objects {
+		byId[m.ObjectId] = m
+	}
+
+	// порядок страницы задает первый запрос
+	list := make([]entities.ObjectSummary, 0, len(rows))
+	for _, row := range rows {
+		m, ok := byId[row.ObjectId]
+		if !ok {
+			continue
+		}
+		list = append(list, entities.ObjectSummary{
+			Object:       ObjectToEntity(m),
+			TypeName:     m.ObjectType.ObjectTypeName,
+			PipelineName: m.Pipeline.Name,
+			Probability:  row.Probability,
+			RiskBand:     entities.RiskBand(row.Probability),
+			DefectCount:  row.DefectCount,
+		})
+	}
+	return list, total, nil
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
 	"github.com/rwrrioe/integrity/backend/internal/repository/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-var ErrObjectNotFound = fmt.Errorf("object not found")
+var (
+	ErrObjectNotFound = fmt.Errorf("object not found")
+	ErrObjectInUse    = fmt.Errorf("object has defects, diagnostics or other linked records")
+)
 
 type AvgObjStat struct {
 	ObjectId      uint    `json:"object_id"`
@@ -32,6 +37,14 @@ type ObjectRepo interface {
 	ListDefects(ctx context.Context, objectId uint) (*[]entities.Defect, error)
 	GetProbabilityHistory(ctx context.Context, objectId uint) (*[]entities.MonthlyProbability, error)
 	GetAvgStatistics(ctx context.Context, objectId uint) (*AvgObjStat, error)
+	SetPipeProperties(ctx context.Context, objectId uint, pipe entities.PipeProperties) error
+	ListObjects(ctx context.Context, f entities.ObjectFilter) ([]entities.ObjectSummary, int64, error)
+	UpdateObjectFields(ctx context.Context, objectId uint, fields map[string]interface{}) error
+	DeleteObject(ctx context.Context, objectId uint) error
+	ObjectTypeExists(ctx context.Context, typeId uint) (bool, error)
+	SnapObject(ctx context.Context, objectId uint) error
+	AssignDistrict(ctx context.Context, objectId uint) error
+	AddProbability(ctx context.Context, objectId uint, probability float64) error
 }
 
 type ObjectRepository struct {
@@ -90,7 +103,10 @@ func (r *ObjectRepository) FindNearestEmployees(ctx context.Context, objectId ui
 func (r *ObjectRepository) ListEmployees(ctx context.Context, objectId uint) (*[]entities.Employee, error) {
 	var models []models.Employee
 
-	if err := r.db.WithContext(ctx).Where("object_id=?", objectId).Limit(10).Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN object_employees ON object_employees.employee_id = employees.employee_id").
+		Where("object_employees.object_id = ?", objectId).
+		Limit(10).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
@@ -104,7 +120,9 @@ func (r *ObjectRepository) ListEmployees(ctx context.Context, objectId uint) (*[
 
 func (r *ObjectRepository) GetObject(ctx context.Context, objectId uint) (*entities.Object, error) {
 	var model models.Object
-	if err := r.db.WithContext(ctx).First(&model, "object_id=?", objectId).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Scopes(ScopeObjects(ctx, "objects")).
+		First(&model, "object_id=?", objectId).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrObjectNotFound
 		}
@@ -115,20 +133,123 @@ func (r *ObjectRepository) GetObject(ctx context.Context, objectId uint) (*entit
 	return &object, nil
 }
 
+func (r *ObjectRepository) SetPipeProperties(ctx context.Context, objectId uint, pipe entities.PipeProperties) error {
+	res := r.db.WithContext(ctx).Model(&models.Object{}).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Where("object_id = ?", objectId).
+		Updates(map[string]interface{}{
+			"outer_diameter": pipe.OuterDiameter,
+			"wall_thickness": pipe.WallThickness,
+			"smys":           pipe.Smys,
+			"maop":           pipe.Maop,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrObjectNotFound
+	}
+	return nil
+}
+
 func (r *ObjectRepository) AddObject(ctx context.Context, object *entities.Object) error {
 	model := ObjectToModel(*object)
+	model.Location = geoPoint(object.Lat, object.Lon)
 
-	if err := r.db.WithContext(ctx).Save(&model).Error; err != nil {
+	if err := r.db.WithContext(ctx).Omit(clause.Associations).Create(&model).Error; err != nil {
 		return err
 	}
 
+	object.ObjectId = model.ObjectId
+	return nil
+}
+
+// geoPoint — значение колонки location в формате EWKT
+func geoPoint(lat, lon float64) string {
+	return fmt.Sprintf("SRID=4326;POINT(%f %f)", lon, lat)
+}
+
+// UpdateObjectFields меняет поля объекта; при смене координат обновляет и location
+func (r *ObjectRepository) UpdateObjectFields(ctx context.Context, objectId uint, fields map[string]interface{}) error {
+	lat, hasLat := fields["lat"].(float64)
+	lon, hasLon := fields["lon"].(float64)
+	if hasLat && hasLon {
+		fields["location"] = geoPoint(lat, lon)
+	}
+
+	res := r.db.WithContext(ctx).Model(&models.Object{}).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Where("object_id = ?", objectId).
+		Updates(fields)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrObjectNotFound
+	}
 	return nil
 }
 
+// DeleteObject удаляет объект без истории: дефекты, диагностики, датчики, ограничения и вложения
+// остаются за объектом, поэтому при их наличии удаление запрещено
+func (r *ObjectRepository) DeleteObject(ctx context.Context, objectId uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var object models.Object
+		if err := tx.Scopes(ScopeObjects(ctx, "objects")).
+			First(&object, "object_id = ?", objectId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrObjectNotFound
+			}
+			return err
+		}
+
+		var linked bool
+		if err := tx.Raw(`SELECT EXISTS (SELECT 1 FROM defects WHERE object_id = ?)
+			OR EXISTS (SELECT 1 FROM diagnostics WHERE object_id = ?)
+			OR EXISTS (SELECT 1 FROM sensors WHERE object_id = ?)
+			OR EXISTS (SELECT 1 FROM pressure_restrictions WHERE object_id = ?)
+			OR EXISTS (SELECT 1 FROM attachments WHERE owner_type = ? AND owner_id = ?)`,
+			objectId, objectId, objectId, objectId, entities.AttachmentOwnerObject, objectId).
+			Scan(&linked).Error; err != nil {
+			return err
+		}
+		if linked {
+			return ErrObjectInUse
+		}
+
+		if err := tx.Exec("DELETE FROM object_employees WHERE object_id = ?", objectId).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("object_id = ?", objectId).Delete(&models.ProbabilityHistory{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Object{}, "object_id = ?", objectId).Error
+	})
+}
+
+func (r *ObjectRepository) ObjectTypeExists(ctx context.Context, typeId uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ObjectType{}).Where("object_type_id = ?", typeId).Count(&count).Error
+	return count > 0, err
+}
+
+// AddProbability сохраняет предсказанную вероятность отказа (доля 0..1) в историю объекта
+func (r *ObjectRepository) AddProbability(ctx context.Context, objectId uint, probability float64) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(&models.ProbabilityHistory{
+		ObjectId:    objectId,
+		Probability: probability,
+		Timestamp:   &now,
+	}).Error
+}
+
 func (r *ObjectRepository) ListDefects(ctx context.Context, objectId uint) (*[]entities.Defect, error) {
 	var models []models.Defect
 
-	if err := r.db.WithContext(ctx).Where("object_id=?", objectId).Limit(10).Find(&models).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN object_employees ON object_employees.employee_id = employees.employee_id").
+		Where("object_employees.object_id = ?", objectId).
+		Limit(10).Find(&models).Error; err != nil {
 		return nil, err
 	}
 
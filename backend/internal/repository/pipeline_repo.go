@@ -2,14 +2,28 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
 	"github.com/rwrrioe/integrity/backend/internal/repository/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPipelineNotFound = fmt.Errorf("pipeline not found")
+	ErrPipelineArchived = fmt.Errorf("pipeline is archived")
 )
 
 type PipelineRepo interface {
 	ListDefects(ctx context.Context, pipelineId int) (*[]entities.Defect, error)
+	GetPipeline(ctx context.Context, pipelineId uint) (*models.Pipeline, error)
+	SetDesignMaop(ctx context.Context, pipelineId uint, maop float64) error
+	ListPipelines(ctx context.Context, filter entities.PipelineFilter) ([]models.Pipeline, int64, error)
+	CreatePipeline(ctx context.Context, pipeline *models.Pipeline) error
+	UpdatePipelineFields(ctx context.Context, pipelineId uint, fields map[string]interface{}) error
+	NameTaken(ctx context.Context, name string, exceptId uint) (bool, error)
 }
 
 type PipelineRepository struct {
@@ -34,3 +48,102 @@ func (r *PipelineRepository) ListDefects(ctx context.Context, pipelineId int) (*
 	}
 	return &defects, nil
 }
+
+// scopePipelines — трубопровод виден, если он назначен пользователю напрямую
+// (в том числе новый, еще без объектов) или виден хотя бы один его объект
+func scopePipelines(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		cond, args := scopeCondition(ctx, "o")
+		if cond == "" {
+			return db
+		}
+		scope := entities.ScopeFromContext(ctx)
+		args = append([]interface{}{nonEmpty(scope.PipelineIds)}, args...)
+		return db.Where("(pipelines.pipeline_id IN ? OR EXISTS (SELECT 1 FROM objects o WHERE o.pipeline_id = pipelines.pipeline_id AND "+cond+"))", args...)
+	}
+}
+
+func (r *PipelineRepository) GetPipeline(ctx context.Context, pipelineId uint) (*models.Pipeline, error) {
+	var pipeline models.Pipeline
+	if err := r.db.WithContext(ctx).
+		Scopes(scopePipelines(ctx)).
+		First(&pipeline, "pipelines.pipeline_id = ?", pipelineId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPipelineNotFound
+		}
+		return nil, err
+	}
+	return &pipeline, nil
+}
+
+func (r *PipelineRepository) SetDesignMaop(ctx context.Context, pipelineId uint, maop float64) error {
+	res := r.db.WithContext(ctx).Model(&models.Pipeline{}).
+		Scopes(scopePipelines(ctx)).
+		Where("pipelines.pipeline_id = ?", pipelineId).
+		Update("design_maop", maop)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPipelineNotFound
+	}
+	return nil
+}
+
+func (r *PipelineRepository) ListPipelines(ctx context.Context, filter entities.PipelineFilter) ([]models.Pipeline, int64, error) {
+	var rows []models.Pipeline
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Pipeline{}).Scopes(scopePipelines(ctx))
+	if !filter.IncludeArchived {
+		query = query.Where("pipelines.archived_at IS NULL")
+	}
+	if filter.Search != "" {
+		like := "%" + filter.Search + "%"
+		query = query.Where("(pipelines.name ILIKE ? OR pipelines.operator ILIKE ?)", like, like)
+	}
+	if filter.Operator != "" {
+		query = query.Where("pipelines.operator = ?", filter.Operator)
+	}
+	if filter.Product != "" {
+		query = query.Where("pipelines.product = ?", filter.Product)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Scopes(Paginate(filter.Page, filter.Limit)).
+		Order("pipelines.name ASC, pipelines.pipeline_id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+func (r *PipelineRepository) CreatePipeline(ctx context.Context, pipeline *models.Pipeline) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(pipeline).Error
+}
+
+func (r *PipelineRepository) UpdatePipelineFields(ctx context.Context, pipelineId uint, fields map[string]interface{}) error {
+	res := r.db.WithContext(ctx).Model(&models.Pipeline{}).
+		Scopes(scopePipelines(ctx)).
+		Where("pipelines.pipeline_id = ?", pipelineId).
+		Updates(fields)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPipelineNotFound
+	}
+	return nil
+}
+
+// NameTaken проверяет уникальность имени без учета регистра и области видимости:
+// импорт CSV сопоставляет трубопроводы по имени
+func (r *PipelineRepository) NameTaken(ctx context.Context, name string, exceptId uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Pipeline{}).
+		Where("LOWER(name) = LOWER(?) AND pipeline_id <> ?", name, exceptId).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
@@ -11,6 +13,7 @@ import (
 
 type ReportRepo interface {
 	// Для Executive Report
+	GetPipelineName(ctx context.Context, pipelineID uint) (string, error)
 	GetPipelineStats(ctx context.Context, pipelineID uint, dateFrom, dateTo time.Time) (*entities.ExecutiveStats, error)
 	ListDefectsByPipeline(ctx context.Context, pipelineID uint, dateFrom, dateTo time.Time) (*[]entities.Defect, error)
 	GetDefectBreakdown(ctx context.Context, pipelineID uint, dateFrom, dateTo time.Time) ([]entities.DefectBreakdownRow, error)
@@ -27,12 +30,28 @@ func NewReportRepository(db *gorm.DB) *ReportRepository {
 	return &ReportRepository{db: db}
 }
 
+func (r *ReportRepository) GetPipelineName(ctx context.Context, pipelineID uint) (string, error) {
+	var pipeline models.Pipeline
+	if err := r.db.WithContext(ctx).
+		Scopes(scopePipelines(ctx)).
+		Select("pipelines.name").
+		First(&pipeline, "pipelines.pipeline_id = ?", pipelineID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrPipelineNotFound
+		}
+		return "", err
+	}
+	return pipeline.Name, nil
+}
+
 func (r *ReportRepository) GetPipelineStats(ctx context.Context, pipelineID uint, dateFrom, dateTo time.Time) (*entities.ExecutiveStats, error) {
 	var stats entities.ExecutiveStats
 
 	baseQuery := r.db.WithContext(ctx).Model(&models.Defect{}).
 		Joins("JOIN objects ON defects.object_id = objects.object_id").
-		Where("objects.pipeline_id = ? AND defects.date BETWEEN ? AND ?", pipelineID, dateFrom, dateTo)
+		Where("objects.pipeline_id = ? AND defects.date BETWEEN ? AND ?", pipelineID, dateFrom, dateTo).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Session(&gorm.Session{}) // каждый подсчет ниже добавляет свое условие к общей базе, а не к предыдущему
 
 	var total int64
 	if err := baseQuery.Count(&total).Error; err != nil {
@@ -40,17 +59,19 @@ func (r *ReportRepository) GetPipelineStats(ctx context.Context, pipelineID uint
 	}
 	stats.TotalDefects = int(total)
 
-	// 2. Critical Issues
-	// ID 5 = "недопустимо" (Critical)
+	// 2. Critical Issues — оценки, отмеченные в каталоге как критичные
 	var critical int64
-	if err := baseQuery.Where("defects.quality_grade_id = ?", 5).Count(&critical).Error; err != nil {
+	if err := baseQuery.
+		Joins("LEFT JOIN quality_grades qg ON qg.quality_grade_id = defects.quality_grade_id").
+		Where(gradeCritical).
+		Count(&critical).Error; err != nil {
 		return nil, err
 	}
 	stats.CriticalIssues = int(critical)
 
-	// 3. Resolved (Status = 'Solved')
+	// 3. Resolved (Status = 'Closed')
 	var resolved int64
-	if err := baseQuery.Where("defects.status = ?", "Solved").Count(&resolved).Error; err != nil {
+	if err := baseQuery.Where("defects.status = ?", entities.DefectClosed).Count(&resolved).Error; err != nil {
 		return nil, err
 	}
 	stats.Resolved = int(resolved)
@@ -60,6 +81,7 @@ func (r *ReportRepository) GetPipelineStats(ctx context.Context, pipelineID uint
 	if err := r.db.WithContext(ctx).Model(&models.Diagnostic{}).
 		Joins("JOIN objects ON diagnostics.object_id = objects.object_id").
 		Where("objects.pipeline_id = ? AND diagnostics.date BETWEEN ? AND ?", pipelineID, dateFrom, dateTo).
+		Scopes(ScopeObjects(ctx, "objects")).
 		Count(&diagnosticsCount).Error; err != nil {
 		return nil, err
 	}
@@ -75,9 +97,10 @@ func (r *ReportRepository) ListDefectsByPipeline(ctx context.Context, pipelineID
 	err := r.db.WithContext(ctx).
 		Joins("JOIN objects ON defects.object_id = objects.object_id").
 		Where("objects.pipeline_id = ? AND defects.date BETWEEN ? AND ?", pipelineID, dateFrom, dateTo).
+		Scopes(ScopeObjects(ctx, "objects")).
 		Preload("DefectType").
 		Preload("QualityGrade").
-		Preload("Objects").
+		Preload("Object").
 		Find(&dbDefects).Error
 
 	if err != nil {
@@ -95,28 +118,27 @@ func (r *ReportRepository) ListDefectsByPipeline(ctx context.Context, pipelineID
 func (r *ReportRepository) GetDefectBreakdown(ctx context.Context, pipelineID uint, dateFrom, dateTo time.Time) ([]entities.DefectBreakdownRow, error) {
 	var results []entities.DefectBreakdownRow
 
-	// Используем SUM(CASE ...) для подсчета по конкретным ID quality_grade_id
-	// 5 - Недопустимо (Critical)
-	// 4 - Требует мер (High)
-	// 7 - Удовлетворительно (Medium)
-	// 6 - Допустимо (Low)
+	// Разбивка по приоритету оценки из каталога (quality_grades.priority)
 	query := `
 		SELECT 
 			dt.name as defect_type,
 			COUNT(*) as count,
-			SUM(CASE WHEN d.quality_grade_id = 5 THEN 1 ELSE 0 END) as critical,
-			SUM(CASE WHEN d.quality_grade_id = 4 THEN 1 ELSE 0 END) as high,
-			SUM(CASE WHEN d.quality_grade_id = 7 THEN 1 ELSE 0 END) as medium,
-			SUM(CASE WHEN d.quality_grade_id = 6 THEN 1 ELSE 0 END) as low
+			SUM(CASE WHEN ` + gradePriority + ` = 'critical' THEN 1 ELSE 0 END) as critical,
+			SUM(CASE WHEN ` + gradePriority + ` = 'high' THEN 1 ELSE 0 END) as high,
+			SUM(CASE WHEN ` + gradePriority + ` = 'medium' THEN 1 ELSE 0 END) as medium,
+			SUM(CASE WHEN ` + gradePriority + ` = 'low' THEN 1 ELSE 0 END) as low
 		FROM defects d
 		JOIN objects o ON d.object_id = o.object_id
 		JOIN defect_types dt ON d.defect_type_id = dt.defect_type_id
-		WHERE o.pipeline_id = ? AND d.date BETWEEN ? AND ?
+		LEFT JOIN quality_grades qg ON qg.quality_grade_id = d.quality_grade_id
+		WHERE o.pipeline_id = ? AND d.date BETWEEN ? AND ?%s
 		GROUP BY dt.name
 		ORDER BY count DESC
 	`
+	scope, scopeArgs := scopeSQL(ctx, "o")
+	args := append([]interface{}{pipelineID, dateFrom, dateTo}, scopeArgs...)
 
-	if err := r.db.WithContext(ctx).Raw(query, pipelineID, dateFrom, dateTo).Scan(&results).Error; err != nil {
+	if err := r.db.WithContext(ctx).Raw(fmt.Sprintf(query, scope), args...).Scan(&results).Error; err != nil {
 		return nil, err
 	}
 
@@ -128,10 +150,12 @@ func (r *ReportRepository) GetDefectWithObjectDetails(ctx context.Context, defec
 	var dbDefect models.Defect
 
 	err := r.db.WithContext(ctx).
-		Preload("DefectTypes").
-		Preload("Objects").
-		Preload("QualityGrades").
-		First(&dbDefect, "defect_id = ?", defectID).Error
+		Joins("JOIN objects ON defects.object_id = objects.object_id").
+		Scopes(ScopeObjects(ctx, "objects")).
+		Preload("DefectType").
+		Preload("Object").
+		Preload("QualityGrade").
+		First(&dbDefect, "defects.defect_id = ?", defectID).Error
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRestrictionNotFound  = fmt.Errorf("pressure restriction not found")
+	ErrRestrictionNotActive = fmt.Errorf("pressure restriction is already lifted or expired")
+)
+
+type RestrictionRepo interface {
+	CreateRestriction(ctx context.Context, restriction *models.PressureRestriction) error
+	GetRestriction(ctx context.Context, restrictionId uint) (*entities.PressureRestriction, error)
+	ListRestrictions(ctx context.Context, filter entities.RestrictionFilter) ([]entities.PressureRestriction, int64, error)
+	ActiveRestrictions(ctx context.Context, pipelineIds []uint) ([]entities.PressureRestriction, error)
+	LiftRestriction(ctx context.Context, restrictionId uint, liftedBy *uint, reason string) error
+	ListSegments(ctx context.Context, query SegmentQuery) ([]models.Object, error)
+	ListOpenDefects(ctx context.Context, objectIds []uint, metalLoss bool) ([]entities.Defect, error)
+}
+
+// SegmentQuery — какие участки (объекты) брать для расчета допустимого давления
+type SegmentQuery struct {
+	PipelineId uint
+	ObjectId   uint
+	// OnlyCandidates — только участки с открытыми дефектами или действующими ограничениями:
+	// остальные заведомо работают на проектном давлении
+	OnlyCandidates bool
+}
+
+type RestrictionRepository struct {
+	db *gorm.DB
+}
+
+func NewRestrictionRepository(db *gorm.DB) *RestrictionRepository {
+	return &RestrictionRepository{db: db}
+}
+
+// scopeRestrictions — ограничение видно, если виден участок, на который оно введено,
+// а ограничение на весь трубопровод — если виден хотя бы один его объект
+func scopeRestrictions(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		cond, args := scopeCondition(ctx, "o")
+		if cond == "" {
+			return db
+		}
+		return db.Where(`EXISTS (
+			SELECT 1 FROM objects o
+			WHERE o.pipeline_id = pressure_restrictions.pipeline_id
+			AND (pressure_restrictions.object_id IS NULL OR o.object_id = pressure_restrictions.object_id)
+			AND `+cond+`)`, args...)
+	}
+}
+
+const activeRestriction = "pressure_restrictions.lifted_at IS NULL AND (pressure_restrictions.valid_until IS NULL OR pressure_restrictions.valid_until > ?)"
+
+func preloadRestriction(db *gorm.DB) *gorm.DB {
+	return db.Preload("Pipeline").Preload("Object").Preload("Imposer").Preload("Lifter")
+}
+
+func (r *RestrictionRepository) CreateRestriction(ctx context.Context, restriction *models.PressureRestriction) error {
+	return r.db.WithContext(ctx).Omit("Pipeline", "Object", "Imposer", "Lifter").Create(restriction).Error
+}
+
+func (r *RestrictionRepository) GetRestriction(ctx context.Context, restrictionId uint) (*entities.PressureRestriction, error) {
+	var model models.PressureRestriction
+	if err := r.db.WithContext(ctx).
+		Scopes(scopeRestrictions(ctx), preloadRestriction).
+		First(&model, "pressure_restrictions.restriction_id = ?", restrictionId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRestrictionNotFound
+		}
+		return nil, err
+	}
+
+	restriction := RestrictionToEntity(model, time.Now())
+	return &restriction, nil
+}
+
+func (r *RestrictionRepository) ListRestrictions(ctx context.Context, filter entities.RestrictionFilter) ([]entities.PressureRestriction, int64, error) {
+	now := time.Now()
+	query := r.db.WithContext(ctx).Model(&models.PressureRestriction{}).Scopes(scopeRestrictions(ctx))
+
+	if filter.PipelineId != 0 {
+		query = query.Where("pressure_restrictions.pipeline_id = ?", filter.PipelineId)
+	}
+	if filter.ObjectId != 0 {
+		// ограничение на весь трубопровод действует и на каждый его участок
+		query = query.Where(`(pressure_restrictions.object_id = ? OR (pressure_restrictions.object_id IS NULL
+			AND pressure_restrictions.pipeline_id = (SELECT pipeline_id FROM objects WHERE object_id = ?)))`, filter.ObjectId, filter.ObjectId)
+	}
+	if filter.ActiveOnly {
+		query = query.Where(activeRestriction, now)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.PressureRestriction
+	if err := query.
+		Scopes(preloadRestriction, Paginate(filter.Page, filter.Limit)).
+		Order("pressure_restrictions.imposed_at DESC").
+		Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	restrictions := make([]entities.PressureRestriction, 0, len(rows))
+	for _, m := range rows {
+		restrictions = append(restrictions, RestrictionToEntity(m, now))
+	}
+	return restrictions, total, nil
+}
+
+// ActiveRestrictions — все действующие ограничения на указанных трубопроводах (и на весь трубопровод, и на участки)
+func (r *RestrictionRepository) ActiveRestrictions(ctx context.Context, pipelineIds []uint) ([]entities.PressureRestriction, error) {
+	if len(pipelineIds) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var rows []models.PressureRestriction
+	if err := r.db.WithContext(ctx).
+		Scopes(scopeRestrictions(ctx), preloadRestriction).
+		Where("pressure_restrictions.pipeline_id IN ?", pipelineIds).
+		Where(activeRestriction, now).
+		Order("pressure_restrictions.max_pressure").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	restrictions := make([]entities.PressureRestriction, 0, len(rows))
+	for _, m := range rows {
+		restrictions = append(restrictions, RestrictionToEntity(m, now))
+	}
+	return restrictions, nil
+}
+
+// LiftRestriction снимает действующее ограничение; снятое или истекшее повторно не снимается
+func (r *RestrictionRepository) LiftRestriction(ctx context.Context, restrictionId uint, liftedBy *uint, reason string) error {
+	now := time.Now()
+	res := r.db.WithContext(ctx).Model(&models.PressureRestriction{}).
+		Scopes(scopeRestrictions(ctx)).
+		Where("pressure_restrictions.restriction_id = ?", restrictionId).
+		Where(activeRestriction, now).
+		Updates(map[string]interface{}{
+			"lifted_at":   now,
+			"lifted_by":   liftedBy,
+			"lift_reason": reason,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		if _, err := r.GetRestriction(ctx, restrictionId); err != nil {
+			return err
+		}
+		return ErrRestrictionNotActive
+	}
+	return nil
+}
+
+func (r *RestrictionRepository) ListSegments(ctx context.Context, query SegmentQuery) ([]models.Object, error) {
+	db := r.db.WithContext(ctx).
+		Scopes(ScopeObjects(ctx, "objects")).
+		Preload("Pipeline")
+
+	if query.PipelineId != 0 {
+		db = db.Where("objects.pipeline_id = ?", query.PipelineId)
+	}
+	if query.ObjectId != 0 {
+		db = db.Where("objects.object_id = ?", query.ObjectId)
+	}
+	if query.OnlyCandidates {
+		db = db.Where(`(EXISTS (SELECT 1 FROM defects d WHERE d.object_id = objects.object_id AND d.status IN ?)
+			OR EXISTS (SELECT 1 FROM pressure_restrictions
+				WHERE pressure_restrictions.pipeline_id = objects.pipeline_id
+				AND (pressure_restrictions.object_id IS NULL OR pressure_restrictions.object_id = objects.object_id)
+				AND `+activeRestriction+`))`, entities.OpenDefectStatuses, time.Now())
+	}
+
+	var objects []models.Object
+	if err := db.Order("objects.pipeline_id, objects.object_id").Find(&objects).Error; err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// ListOpenDefects — открытые дефекты участков. metalLoss = true отбирает дефекты потери металла,
+// чье безопасное давление ограничивает МДРД; false — остальные, которые B31G не оценивает.
+func (r *RestrictionRepository) ListOpenDefects(ctx context.Context, objectIds []uint, metalLoss bool) ([]entities.Defect, error) {
+	if len(objectIds) == 0 {
+		return nil, nil
+	}
+
+	typeCond := "LOWER(TRIM(COALESCE(dt.name, ''))) IN ?"
+	if !metalLoss {
+		typeCond = "LOWER(TRIM(COALESCE(dt.name, ''))) NOT IN ?"
+	}
+
+	var rows []models.Defect
+	if err := r.db.WithContext(ctx).
+		Joins("LEFT JOIN defect_types dt ON dt.defect_type_id = defects.defect_type_id").
+		Where("defects.object_id IN ? AND defects.status IN ?", objectIds, entities.OpenDefectStatuses).
+		Where(typeCond, entities.MetalLossDefectTypes).
+		Preload("DefectType").
+		Order("defects.defect_id").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	defects := make([]entities.Defect, 0, len(rows))
+	for _, m := range rows {
+		defects = append(defects, DefectToEntity(m))
+	}
+	return defects, nil
+}
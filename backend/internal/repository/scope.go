@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// ScopeObjects ограничивает запрос объектами, которые доступны пользователю из ctx.
+// objects — имя или алиас таблицы objects в запросе (она должна быть заджойнена).
+func ScopeObjects(ctx context.Context, objects string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		cond, args := scopeCondition(ctx, objects)
+		if cond == "" {
+			return db
+		}
+		return db.Where(cond, args...)
+	}
+}
+
+// scopeSQL — то же самое для Raw-запросов: возвращает фрагмент " AND (...)" и его аргументы
+func scopeSQL(ctx context.Context, objects string) (string, []interface{}) {
+	cond, args := scopeCondition(ctx, objects)
+	if cond == "" {
+		return "", nil
+	}
+	return " AND " + cond, args
+}
+
+func scopeCondition(ctx context.Context, objects string) (string, []interface{}) {
+	scope := entities.ScopeFromContext(ctx)
+	if scope.All {
+		return "", nil
+	}
+	if len(scope.PipelineIds) == 0 && len(scope.DistrictIds) == 0 {
+		return "1 = 0", nil
+	}
+
+	cond := fmt.Sprintf("(%[1]s.pipeline_id IN ? OR %[1]s.district_id IN ?)", objects)
+	return cond, []interface{}{nonEmpty(scope.PipelineIds), nonEmpty(scope.DistrictIds)}
+}
+
+// nonEmpty нужен, чтобы "IN ?" с пустым списком не превращался в невалидный SQL
+func nonEmpty(ids []uint) []uint {
+	if len(ids) == 0 {
+		return []uint{0}
+	}
+	return ids
+}
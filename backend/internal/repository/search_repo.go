@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// MinWordSimilarity — порог нечеткого совпадения (pg_trgm word_similarity): ловит опечатки
+// вроде "корозия" и неполные слова, но не случайные совпадения по паре букв.
+// Условия поиска используют оператор "? <% expr", который берет порог из pg_trgm.word_similarity_threshold
+// соединения (его выставляет database.DbConnect) и, в отличие от функции word_similarity, идет по GIN-индексу.
+const MinWordSimilarity = 0.4
+
+// tsDoc — документ для полнотекстового поиска сразу по русской и английской морфологии.
+// Выражения, по которым ищут, совпадают с индексами database.ensureSearchIndexes — иначе индекс не возьмется.
+func tsDoc(expr string) string {
+	return fmt.Sprintf("(to_tsvector('russian', coalesce(%[1]s, '')) || to_tsvector('english', coalesce(%[1]s, '')))", expr)
+}
+
+// tsQuery — запрос в синтаксисе поисковой строки ("коррозия -вмятина", "MT-02 OR MT-03"); аргумент передается дважды
+const tsQuery = "(websearch_to_tsquery('russian', ?) || websearch_to_tsquery('english', ?))"
+
+// headline подсвечивает совпадения тегом <mark>. Исходный текст экранируется до подсветки,
+// чтобы в выдачу не попал пользовательский HTML из описаний.
+func headline(expr string) string {
+	escaped := fmt.Sprintf("replace(replace(replace(coalesce(%s, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;')", expr)
+	return fmt.Sprintf("ts_headline('russian', %s, %s, 'StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=8, MaxFragments=2')", escaped, tsQuery)
+}
+
+// DefectMatch — условие поиска дефекта по описанию и названию типа; ожидает LEFT JOIN defect_types dt.
+// Используется и глобальным поиском, и поиском в списке дефектов.
+func DefectMatch(q string) (string, []interface{}) {
+	cond := fmt.Sprintf("(%s @@ %s OR %s @@ %s OR ? <%% coalesce(dt.name, ''))",
+		tsDoc("defects.description"), tsQuery, tsDoc("dt.name"), tsQuery)
+	return cond, []interface{}{q, q, q, q, q}
+}
+
+type SearchRepo interface {
+	SearchDefects(ctx context.Context, q string, limit int) ([]entities.SearchResult, error)
+	SearchObjects(ctx context.Context, q string, limit int) ([]entities.SearchResult, error)
+	SearchPipelines(ctx context.Context, q string, limit int) ([]entities.SearchResult, error)
+}
+
+type SearchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// rank = релевантность полнотекстового совпадения + лучшая нечеткая похожесть; оба слагаемых в [0, 1]
+
+func (r *SearchRepository) SearchDefects(ctx context.Context, q string, limit int) ([]entities.SearchResult, error) {
+	match, matchArgs := DefectMatch(q)
+	scope, scopeArgs := scopeSQL(ctx, "objects")
+
+	query := fmt.Sprintf(`
+		SELECT
+			defects.defect_id AS id,
+			coalesce(dt.name, '') AS title,
+			objects.object_name || coalesce(' · ' || p.name, '') AS subtitle,
+			%s AS highlight,
+			ts_rank(%s, %s) + word_similarity(?, coalesce(dt.name, '')) AS rank
+		FROM defects
+		JOIN objects ON objects.object_id = defects.object_id
+		LEFT JOIN defect_types dt ON dt.defect_type_id = defects.defect_type_id
+		LEFT JOIN pipelines p ON p.pipeline_id = objects.pipeline_id
+		WHERE %s%s
+		ORDER BY rank DESC, defects.date DESC
+		LIMIT ?
+	`, headline("defects.description"), tsDoc("defects.description || ' ' || coalesce(dt.name, '')"), tsQuery, match, scope)
+
+	args := []interface{}{q, q, q, q, q}
+	args = append(args, matchArgs...)
+	args = append(args, scopeArgs...)
+	args = append(args, limit)
+
+	return r.scan(ctx, entities.SearchDefect, query, args)
+}
+
+func (r *SearchRepository) SearchObjects(ctx context.Context, q string, limit int) ([]entities.SearchResult, error) {
+	scope, scopeArgs := scopeSQL(ctx, "objects")
+	doc := tsDoc("objects.object_name || ' ' || coalesce(objects.material, '') || ' ' || coalesce(p.name, '')")
+	// в условии документ объекта и название трубопровода раздельно: первое идет по индексу на objects
+	match := tsDoc("objects.object_name || ' ' || coalesce(objects.material, '')")
+
+	query := fmt.Sprintf(`
+		SELECT
+			objects.object_id AS id,
+			objects.object_name AS title,
+			concat_ws(' · ', nullif(objects.material, ''), p.name) AS subtitle,
+			%s AS highlight,
+			ts_rank(%s, %s) + GREATEST(word_similarity(?, objects.object_name), word_similarity(?, coalesce(objects.material, ''))) AS rank
+		FROM objects
+		LEFT JOIN pipelines p ON p.pipeline_id = objects.pipeline_id
+		WHERE (%s @@ %s
+			OR %s @@ %s
+			OR ? <%% objects.object_name
+			OR ? <%% coalesce(objects.material, ''))%s
+		ORDER BY rank DESC, objects.object_id
+		LIMIT ?
+	`, headline("objects.object_name || ' ' || coalesce(objects.material, '')"), doc, tsQuery, match, tsQuery, tsDoc("p.name"), tsQuery, scope)
+
+	args := []interface{}{q, q, q, q, q, q, q, q, q, q, q, q}
+	args = append(args, scopeArgs...)
+	args = append(args, limit)
+
+	return r.scan(ctx, entities.SearchObject, query, args)
+}
+
+// SearchPipelines — трубопровод виден, если виден хотя бы один его объект
+func (r *SearchRepository) SearchPipelines(ctx context.Context, q string, limit int) ([]entities.SearchResult, error) {
+	cond, scopeArgs := scopeCondition(ctx, "o")
+	scope := ""
+	if cond != "" {
+		scope = " AND EXISTS (SELECT 1 FROM objects o WHERE o.pipeline_id = pipelines.pipeline_id AND " + cond + ")"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			pipelines.pipeline_id AS id,
+			pipelines.name AS title,
+			'' AS subtitle,
+			%s AS highlight,
+			ts_rank(%s, %s) + word_similarity(?, pipelines.name) AS rank
+		FROM pipelines
+		WHERE (%s @@ %s OR ? <%% pipelines.name)%s
+		ORDER BY rank DESC, pipelines.pipeline_id
+		LIMIT ?
+	`, headline("pipelines.name"), tsDoc("pipelines.name"), tsQuery, tsDoc("pipelines.name"), tsQuery, scope)
+
+	args := []interface{}{q, q, q, q, q, q, q, q}
+	args = append(args, scopeArgs...)
+	args = append(args, limit)
+
+	return r.scan(ctx, entities.SearchPipeline, query, args)
+}
+
+func (r *SearchRepository) scan(ctx context.Context, kind, query string, args []interface{}) ([]entities.SearchResult, error) {
+	var results []entities.SearchResult
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Type = kind
+	}
+	return results, nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUserNotFound  = fmt.Errorf("user not found")
+	ErrUserExists    = fmt.Errorf("user already exists")
+	ErrTokenInvalid  = fmt.Errorf("token is invalid or expired")
+	ErrEmployeeBound = fmt.Errorf("employee is already bound to another user")
+)
+
+type UserRepo interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUser(ctx context.Context, userId uint) (*entities.User, error)
+	ListUsers(ctx context.Context, page, limit int) ([]entities.User, int64, error)
+	SetUserRole(ctx context.Context, userId uint, role string) error
+	GetAssignments(ctx context.Context, userId uint) (*entities.UserAssignments, error)
+	SetAssignments(ctx context.Context, userId uint, assignments entities.UserAssignments) error
+	CreateToken(ctx context.Context, userId uint, purpose, tokenHash string, expiresAt time.Time) error
+	ConsumeToken(ctx context.Context, purpose, tokenHash string) (uint, error)
+	UpdatePassword(ctx context.Context, userId uint, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, userId uint) error
+	BindEmployee(ctx context.Context, userId uint, employeeId *uint) error
+}
+
+type UserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Transaction выполняет fn в одной транзакции; репозиторий tx пишет в нее
+func (r *UserRepository) Transaction(ctx context.Context, fn func(tx *UserRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&UserRepository{db: tx})
+	})
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", user.Email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrUserExists
+	}
+
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+// GetUserByEmail возвращает модель целиком (вместе с хэшем пароля) — только для аутентификации
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var model models.User
+	if err := r.db.WithContext(ctx).First(&model, "email = ?", email).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *UserRepository) GetUser(ctx context.Context, userId uint) (*entities.User, error) {
+	var model models.User
+	if err := r.db.WithContext(ctx).First(&model, "user_id = ?", userId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	user := UserToEntity(model)
+	return &user, nil
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context, page, limit int) ([]entities.User, int64, error) {
+	var rows []models.User
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.User{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Scopes(Paginate(page, limit)).Order("user_id ASC").Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	users := make([]entities.User, 0, len(rows))
+	for _, m := range rows {
+		users = append(users, UserToEntity(m))
+	}
+	return users, total, nil
+}
+
+func (r *UserRepository) SetUserRole(ctx context.Context, userId uint, role string) error {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Role{}).Where("name = ?", role).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrRoleNotFound
+	}
+
+	res := r.db.WithContext(ctx).Model(&models.User{}).Where("user_id = ?", userId).Update("role", role)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *UserRepository) GetAssignments(ctx context.Context, userId uint) (*entities.UserAssignments, error) {
+	assignments := entities.UserAssignments{PipelineIds: []uint{}, DistrictIds: []uint{}}
+
+	if err := r.db.WithContext(ctx).Table("user_pipelines").
+		Where("user_id = ?", userId).
+		Pluck("pipeline_id", &assignments.PipelineIds).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Table("user_districts").
+		Where("user_id = ?", userId).
+		Pluck("district_id", &assignments.DistrictIds).Error; err != nil {
+		return nil, err
+	}
+	return &assignments, nil
+}
+
+func (r *UserRepository) SetAssignments(ctx context.Context, userId uint, assignments entities.UserAssignments) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user := models.User{UserId: userId}
+		if err := tx.First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		pipelines := make([]models.Pipeline, len(assignments.PipelineIds))
+		for i, id := range assignments.PipelineIds {
+			pipelines[i] = models.Pipeline{PipelineId: id}
+		}
+		districts := make([]models.District, len(assignments.DistrictIds))
+		for i, id := range assignments.DistrictIds {
+			districts[i] = models.District{DistrictId: id}
+		}
+
+		if err := tx.Model(&user).Association("Pipelines").Replace(pipelines); err != nil {
+			return err
+		}
+		return tx.Model(&user).Association("Districts").Replace(districts)
+	})
+}
+
+func (r *UserRepository) CreateToken(ctx context.Context, userId uint, purpose, tokenHash string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Create(&models.UserToken{
+		UserId:    userId,
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// ConsumeToken помечает токен использованным и возвращает владельца.
+// Просроченный, чужого назначения или уже использованный токен дает ErrTokenInvalid.
+func (r *UserRepository) ConsumeToken(ctx context.Context, purpose, tokenHash string) (uint, error) {
+	var userId uint
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var token models.UserToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, time.Now()).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTokenInvalid
+			}
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&token).Update("used_at", &now).Error; err != nil {
+			return err
+		}
+		// остальные неиспользованные токены того же назначения больше не нужны
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserId, purpose).
+			Update("used_at", &now).Error; err != nil {
+			return err
+		}
+
+		userId = token.UserId
+		return nil
+	})
+	return userId, err
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, userId uint, passwordHash string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).
+		Where("user_id = ?", userId).
+		Update("password_hash", passwordHash).Error
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userId uint) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).
+		Where("user_id = ? AND email_verified_at IS NULL", userId).
+		Update("email_verified_at", time.Now()).Error
+}
+
+// BindEmployee привязывает пользователя к сотруднику; nil снимает привязку
+func (r *UserRepository) BindEmployee(ctx context.Context, userId uint, employeeId *uint) error {
+	if employeeId != nil {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(&models.Employee{}).
+			Where("employee_id = ?", *employeeId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrEmployeeNotFound
+		}
+
+		if err := r.db.WithContext(ctx).Model(&models.User{}).
+			Where("employee_id = ? AND user_id <> ?", *employeeId, userId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrEmployeeBound
+		}
+	}
+
+	res := r.db.WithContext(ctx).Model(&models.User{}).Where("user_id = ?", userId).Update("employee_id", employeeId)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrWorkOrderNotFound = fmt.Errorf("work order not found")
+
+type WorkOrderRepo interface {
+	CreateWorkOrder(ctx context.Context, order *models.WorkOrder, defectIds []uint) error
+	GetWorkOrder(ctx context.Context, workOrderId uint) (*entities.WorkOrder, error)
+	LockWorkOrder(ctx context.Context, workOrderId uint) (*models.WorkOrder, error)
+	ListWorkOrders(ctx context.Context, filter entities.WorkOrderFilter) ([]entities.WorkOrder, int64, error)
+	UpdateWorkOrderFields(ctx context.Context, workOrderId uint, fields map[string]interface{}) error
+	SetEmployees(ctx context.Context, workOrderId uint, employeeIds []uint) error
+	AddDefects(ctx context.Context, workOrderId uint, defectIds []uint) error
+	ListDefectIds(ctx context.Context, workOrderId uint) ([]uint, error)
+	CountHiddenDefects(ctx context.Context, workOrderId uint) (int64, error)
+	ListEmployeeIds(ctx context.Context, workOrderId uint) ([]uint, error)
+	DefectsInActiveOrders(ctx context.Context, defectIds []uint) ([]uint, error)
+	CountEmployees(ctx context.Context, employeeIds []uint) (int64, error)
+	MethodExists(ctx context.Context, methodId uint) (bool, error)
+}
+
+type WorkOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkOrderRepository(db *gorm.DB) *WorkOrderRepository {
+	return &WorkOrderRepository{db: db}
+}
+
+// Transaction открывает транзакцию, общую для нарядов и дефектов: наряд и статусы его дефектов меняются атомарно
+func (r *WorkOrderRepository) Transaction(ctx context.Context, fn func(orders *WorkOrderRepository, defects *DefectRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&WorkOrderRepository{db: tx}, NewDefectRepo(tx))
+	})
+}
+
+// scopeWorkOrders — наряд виден, если виден хотя бы один из его дефектов
+func scopeWorkOrders(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		cond, args := scopeCondition(ctx, "o")
+		if cond == "" {
+			return db
+		}
+		return db.Where(`EXISTS (
+			SELECT 1 FROM work_order_defects wod
+			JOIN defects d ON d.defect_id = wod.defect_id
+			JOIN objects o ON o.object_id = d.object_id
+			WHERE wod.work_order_id = work_orders.work_order_id AND `+cond+`)`, args...)
+	}
+}
+
+// scopeOrderDefects — для Preload: из дефектов наряда подгружаются только видимые пользователю
+func scopeOrderDefects(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		cond, args := scopeCondition(ctx, "o")
+		if cond == "" {
+			return db
+		}
+		return db.Where("EXISTS (SELECT 1 FROM objects o WHERE o.object_id = defects.object_id AND "+cond+")", args...)
+	}
+}
+
+func (r *WorkOrderRepository) CreateWorkOrder(ctx context.Context, order *models.WorkOrder, defectIds []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return err
+		}
+		return insertLinks(tx, "work_order_defects", "work_order_id", order.WorkOrderId, "defect_id", defectIds)
+	})
+}
+
+// insertLinks заполняет join-таблицу many2many напрямую, не трогая связанные записи
+func insertLinks(tx *gorm.DB, table, ownerColumn string, ownerId uint, column string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	rows := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, map[string]interface{}{ownerColumn: ownerId, column: id})
+	}
+	return tx.Table(table).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (r *WorkOrderRepository) GetWorkOrder(ctx context.Context, workOrderId uint) (*entities.WorkOrder, error) {
+	var model models.WorkOrder
+	if err := r.db.WithContext(ctx).
+		Scopes(scopeWorkOrders(ctx)).
+		Preload("Method").Preload("Employees").
+		Preload("Defects", scopeOrderDefects(ctx)).Preload("Defects.Object").Preload("Defects.DefectType").Preload("Defects.QualityGrade").
+		First(&model, "work_orders.work_order_id = ?", workOrderId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkOrderNotFound
+		}
+		return nil, err
+	}
+
+	order := WorkOrderToEntity(model)
+	return &order, nil
+}
+
+// LockWorkOrder читает наряд с блокировкой строки — вызывать внутри Transaction
+func (r *WorkOrderRepository) LockWorkOrder(ctx context.Context, workOrderId uint) (*models.WorkOrder, error) {
+	var model models.WorkOrder
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(scopeWorkOrders(ctx)).
+		First(&model, "work_orders.work_order_id = ?", workOrderId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkOrderNotFound
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *WorkOrderRepository) ListWorkOrders(ctx context.Context, filter entities.WorkOrderFilter) ([]entities.WorkOrder, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.WorkOrder{}).Scopes(scopeWorkOrders(ctx))
+
+	if filter.Status != "" {
+		query = query.Where("work_orders.status = ?", filter.Status)
+	}
+	if filter.ActiveOnly {
+		query = query.Where("work_orders.status IN ?", entities.ActiveWorkOrderStatuses)
+	}
+	if filter.Priority != "" {
+		query = query.Where("work_orders.priority = ?", filter.Priority)
+	}
+	if filter.EmployeeId != 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM work_order_employees woe WHERE woe.work_order_id = work_orders.work_order_id AND woe.employee_id = ?)", filter.EmployeeId)
+	}
+	if filter.DefectId != 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM work_order_defects wd WHERE wd.work_order_id = work_orders.work_order_id AND wd.defect_id = ?)", filter.DefectId)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.WorkOrder
+	if err := query.
+		Preload("Method").Preload("Employees").Preload("Defects", scopeOrderDefects(ctx)).
+		Scopes(Paginate(filter.Page, filter.Limit)).
+		Order(`CASE work_orders.priority WHEN 'critical' THEN 4 WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END DESC`).
+		Order("work_orders.planned_start ASC NULLS LAST, work_orders.work_order_id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	orders := make([]entities.WorkOrder, 0, len(rows))
+	for _, m := range rows {
+		orders = append(orders, WorkOrderToEntity(m))
+	}
+	return orders, total, nil
+}
+
+func (r *WorkOrderRepository) UpdateWorkOrderFields(ctx context.Context, workOrderId uint, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.WorkOrder{}).Where("work_order_id = ?", workOrderId).Updates(fields).Error
+}
+
+func (r *WorkOrderRepository) SetEmployees(ctx context.Context, workOrderId uint, employeeIds []uint) error {
+	if err := r.db.WithContext(ctx).Exec("DELETE FROM work_order_employees WHERE work_order_id = ?", workOrderId).Error; err != nil {
+		return err
+	}
+	return insertLinks(r.db.WithContext(ctx), "work_order_employees", "work_order_id", workOrderId, "employee_id", employeeIds)
+}
+
+// AddDefects включает дефекты в существующий наряд; уже включенные пропускаются
+func (r *WorkOrderRepository) AddDefects(ctx context.Context, workOrderId uint, defectIds []uint) error {
+	return insertLinks(r.db.WithContext(ctx), "work_order_defects", "work_order_id", workOrderId, "defect_id", defectIds)
+}
+
+func (r *WorkOrderRepository) ListDefectIds(ctx context.Context, workOrderId uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Table("work_order_defects").
+		Where("work_order_id = ?", workOrderId).
+		Order("defect_id ASC").
+		Pluck("defect_id", &ids).Error
+	return ids, err
+}
+
+// CountHiddenDefects — сколько дефектов наряда не видно пользователю из ctx
+func (r *WorkOrderRepository) CountHiddenDefects(ctx context.Context, workOrderId uint) (int64, error) {
+	cond, args := scopeCondition(ctx, "o")
+	if cond == "" {
+		return 0, nil
+	}
+	var count int64
+	err := r.db.WithContext(ctx).Table("work_order_defects wod").
+		Joins("JOIN defects d ON d.defect_id = wod.defect_id").
+		Joins("JOIN objects o ON o.object_id = d.object_id").
+		Where("wod.work_order_id = ?", workOrderId).
+		Where("NOT "+cond, args...).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *WorkOrderRepository) ListEmployeeIds(ctx context.Context, workOrderId uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Table("work_order_employees").
+		Where("work_order_id = ?", workOrderId).
+		Order("employee_id ASC").
+		Pluck("employee_id", &ids).Error
+	return ids, err
+}
+
+// DefectsInActiveOrders возвращает те дефекты из списка, которые уже входят в незавершенный наряд
+func (r *WorkOrderRepository) DefectsInActiveOrders(ctx context.Context, defectIds []uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Table("work_order_defects wod").
+		Joins("JOIN work_orders wo ON wo.work_order_id = wod.work_order_id").
+		Where("wod.defect_id IN ? AND wo.status IN ?", defectIds, entities.ActiveWorkOrderStatuses).
+		Distinct().
+		Pluck("wod.defect_id", &ids).Error
+	return ids, err
+}
+
+func (r *WorkOrderRepository) CountEmployees(ctx context.Context, employeeIds []uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Employee{}).Where("employee_id IN ?", employeeIds).Count(&count).Error
+	return count, err
+}
+
+func (r *WorkOrderRepository) MethodExists(ctx context.Context, methodId uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Method{}).Where("method_id = ?", methodId).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+type AccessProvider interface {
+	EnsureDefaults(ctx context.Context) error
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+	Allowed(ctx context.Context, principal entities.Principal, permission string) (bool, error)
+	ListRoles(ctx context.Context) ([]entities.Role, error)
+	ListPermissions(ctx context.Context) ([]entities.PermissionInfo, error)
+	SetRolePermissions(ctx context.Context, role string, permissions []string) error
+	ListUsers(ctx context.Context, page, limit int) ([]entities.User, int64, error)
+	SetUserRole(ctx context.Context, userId uint, role string) error
+	ResolveScope(ctx context.Context, principal entities.Principal) (entities.AccessScope, error)
+	GetAssignments(ctx context.Context, userId uint) (*entities.UserAssignments, error)
+	SetAssignments(ctx context.Context, userId uint, assignments entities.UserAssignments) error
+	BindEmployee(ctx context.Context, userId uint, employeeId *uint) error
+	ListDistricts(ctx context.Context) ([]entities.District, error)
+	AddDistrict(ctx context.Context, district *entities.District) error
+}
+
+// AccessService держит матрицу прав в памяти и перечитывает ее из БД раз в cacheTTL,
+// чтобы проверка прав не ходила в БД на каждый запрос
+type AccessService struct {
+	repo      *repository.AccessRepository
+	users     *repository.UserRepository
+	districts *repository.DistrictRepository
+	audit     *AuditService
+	cacheTTL  time.Duration
+
+	mu       sync.RWMutex
+	matrix   map[string]map[string]bool
+	loadedAt time.Time
+}
+
+func NewAccessService(repo *repository.AccessRepository, users *repository.UserRepository, districts *repository.DistrictRepository, audit *AuditService, cacheTTL time.Duration) *AccessService {
+	return &AccessService{
+		repo:      repo,
+		users:     users,
+		districts: districts,
+		audit:     audit,
+		cacheTTL:  cacheTTL,
+	}
+}
+
+func (s *AccessService) EnsureDefaults(ctx context.Context) error {
+	if err := s.repo.SeedDefaults(ctx, entities.Permissions, entities.DefaultRoles); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *AccessService) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	s.mu.RLock()
+	fresh := s.matrix != nil && time.Since(s.loadedAt) < s.cacheTTL
+	if fresh {
+		ok := s.matrix[role][permission]
+		s.mu.RUnlock()
+		return ok, nil
+	}
+	s.mu.RUnlock()
+
+	if err := s.reload(ctx); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.matrix[role][permission], nil
+}
+
+// Allowed проверяет право у пользователя (через его роль) или у API-ключа (через список прав ключа)
+func (s *AccessService) Allowed(ctx context.Context, principal entities.Principal, permission string) (bool, error) {
+	if principal.ApiKeyId != 0 {
+		for _, p := range principal.Permissions {
+			if p == permission {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return s.HasPermission(ctx, principal.Role, permission)
+}
+
+func (s *AccessService) reload(ctx context.Context) error {
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	matrix := make(map[string]map[string]bool, len(roles))
+	for _, role := range roles {
+		perms := make(map[string]bool, len(role.Permissions))
+		for _, p := range role.Permissions {
+			perms[p] = true
+		}
+		matrix[role.Name] = perms
+	}
+
+	s.mu.Lock()
+	s.matrix = matrix
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *AccessService) invalidate() {
+	s.mu.Lock()
+	s.matrix = nil
+	s.mu.Unlock()
+}
+
+func (s *AccessService) ListRoles(ctx context.Context) ([]entities.Role, error) {
+	return s.repo.ListRoles(ctx)
+}
+
+func (s *AccessService) ListPermissions(ctx context.Context) ([]entities.PermissionInfo, error) {
+	return s.repo.ListPermissions(ctx)
+}
+
+func (s *AccessService) SetRolePermissions(ctx context.Context, role string, permissions []string) error {
+	var before []string
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range roles {
+		if r.Name == role {
+			before = r.Permissions
+		}
+	}
+
+	if err := s.repo.SetRolePermissions(ctx, role, permissions); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	s.audit.Record(ctx, entities.AuditRolePermissions, "role", role,
+		map[string]interface{}{"permissions": before}, map[string]interface{}{"permissions": permissions})
+	return nil
+}
+
+func (s *AccessService) ListUsers(ctx context.Context, page, limit int) ([]entities.User, int64, error) {
+	return s.users.ListUsers(ctx, page, limit)
+}
+
+func (s *AccessService) SetUserRole(ctx context.Context, userId uint, role string) error {
+	user, err := s.users.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if err := s.users.SetUserRole(ctx, userId, role); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, entities.AuditUserRole, "user", userId,
+		map[string]interface{}{"role": user.Role}, map[string]interface{}{"role": role})
+	return nil
+}
+
+// ResolveScope определяет, какие данные видит пользователь: все (право data:all)
+// или только назначенные ему трубопроводы и районы. API-ключ без списка трубопроводов видит все.
+func (s *AccessService) ResolveScope(ctx context.Context, principal entities.Principal) (entities.AccessScope, error) {
+	if principal.ApiKeyId != 0 {
+		if len(principal.PipelineIds) == 0 {
+			return entities.AccessScope{All: true}, nil
+		}
+		return entities.AccessScope{PipelineIds: principal.PipelineIds}, nil
+	}
+
+	all, err := s.HasPermission(ctx, principal.Role, entities.PermDataAll)
+	if err != nil {
+		return entities.AccessScope{}, err
+	}
+	if all {
+		return entities.AccessScope{All: true}, nil
+	}
+
+	assignments, err := s.users.GetAssignments(ctx, principal.UserId)
+	if err != nil {
+		return entities.AccessScope{}, err
+	}
+	// назначение на область открывает и ее районы
+	districtIds, err := s.districts.WithDescendants(ctx, assignments.DistrictIds)
+	if err != nil {
+		return entities.AccessScope{}, err
+	}
+	return entities.AccessScope{
+		PipelineIds: assignments.PipelineIds,
+		DistrictIds: districtIds,
+	}, nil
+}
+
+func (s *AccessService) GetAssignments(ctx context.Context, userId uint) (*entities.UserAssignments, error) {
+	return s.users.GetAssignments(ctx, userId)
+}
+
+func (s *AccessService) SetAssignments(ctx context.Context, userId uint, assignments entities.UserAssignments) error {
+	before, err := s.users.GetAssignments(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if err := s.users.SetAssignments(ctx, userId, assignments); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, entities.AuditUserAssignments, "user", userId, before, assignments)
+	return nil
+}
+
+// BindEmployee связывает учетную запись с сотрудником из справочника; nil отвязывает
+func (s *AccessService) BindEmployee(ctx context.Context, userId uint, employeeId *uint) error {
+	user, err := s.users.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if err := s.users.BindEmployee(ctx, userId, employeeId); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, entities.AuditUserEmployee, "user", userId,
+		map[string]interface{}{"employee_id": user.EmployeeId}, map[string]interface{}{"employee_id": employeeId})
+	return nil
+}
+
+func (s *AccessService) ListDistricts(ctx context.Context) ([]entities.District, error) {
+	return s.districts.ListDistricts(ctx)
+}
+
+func (s *AccessService) AddDistrict(ctx context.Context, district *entities.District) error {
+	if err := s.districts.AddDistrict(ctx, district); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, entities.AuditDistrictCreate, "district", district.DistrictId, nil, district)
+	return nil
+}
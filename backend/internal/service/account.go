@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/clients/mail"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+type AccountProvider interface {
+	SendVerification(ctx context.Context, userId uint) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+// AccountService — сброс пароля и подтверждение почты через одноразовые ссылки
+type AccountService struct {
+	repo      *repository.UserRepository
+	auth      *AuthService
+	mailer    mail.Mailer
+	audit     *AuditService
+	appURL    string
+	resetTTL  time.Duration
+	verifyTTL time.Duration
+}
+
+func NewAccountService(repo *repository.UserRepository, auth *AuthService, mailer mail.Mailer, audit *AuditService, appURL string) *AccountService {
+	return &AccountService{
+		repo:      repo,
+		auth:      auth,
+		mailer:    mailer,
+		audit:     audit,
+		appURL:    appURL,
+		resetTTL:  time.Hour,
+		verifyTTL: 72 * time.Hour,
+	}
+}
+
+func (s *AccountService) issueToken(ctx context.Context, userId uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.CreateToken(ctx, userId, purpose, hashSecret(token), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", s.appURL, path, url.QueryEscape(token))
+}
+
+func (s *AccountService) SendVerification(ctx context.Context, userId uint) error {
+	user, err := s.repo.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user.UserId, entities.TokenEmailVerify, s.verifyTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "IntegrityOS: подтверждение почты",
+		Body: fmt.Sprintf("Здравствуйте, %s!\r\n\r\nЧтобы подтвердить адрес, перейдите по ссылке:\r\n%s\r\n\r\nСсылка действует %s.\r\n",
+			user.Name, s.link("/verify", token), s.verifyTTL),
+	})
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	userId, err := s.repo.ConsumeToken(ctx, entities.TokenEmailVerify, hashSecret(token))
+	if err != nil {
+		return err
+	}
+	return s.repo.MarkEmailVerified(ctx, userId)
+}
+
+// ForgotPassword не сообщает, существует ли такой адрес, — чтобы по ответу нельзя было перебирать пользователей.
+// Поиск пользователя и отправка письма идут в фоне: ни ответ, ни время ответа не зависят от адреса.
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.sendPasswordReset(ctx, normalizeEmail(email)); err != nil {
+			log.Printf("account.ForgotPassword: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *AccountService) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			log.Printf("account.ForgotPassword: unknown email %q", email)
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.issueToken(ctx, user.UserId, entities.TokenPasswordReset, s.resetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "IntegrityOS: сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\r\n\r\nДля смены пароля перейдите по ссылке:\r\n%s\r\n\r\nСсылка действует %s. Если вы не запрашивали сброс, просто проигнорируйте письмо.\r\n",
+			user.Name, s.link("/reset-password", token), s.resetTTL),
+	})
+}
+
+// ResetPassword меняет пароль и завершает все сессии пользователя. Токен, пароль и отзыв сессий
+// идут в одной транзакции: если сессии отозвать не удалось, токен не сгорает и пароль не меняется.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	var userId uint
+	err = s.repo.Transaction(ctx, func(tx *repository.UserRepository) error {
+		id, err := tx.ConsumeToken(ctx, entities.TokenPasswordReset, hashSecret(token))
+		if err != nil {
+			return err
+		}
+		userId = id
+
+		if err := tx.UpdatePassword(ctx, userId, hash); err != nil {
+			return err
+		}
+		// письмо со ссылкой пришло на почту — значит, адрес подтвержден
+		if err := tx.MarkEmailVerified(ctx, userId); err != nil {
+			return err
+		}
+		return s.auth.RevokeUserSessions(ctx, userId)
+	})
+	if err != nil {
+		return err
+	}
+
+	// запрос анонимный — автором изменения считаем владельца ссылки
+	actor := entities.ActorFromContext(ctx)
+	actor.UserId = userId
+	ctx = entities.ContextWithActor(ctx, actor)
+	s.audit.Record(ctx, entities.AuditUserPasswordReset, "user", userId, nil, nil)
+	return nil
+}
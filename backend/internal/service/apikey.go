@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+)
+
+var (
+	ErrInvalidApiKey   = fmt.Errorf("invalid api key")
+	ErrApiKeyNameEmpty = fmt.Errorf("api key name is required")
+)
+
+// apiKeyTag — префикс всех ключей, по нему ключ легко найти в логах и конфигах
+const apiKeyTag = "ik_"
+
+// apiKeyTouchInterval — точность last_used_at: чаще ключ в БД не отмечается
+const apiKeyTouchInterval = time.Minute
+
+type ApiKeyProvider interface {
+	Create(ctx context.Context, req entities.ApiKey) (*entities.ApiKey, string, error)
+	List(ctx context.Context) ([]entities.ApiKey, error)
+	Rotate(ctx context.Context, apiKeyId uint) (*entities.ApiKey, string, error)
+	Revoke(ctx context.Context, apiKeyId uint) error
+	Authenticate(ctx context.Context, key string) (*entities.Principal, error)
+}
+
+// ApiKeyService выдает ключи машинным клиентам. Ключ показывается один раз при создании
+// или ротации, в БД хранится только его sha256.
+type ApiKeyService struct {
+	repo   *repository.ApiKeyRepository
+	access *repository.AccessRepository
+	audit  *AuditService
+}
+
+func NewApiKeyService(repo *repository.ApiKeyRepository, access *repository.AccessRepository, audit *AuditService) *ApiKeyService {
+	return &ApiKeyService{
+		repo:   repo,
+		access: access,
+		audit:  audit,
+	}
+}
+
+func (s *ApiKeyService) Create(ctx context.Context, req entities.ApiKey) (*entities.ApiKey, string, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, "", ErrApiKeyNameEmpty
+	}
+	if err := s.validatePermissions(ctx, req.Permissions); err != nil {
+		return nil, "", err
+	}
+	req.PipelineIds = uniqueIds(req.PipelineIds)
+	if err := s.validatePipelines(ctx, req.PipelineIds); err != nil {
+		return nil, "", err
+	}
+
+	key, prefix, err := newApiKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	model := models.ApiKey{
+		Name:        req.Name,
+		Prefix:      prefix,
+		KeyHash:     hashSecret(key),
+		Permissions: req.Permissions,
+		PipelineIds: req.PipelineIds,
+		CreatedBy:   req.CreatedBy,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := s.repo.CreateApiKey(ctx, &model); err != nil {
+		return nil, "", err
+	}
+
+	created := repository.ApiKeyToEntity(model)
+	s.audit.Record(ctx, entities.AuditApiKeyCreate, "api_key", created.ApiKeyId, nil, created)
+	return &created, key, nil
+}
+
+func (s *ApiKeyService) validatePermissions(ctx context.Context, permissions []string) error {
+	known, err := s.access.ListPermissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	codes := make(map[string]bool, len(known))
+	for _, p := range known {
+		codes[p.Code] = true
+	}
+	for _, p := range permissions {
+		if !codes[p] {
+			return fmt.Errorf("%w: %s", repository.ErrUnknownPermission, p)
+		}
+	}
+	return nil
+}
+
+func (s *ApiKeyService) validatePipelines(ctx context.Context, pipelineIds []uint) error {
+	if len(pipelineIds) == 0 {
+		return nil
+	}
+	existing, err := s.access.ExistingPipelineIds(ctx, pipelineIds)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+	for _, id := range pipelineIds {
+		if !found[id] {
+			return fmt.Errorf("%w: %d", repository.ErrUnknownPipeline, id)
+		}
+	}
+	return nil
+}
+
+func (s *ApiKeyService) List(ctx context.Context) ([]entities.ApiKey, error) {
+	return s.repo.ListApiKeys(ctx)
+}
+
+// Rotate выдает новый секрет для ключа, сохраняя его права и область; старый секрет сразу перестает работать
+func (s *ApiKeyService) Rotate(ctx context.Context, apiKeyId uint) (*entities.ApiKey, string, error) {
+	key, prefix, err := newApiKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	rotated, err := s.repo.RotateApiKey(ctx, apiKeyId, prefix, hashSecret(key))
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.audit.Record(ctx, entities.AuditApiKeyRotate, "api_key", apiKeyId, nil, map[string]interface{}{"prefix": prefix})
+	return rotated, key, nil
+}
+
+func (s *ApiKeyService) Revoke(ctx context.Context, apiKeyId uint) error {
+	if err := s.repo.RevokeApiKey(ctx, apiKeyId); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, entities.AuditApiKeyRevoke, "api_key", apiKeyId, nil, nil)
+	return nil
+}
+
+func (s *ApiKeyService) Authenticate(ctx context.Context, key string) (*entities.Principal, error) {
+	if !strings.HasPrefix(key, apiKeyTag) {
+		return nil, ErrInvalidApiKey
+	}
+
+	apiKey, err := s.repo.GetActiveApiKey(ctx, hashSecret(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrApiKeyNotFound) {
+			return nil, ErrInvalidApiKey
+		}
+		return nil, err
+	}
+
+	// ключ машинного клиента приходит на каждый запрос — не пишем в БД, если он отмечен недавно
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.repo.TouchApiKey(ctx, apiKey.ApiKeyId); err != nil {
+			log.Printf("apikey: failed to update last_used_at for key %d: %v", apiKey.ApiKeyId, err)
+		}
+	}
+
+	principal := &entities.Principal{
+		ApiKeyId:    apiKey.ApiKeyId,
+		Permissions: apiKey.Permissions,
+		PipelineIds: apiKey.PipelineIds,
+	}
+	if apiKey.ExpiresAt != nil {
+		principal.ExpiresAt = *apiKey.ExpiresAt
+	}
+	return principal, nil
+}
+
+// newApiKey возвращает ключ вида ik_<prefix>_<secret> и его видимый префикс
+func newApiKey() (string, string, error) {
+	secret, err := randomSecret()
+	if err != nil {
+		return "", "", err
+	}
+	prefix := secret[:8]
+	return apiKeyTag + prefix + "_" + secret[8:], prefix, nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/pkg/assessment"
+)
+
+type AssessmentProvider interface {
+	Assess(ctx context.Context, defectId uint, method string) (*entities.DefectAssessment, error)
+	SetPipeProperties(ctx context.Context, objectId uint, pipe entities.PipeProperties) (*entities.Object, error)
+}
+
+// AssessmentService считает остаточную прочность трубы с дефектом по параметрам трубы объекта
+type AssessmentService struct {
+	defects *repository.DefectRepository
+	objects *repository.ObjectRepository
+	audit   *AuditService
+}
+
+func NewAssessmentService(defects *repository.DefectRepository, objects *repository.ObjectRepository, audit *AuditService) *AssessmentService {
+	return &AssessmentService{
+		defects: defects,
+		objects: objects,
+		audit:   audit,
+	}
+}
+
+// validatePipe проверяет, что у объекта заданы все параметры трубы, нужные для расчета
+func validatePipe(pipe entities.PipeProperties) error {
+	verr := &entities.ValidationError{}
+	positive := func(field string, v float64) {
+		if v <= 0 {
+			verr.Add(field, "must be set and positive")
+		}
+	}
+	positive("outer_diameter", pipe.OuterDiameter)
+	positive("wall_thickness", pipe.WallThickness)
+	positive("smys", pipe.Smys)
+	positive("maop", pipe.Maop)
+	if pipe.OuterDiameter > 0 && pipe.WallThickness >= pipe.OuterDiameter/2 {
+		verr.Add("wall_thickness", "must be less than the pipe radius")
+	}
+	return verr.Err()
+}
+
+func (s *AssessmentService) Assess(ctx context.Context, defectId uint, method string) (*entities.DefectAssessment, error) {
+	if method == "" {
+		method = assessment.MethodModifiedB31G
+	}
+	if !contains(assessment.Methods, method) {
+		verr := &entities.ValidationError{}
+		verr.Addf("method", "must be one of %s", strings.Join(assessment.Methods, ", "))
+		return nil, verr.Err()
+	}
+
+	defect, err := s.defects.GetDefect(ctx, defectId)
+	if err != nil {
+		return nil, err
+	}
+	if !entities.IsMetalLoss(defect.DefectType) {
+		verr := &entities.ValidationError{}
+		verr.Add("defect_type", "assessment applies to metal loss defects only")
+		return nil, verr.Err()
+	}
+	object, err := s.objects.GetObject(ctx, defect.ObjectId)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePipe(object.Pipe); err != nil {
+		return nil, err
+	}
+	return evaluate(method, *defect, object.Pipe)
+}
+
+// evaluate считает дефект по уже проверенным параметрам трубы
+func evaluate(method string, defect entities.Defect, pipe entities.PipeProperties) (*entities.DefectAssessment, error) {
+	res, err := assessment.Assess(method, assessment.Pipe{
+		Diameter:      pipe.OuterDiameter,
+		WallThickness: pipe.WallThickness,
+		SMYS:          pipe.Smys,
+		MAOP:          pipe.Maop,
+	}, assessment.Defect{Depth: defect.Depth, Length: defect.Length})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.DefectAssessment{
+		DefectId:              defect.DefectId,
+		ObjectId:              defect.ObjectId,
+		Method:                res.Method,
+		Depth:                 defect.Depth,
+		Length:                defect.Length,
+		Pipe:                  pipe,
+		DepthRatio:            res.DepthRatio,
+		FlowStress:            res.FlowStress,
+		FoliasFactor:          res.FoliasFactor,
+		FailurePressure:       res.FailurePressure,
+		SafePressure:          res.SafePressure,
+		EstimatedRepairFactor: res.EstimatedRepairFactor,
+		Verdict:               res.Verdict,
+	}, nil
+}
+
+func (s *AssessmentService) SetPipeProperties(ctx context.Context, objectId uint, pipe entities.PipeProperties) (*entities.Object, error) {
+	if err := validatePipe(pipe); err != nil {
+		return nil, err
+	}
+
+	before, err := s.objects.GetObject(ctx, objectId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.objects.SetPipeProperties(ctx, objectId, pipe); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, entities.AuditObjectPipe, "object", objectId, before.Pipe, pipe)
+	before.Pipe = pipe
+	return before, nil
+}
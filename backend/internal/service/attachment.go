@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"github.com/rwrrioe/integrity/backend/internal/storage/blob"
+)
+
+var (
+	ErrAttachmentEmpty     = fmt.Errorf("file is empty")
+	ErrAttachmentTooLarge  = fmt.Errorf("file is too large")
+	ErrUnsupportedFileType = fmt.Errorf("unsupported file type")
+	ErrNoThumbnail         = fmt.Errorf("attachment has no thumbnail")
+)
+
+// allowedContentTypes — фото, сканы УЗК/радиографии и PDF-отчеты.
+// TIFF и DICOM http.DetectContentType не распознает, для них доверяем типу, заявленному клиентом.
+var allowedContentTypes = map[string]bool{
+	"image/jpeg":        true,
+	"image/png":         true,
+	"image/gif":         true,
+	"image/bmp":         true,
+	"image/webp":        true,
+	"image/tiff":        true,
+	"application/pdf":   true,
+	"application/dicom": true,
+}
+
+type AttachmentProvider interface {
+	Upload(ctx context.Context, ownerType string, ownerId uint, fileName, declaredType string, data io.Reader) (*entities.Attachment, error)
+	List(ctx context.Context, ownerType string, ownerId uint) ([]entities.Attachment, error)
+	Get(ctx context.Context, attachmentId uint) (*entities.Attachment, error)
+	Open(ctx context.Context, attachmentId uint, thumbnail bool) (*entities.Attachment, io.ReadCloser, error)
+	Delete(ctx context.Context, attachmentId uint) error
+}
+
+type AttachmentService struct {
+	repo    *repository.AttachmentRepository
+	store   blob.BlobStore
+	audit   *AuditService
+	maxSize int64
+}
+
+func NewAttachmentService(repo *repository.AttachmentRepository, store blob.BlobStore, audit *AuditService, maxSize int64) *AttachmentService {
+	return &AttachmentService{
+		repo:    repo,
+		store:   store,
+		audit:   audit,
+		maxSize: maxSize,
+	}
+}
+
+// MaxSize — предельный размер одного файла в байтах
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+func (s *AttachmentService) Upload(ctx context.Context, ownerType string, ownerId uint, fileName, declaredType string, data io.Reader) (*entities.Attachment, error) {
+	if err := s.repo.OwnerExists(ctx, ownerType, ownerId); err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(data, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, ErrAttachmentEmpty
+	}
+	if int64(len(content)) > s.maxSize {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrAttachmentTooLarge, s.maxSize)
+	}
+
+	contentType, err := detectContentType(content, declaredType)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	model := models.Attachment{
+		OwnerType:   ownerType,
+		OwnerId:     ownerId,
+		FileName:    cleanFileName(fileName),
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Checksum:    hex.EncodeToString(sum[:]),
+		StorageKey:  fmt.Sprintf("%s/%d/%s%s", ownerType, ownerId, uuid.NewString(), storageExt(fileName)),
+	}
+	if actor := entities.ActorFromContext(ctx); actor.UserId != 0 {
+		model.UploadedBy = &actor.UserId
+	} else if actor.ApiKeyId != 0 {
+		model.UploadedByApiKey = &actor.ApiKeyId
+	}
+
+	if err := s.store.Put(ctx, model.StorageKey, bytes.NewReader(content), model.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	// без миниатюры вложение остается рабочим, поэтому ошибка только логируется
+	if strings.HasPrefix(contentType, "image/") {
+		thumb, err := makeThumbnail(content)
+		if err != nil {
+			log.Printf("attachment: thumbnail for %s: %v", model.StorageKey, err)
+		} else {
+			key := model.StorageKey + ".thumb.jpg"
+			if err := s.store.Put(ctx, key, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+				log.Printf("attachment: store thumbnail %s: %v", key, err)
+			} else {
+				model.ThumbnailKey = key
+			}
+		}
+	}
+
+	if err := s.repo.CreateAttachment(ctx, &model); err != nil {
+		s.removeBlobs(ctx, model)
+		return nil, err
+	}
+
+	attachment := repository.AttachmentToEntity(model)
+	s.audit.Record(ctx, entities.AuditAttachmentUpload, "attachment", attachment.AttachmentId, nil, attachment)
+	return &attachment, nil
+}
+
+func (s *AttachmentService) List(ctx context.Context, ownerType string, ownerId uint) ([]entities.Attachment, error) {
+	if err := s.repo.OwnerExists(ctx, ownerType, ownerId); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAttachments(ctx, ownerType, ownerId)
+}
+
+// load читает вложение и проверяет, что его владелец виден пользователю; чужое вложение выглядит как несуществующее
+func (s *AttachmentService) load(ctx context.Context, attachmentId uint) (*models.Attachment, error) {
+	model, err := s.repo.GetAttachment(ctx, attachmentId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.OwnerExists(ctx, model.OwnerType, model.OwnerId); err != nil {
+		if errors.Is(err, repository.ErrAttachmentOwnerNotFound) {
+			return nil, repository.ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return model, nil
+}
+
+func (s *AttachmentService) Get(ctx context.Context, attachmentId uint) (*entities.Attachment, error) {
+	model, err := s.load(ctx, attachmentId)
+	if err != nil {
+		return nil, err
+	}
+	attachment := repository.AttachmentToEntity(*model)
+	return &attachment, nil
+}
+
+// Open отдает содержимое файла (или его миниатюры); закрыть reader должен вызывающий
+func (s *AttachmentService) Open(ctx context.Context, attachmentId uint, thumbnail bool) (*entities.Attachment, io.ReadCloser, error) {
+	model, err := s.load(ctx, attachmentId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := model.StorageKey
+	if thumbnail {
+		if model.ThumbnailKey == "" {
+			return nil, nil, ErrNoThumbnail
+		}
+		key = model.ThumbnailKey
+	}
+
+	r, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	attachment := repository.AttachmentToEntity(*model)
+	return &attachment, r, nil
+}
+
+func (s *AttachmentService) Delete(ctx context.Context, attachmentId uint) error {
+	model, err := s.load(ctx, attachmentId)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteAttachment(ctx, attachmentId); err != nil {
+		return err
+	}
+	s.removeBlobs(ctx, *model)
+
+	s.audit.Record(ctx, entities.AuditAttachmentDelete, "attachment", attachmentId, repository.AttachmentToEntity(*model), nil)
+	return nil
+}
+
+func (s *AttachmentService) removeBlobs(ctx context.Context, model models.Attachment) {
+	for _, key := range []string{model.StorageKey, model.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("attachment: delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// detectContentType определяет тип по содержимому, а не по заголовку клиента
+func detectContentType(content []byte, declared string) (string, error) {
+	detected := strings.TrimSpace(strings.SplitN(http.DetectContentType(content), ";", 2)[0])
+	if allowedContentTypes[detected] {
+		return detected, nil
+	}
+
+	declared = strings.ToLower(strings.TrimSpace(strings.SplitN(declared, ";", 2)[0]))
+	if detected == "application/octet-stream" && (declared == "image/tiff" || declared == "application/dicom") {
+		return declared, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedFileType, detected)
+}
+
+// storageExt — расширение для ключа в хранилище. Ключ не экранируется при подписи запроса к S3,
+// поэтому расширение с символами вне [a-z0-9.] отбрасывается: тип файла и так хранится в content_type.
+func storageExt(fileName string) string {
+	ext := strings.ToLower(filepath.Ext(cleanFileName(fileName)))
+	for _, r := range ext {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.') {
+			return ""
+		}
+	}
+	return ext
+}
+
+func cleanFileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
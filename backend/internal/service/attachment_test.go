@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+func TestStorageExt(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"photo.JPG", ".jpg"},
+		{"report.v2.pdf", ".pdf"},
+		{"scan.tar.gz", ".gz"},
+		{"no extension", ""},
+		{"отчет.пдф", ""},
+		{"a.p%2Fdf", ""},
+		{"a.pdf?x=1", ""},
+		{"a.p df", ""},
+		{`C:\docs\plan.Mp4`, ".mp4"},
+	}
+
+	for _, tt := range tests {
+		if got := storageExt(tt.name); got != tt.want {
+			t.Errorf("storageExt(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
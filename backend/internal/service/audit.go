@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+)
+
+type AuditProvider interface {
+	Record(ctx context.Context, action, entityType string, entityId interface{}, before, after interface{})
+	List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, int64, error)
+}
+
+// AuditService пишет журнал аудита. Актор и IP берутся из контекста запроса;
+// before/after сохраняются как разница — только изменившиеся поля.
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record не возвращает ошибку: изменение уже выполнено, и сбой записи аудита не должен его откатывать
+func (s *AuditService) Record(ctx context.Context, action, entityType string, entityId interface{}, before, after interface{}) {
+	beforeMap, afterMap := diffFields(toFields(before), toFields(after))
+	actor := entities.ActorFromContext(ctx)
+
+	entry := models.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityId:   fmt.Sprint(entityId),
+		Before:     beforeMap,
+		After:      afterMap,
+		Ip:         actor.Ip,
+	}
+	if actor.UserId != 0 {
+		entry.ActorUserId = &actor.UserId
+	}
+	if actor.ApiKeyId != 0 {
+		entry.ActorApiKeyId = &actor.ApiKeyId
+	}
+
+	// запись не должна зависеть от отмены контекста запроса
+	if err := s.repo.AddEntry(context.WithoutCancel(ctx), &entry); err != nil {
+		log.Printf("audit: failed to record %s %s/%s: %v", action, entityType, entry.EntityId, err)
+	}
+}
+
+func (s *AuditService) List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, int64, error) {
+	return s.repo.ListEntries(ctx, filter)
+}
+
+// toFields приводит произвольное значение к map через JSON
+func toFields(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return map[string]interface{}{"value": json.RawMessage(raw)}
+	}
+	return fields
+}
+
+// diffFields оставляет только поля, которые отличаются в before и after
+func diffFields(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	if before == nil || after == nil {
+		return before, after
+	}
+
+	b := make(map[string]interface{})
+	a := make(map[string]interface{})
+	for k, v := range before {
+		if nv, ok := after[k]; !ok || !reflect.DeepEqual(v, nv) {
+			b[k] = v
+		}
+	}
+	for k, v := range after {
+		if ov, ok := before[k]; !ok || !reflect.DeepEqual(ov, v) {
+			a[k] = v
+		}
+	}
+	return b, a
+}
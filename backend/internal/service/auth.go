@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"github.com/rwrrioe/integrity/backend/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials = fmt.Errorf("invalid email or password")
+	ErrInvalidToken       = fmt.Errorf("invalid token")
+	ErrSessionRevoked     = fmt.Errorf("session revoked")
+	ErrWeakPassword       = fmt.Errorf("password must be at least 8 characters long")
+	ErrInvalidEmail       = fmt.Errorf("invalid email")
+)
+
+type AuthProvider interface {
+	Register(ctx context.Context, email, password, name string) (*entities.User, error)
+	Login(ctx context.Context, email, password string) (*entities.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*entities.TokenPair, error)
+	Authenticate(ctx context.Context, token string) (*entities.Principal, error)
+	Logout(ctx context.Context, principal entities.Principal) error
+	RevokeUserSessions(ctx context.Context, userId uint) error
+}
+
+// AuthService выдает короткоживущие access-токены (JWT) и долгоживущие refresh-токены.
+// Сессии хранятся в Redis: удаление сессии сразу делает недействительными оба токена.
+type AuthService struct {
+	repo       *repository.UserRepository
+	redis      *storage.RedisStorage
+	audit      *AuditService
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewAuthService(repo *repository.UserRepository, redis *storage.RedisStorage, audit *AuditService, secret []byte, accessTTL, refreshTTL time.Duration) *AuthService {
+	return &AuthService{
+		repo:       repo,
+		redis:      redis,
+		audit:      audit,
+		secret:     secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func sessionKey(sessionId string) string {
+	return fmt.Sprintf("auth:session:%s", sessionId)
+}
+
+func userSessionsKey(userId uint) string {
+	return fmt.Sprintf("auth:user_sessions:%d", userId)
+}
+
+type tokenClaims struct {
+	UserId uint   `json:"uid"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	jwt.StandardClaims
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *AuthService) Register(ctx context.Context, email, password, name string) (*entities.User, error) {
+	user, err := s.createUser(ctx, email, password, name, entities.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := entities.ActorFromContext(ctx)
+	actor.UserId = user.UserId
+	s.audit.Record(entities.ContextWithActor(ctx, actor), entities.AuditUserRegister, "user", user.UserId, nil, user)
+	return user, nil
+}
+
+// EnsureAdmin создает администратора при первом запуске, если его еще нет
+func (s *AuthService) EnsureAdmin(ctx context.Context, email, password string) error {
+	_, err := s.repo.GetUserByEmail(ctx, normalizeEmail(email))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return err
+	}
+
+	_, err = s.createUser(ctx, email, password, "Administrator", entities.RoleAdmin)
+	return err
+}
+
+func (s *AuthService) createUser(ctx context.Context, email, password, name, role string) (*entities.User, error) {
+	email = normalizeEmail(email)
+	if !strings.Contains(email, "@") {
+		return nil, ErrInvalidEmail
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	model := models.User{
+		Email:        email,
+		PasswordHash: hash,
+		Name:         name,
+		Role:         role,
+	}
+	if err := s.repo.CreateUser(ctx, &model); err != nil {
+		return nil, err
+	}
+
+	user := repository.UserToEntity(model)
+	return &user, nil
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", ErrWeakPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (s *AuthService) Login(ctx context.Context, email, password string) (*entities.TokenPair, error) {
+	user, err := s.repo.GetUserByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	session := entities.Session{
+		SessionId: uuid.NewString(),
+		UserId:    user.UserId,
+		CreatedAt: time.Now(),
+	}
+	return s.issueTokens(ctx, session, repository.UserToEntity(*user))
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов. Старый refresh-токен при этом сгорает;
+// повторное предъявление уже использованного токена считается кражей и отзывает всю сессию.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*entities.TokenPair, error) {
+	sessionId, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := s.redis.Get(ctx, sessionKey(sessionId))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrSessionRevoked
+		}
+		return nil, err
+	}
+	var session entities.Session
+	if err := json.Unmarshal([]byte(raw), &session); err != nil {
+		return nil, err
+	}
+
+	if session.RefreshHash != hashSecret(secret) {
+		s.revokeSession(ctx, session.UserId, session.SessionId)
+		return nil, ErrSessionRevoked
+	}
+
+	user, err := s.repo.GetUser(ctx, session.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return s.rotateTokens(ctx, session, *user, raw)
+}
+
+func (s *AuthService) issueTokens(ctx context.Context, session entities.Session, user entities.User) (*entities.TokenPair, error) {
+	return s.rotateTokens(ctx, session, user, "")
+}
+
+// rotateTokens выдает новую пару токенов сессии. Если prev не пуст, сессия перезаписывается, только пока
+// в Redis лежит именно prev: из двух одновременных Refresh с одним токеном пройдет один, второй — повторное
+// предъявление, и сессия отзывается.
+func (s *AuthService) rotateTokens(ctx context.Context, session entities.Session, user entities.User, prev string) (*entities.TokenPair, error) {
+	secret, err := randomSecret()
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshHash = hashSecret(secret)
+	session.ExpiresAt = time.Now().Add(s.refreshTTL)
+
+	if prev == "" {
+		if err := s.redis.SetWithTTL(ctx, sessionKey(session.SessionId), session, s.refreshTTL); err != nil {
+			return nil, err
+		}
+	} else {
+		swapped, err := s.redis.CompareAndSwap(ctx, sessionKey(session.SessionId), prev, session, s.refreshTTL)
+		if err != nil {
+			return nil, err
+		}
+		if !swapped {
+			s.revokeSession(ctx, session.UserId, session.SessionId)
+			return nil, ErrSessionRevoked
+		}
+	}
+	if err := s.redis.AddToSet(ctx, userSessionsKey(user.UserId), session.SessionId, s.refreshTTL); err != nil {
+		return nil, err
+	}
+
+	principal := &entities.Principal{
+		UserId:    user.UserId,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionId: session.SessionId,
+		ExpiresAt: time.Now().Add(s.accessTTL),
+	}
+	token, err := s.signToken(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.TokenPair{
+		AccessToken:  token,
+		RefreshToken: session.SessionId + "." + secret,
+		ExpiresAt:    principal.ExpiresAt,
+		Role:         user.Role,
+	}, nil
+}
+
+// Authenticate проверяет access-токен и то, что его сессия не отозвана
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*entities.Principal, error) {
+	principal, err := s.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	alive, err := s.redis.Exists(ctx, sessionKey(principal.SessionId))
+	if err != nil {
+		return nil, err
+	}
+	if !alive {
+		return nil, ErrSessionRevoked
+	}
+	return principal, nil
+}
+
+func (s *AuthService) Logout(ctx context.Context, principal entities.Principal) error {
+	return s.revokeSession(ctx, principal.UserId, principal.SessionId)
+}
+
+// RevokeUserSessions — "выйти на всех устройствах"
+func (s *AuthService) RevokeUserSessions(ctx context.Context, userId uint) error {
+	sessions, err := s.redis.SetMembers(ctx, userSessionsKey(userId))
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(sessions)+1)
+	for _, id := range sessions {
+		keys = append(keys, sessionKey(id))
+	}
+	keys = append(keys, userSessionsKey(userId))
+	if err := s.redis.Delete(ctx, keys...); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, entities.AuditUserSessionsKill, "user", userId, nil, map[string]interface{}{"sessions": len(sessions)})
+	return nil
+}
+
+func (s *AuthService) revokeSession(ctx context.Context, userId uint, sessionId string) error {
+	if err := s.redis.Delete(ctx, sessionKey(sessionId)); err != nil {
+		return err
+	}
+	return s.redis.RemoveFromSet(ctx, userSessionsKey(userId), sessionId)
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *AuthService) signToken(p *entities.Principal) (string, error) {
+	claims := tokenClaims{
+		UserId: p.UserId,
+		Email:  p.Email,
+		Role:   p.Role,
+		StandardClaims: jwt.StandardClaims{
+			Id:        p.SessionId,
+			Subject:   fmt.Sprint(p.UserId),
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: p.ExpiresAt.Unix(),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+// ParseToken проверяет подпись и срок действия токена
+func (s *AuthService) ParseToken(token string) (*entities.Principal, error) {
+	var claims tokenClaims
+
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return s.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return &entities.Principal{
+		UserId:    claims.UserId,
+		Email:     claims.Email,
+		Role:      claims.Role,
+		SessionId: claims.Id,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
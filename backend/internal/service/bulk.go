@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+// errBulkItem — дефект не подходит для операции; в отчет попадает как ошибка позиции
+var errBulkItem = errors.New("not applicable")
+
+// errBulkRollback откатывает транзакцию при dry-run или отказе хотя бы по одной позиции
+var errBulkRollback = errors.New("bulk rollback")
+
+type BulkProvider interface {
+	Apply(ctx context.Context, req entities.BulkDefectRequest) (*entities.BulkResult, error)
+}
+
+// BulkService — массовые операции над дефектами. Каждая позиция проходит те же проверки,
+// что и одиночное изменение; все позиции применяются в одной транзакции.
+type BulkService struct {
+	orders  *repository.WorkOrderRepository
+	defects *repository.DefectRepository
+	audit   *AuditService
+}
+
+func NewBulkService(orders *repository.WorkOrderRepository, defects *repository.DefectRepository, audit *AuditService) *BulkService {
+	return &BulkService{
+		orders:  orders,
+		defects: defects,
+		audit:   audit,
+	}
+}
+
+// bulkStep — изменение одного дефекта внутри общей транзакции; возвращает статус до и после
+type bulkStep func(ctx context.Context, orders *repository.WorkOrderRepository, defects *repository.DefectRepository, defectId uint) (string, string, error)
+
+func (s *BulkService) Apply(ctx context.Context, req entities.BulkDefectRequest) (*entities.BulkResult, error) {
+	if err := s.validate(ctx, &req); err != nil {
+		return nil, err
+	}
+	ids, err := s.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entities.BulkResult{
+		Operation: req.Operation,
+		DryRun:    req.DryRun,
+		Total:     len(ids),
+		Items:     make([]entities.BulkItemResult, 0, len(ids)),
+	}
+
+	err = s.orders.Transaction(ctx, func(orders *repository.WorkOrderRepository, defects *repository.DefectRepository) error {
+		step, err := s.prepare(ctx, orders, req)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			item := entities.BulkItemResult{DefectId: id}
+			item.FromStatus, item.ToStatus, err = step(ctx, orders, defects, id)
+			switch {
+			case err == nil:
+				item.Ok = true
+				result.Succeeded++
+			case isBulkItemError(err):
+				item.Error = err.Error()
+				result.Failed++
+			default:
+				return fmt.Errorf("defect %d: %w", id, err)
+			}
+			result.Items = append(result.Items, item)
+		}
+
+		if req.DryRun || result.Failed > 0 {
+			return errBulkRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkRollback) {
+		return nil, err
+	}
+
+	result.Applied = err == nil
+	if result.Applied {
+		s.record(ctx, req, result)
+	}
+	return result, nil
+}
+
+func (s *BulkService) validate(ctx context.Context, req *entities.BulkDefectRequest) error {
+	verr := &entities.ValidationError{}
+	req.DefectIds = uniqueIds(req.DefectIds)
+
+	if !contains(entities.BulkOperations, req.Operation) {
+		verr.Addf("operation", "must be one of %s", strings.Join(entities.BulkOperations, ", "))
+	}
+
+	switch {
+	case len(req.DefectIds) > 0 && req.Filter != nil:
+		verr.Add("defect_ids", "pass either defect_ids or filter, not both")
+	case len(req.DefectIds) > entities.BulkMaxItems:
+		verr.Addf("defect_ids", "at most %d defects per request", entities.BulkMaxItems)
+	case req.Filter != nil:
+		// пустой фильтр выбрал бы все дефекты — такое надо просить явно списком
+		if req.Filter.Empty() {
+			verr.Add("filter", "at least one condition is required")
+		} else if err := validateDefectFilter(*req.Filter); err != nil {
+			return err
+		}
+	case len(req.DefectIds) == 0:
+		verr.Add("defect_ids", "defect_ids or filter is required")
+	}
+
+	switch req.Operation {
+	case entities.BulkTransition:
+		if req.Transition == nil {
+			verr.Add("transition", "required")
+			break
+		}
+		if _, ok := entities.DefectTransitions[req.Transition.To]; !ok {
+			verr.Addf("transition.to", "unknown status %q", req.Transition.To)
+		} else if err := validateTransition(*req.Transition); err != nil {
+			return err
+		}
+	case entities.BulkAssign:
+		req.EmployeeIds = uniqueIds(req.EmployeeIds)
+		if len(req.EmployeeIds) == 0 {
+			verr.Add("employee_ids", "at least one employee is required")
+			break
+		}
+		count, err := s.orders.CountEmployees(ctx, req.EmployeeIds)
+		if err != nil {
+			return err
+		}
+		if count != int64(len(req.EmployeeIds)) {
+			verr.Add("employee_ids", "unknown employee")
+		}
+	case entities.BulkSetGrade:
+		ok, err := s.defects.QualityGradeExists(ctx, req.QualityGradeId)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			verr.Add("quality_grade_id", "quality grade not found")
+		}
+	case entities.BulkAttachWorkOrder:
+		if req.WorkOrderId == 0 {
+			verr.Add("work_order_id", "required")
+		}
+	}
+	return verr.Err()
+}
+
+// resolve превращает фильтр в список id; больше BulkMaxItems дефектов за раз не меняем
+func (s *BulkService) resolve(ctx context.Context, req entities.BulkDefectRequest) ([]uint, error) {
+	if req.Filter == nil {
+		return req.DefectIds, nil
+	}
+	ids, total, err := s.defects.ListIds(ctx, *req.Filter, entities.BulkMaxItems)
+	if err != nil {
+		return nil, err
+	}
+	verr := &entities.ValidationError{}
+	if total > entities.BulkMaxItems {
+		verr.Addf("filter", "matches %d defects, at most %d per request", total, entities.BulkMaxItems)
+	}
+	if total == 0 {
+		verr.Add("filter", "no defects match the filter")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// prepare проверяет общее для всех позиций (наряд) и возвращает шаг для одного дефекта
+func (s *BulkService) prepare(ctx context.Context, orders *repository.WorkOrderRepository, req entities.BulkDefectRequest) (bulkStep, error) {
+	switch req.Operation {
+	case entities.BulkTransition:
+		return func(ctx context.Context, _ *repository.WorkOrderRepository, defects *repository.DefectRepository, id uint) (string, string, error) {
+			change, err := applyTransition(ctx, defects, id, *req.Transition)
+			if err != nil {
+				return "", "", err
+			}
+			return change.FromStatus, change.ToStatus, nil
+		}, nil
+
+	case entities.BulkAssign:
+		return func(ctx context.Context, _ *repository.WorkOrderRepository, defects *repository.DefectRepository, id uint) (string, string, error) {
+			defect, err := defects.LockDefect(ctx, id)
+			if err != nil {
+				return "", "", err
+			}
+			if !contains(entities.OpenDefectStatuses, defect.Status) {
+				return defect.Status, "", fmt.Errorf("%w: defect is %s", errBulkItem, defect.Status)
+			}
+			return defect.Status, defect.Status, defects.AssignEmployees(ctx, id, req.EmployeeIds)
+		}, nil
+
+	case entities.BulkSetGrade:
+		return func(ctx context.Context, _ *repository.WorkOrderRepository, defects *repository.DefectRepository, id uint) (string, string, error) {
+			defect, err := defects.LockDefect(ctx, id)
+			if err != nil {
+				return "", "", err
+			}
+			return defect.Status, defect.Status, defects.UpdateDefectFields(ctx, id, map[string]interface{}{"quality_grade_id": req.QualityGradeId})
+		}, nil
+
+	case entities.BulkAttachWorkOrder:
+		return s.prepareAttach(ctx, orders, req.WorkOrderId)
+	}
+	return nil, fmt.Errorf("unknown bulk operation %q", req.Operation)
+}
+
+// prepareAttach — правила те же, что при создании наряда: только разобранные дефекты вне других активных нарядов.
+// Если исполнители уже назначены, дефект сразу планируется, как при назначении наряда.
+func (s *BulkService) prepareAttach(ctx context.Context, orders *repository.WorkOrderRepository, workOrderId uint) (bulkStep, error) {
+	order, err := orders.LockWorkOrder(ctx, workOrderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != entities.WorkOrderPlanned && order.Status != entities.WorkOrderAssigned {
+		return nil, fmt.Errorf("%w: cannot attach defects to %s work order", ErrWorkOrderState, order.Status)
+	}
+	ids, err := orders.ListDefectIds(ctx, workOrderId)
+	if err != nil {
+		return nil, err
+	}
+	attached := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		attached[id] = true
+	}
+	employees, err := orders.ListEmployeeIds(ctx, workOrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, orders *repository.WorkOrderRepository, defects *repository.DefectRepository, id uint) (string, string, error) {
+		defect, err := defects.LockDefect(ctx, id)
+		if err != nil {
+			return "", "", err
+		}
+		if attached[id] {
+			return defect.Status, defect.Status, nil
+		}
+		if defect.Status != entities.DefectTriaged && defect.Status != entities.DefectScheduled {
+			return defect.Status, "", fmt.Errorf("%w: defect is %s, expected %s or %s", errBulkItem,
+				defect.Status, entities.DefectTriaged, entities.DefectScheduled)
+		}
+		busy, err := orders.DefectsInActiveOrders(ctx, []uint{id})
+		if err != nil {
+			return "", "", err
+		}
+		if len(busy) > 0 {
+			return defect.Status, "", fmt.Errorf("%w: defect already belongs to an active work order", errBulkItem)
+		}
+		if err := orders.AddDefects(ctx, workOrderId, []uint{id}); err != nil {
+			return "", "", err
+		}
+
+		if order.Status != entities.WorkOrderAssigned || defect.Status != entities.DefectTriaged {
+			return defect.Status, defect.Status, nil
+		}
+		change, err := applyTransition(ctx, defects, id, entities.TransitionRequest{
+			To:          entities.DefectScheduled,
+			PlannedDate: order.PlannedStart,
+			EmployeeIds: employees,
+			Comment:     fmt.Sprintf("work order #%d assigned", workOrderId),
+		})
+		if err != nil {
+			return "", "", err
+		}
+		return change.FromStatus, change.ToStatus, nil
+	}, nil
+}
+
+// isBulkItemError — ошибка относится к конкретному дефекту и не мешает проверить остальные
+func isBulkItemError(err error) bool {
+	var verr *entities.ValidationError
+	return errors.As(err, &verr) ||
+		errors.Is(err, errBulkItem) ||
+		errors.Is(err, repository.ErrDefectNotFound) ||
+		errors.Is(err, ErrInvalidTransition) ||
+		errors.Is(err, ErrUnknownStatus)
+}
+
+// record пишет в аудит сводку и изменение каждого дефекта, как при одиночных операциях
+func (s *BulkService) record(ctx context.Context, req entities.BulkDefectRequest, result *entities.BulkResult) {
+	ids := make([]uint, 0, len(result.Items))
+	for _, item := range result.Items {
+		ids = append(ids, item.DefectId)
+		switch req.Operation {
+		case entities.BulkTransition:
+			s.audit.Record(ctx, entities.AuditDefectTransition, "defect", item.DefectId,
+				map[string]interface{}{"status": item.FromStatus}, map[string]interface{}{"status": item.ToStatus})
+		case entities.BulkAssign:
+			s.audit.Record(ctx, entities.AuditEmployeesAssign, "defect", item.DefectId, nil,
+				map[string]interface{}{"employee_ids": req.EmployeeIds})
+		case entities.BulkSetGrade:
+			s.audit.Record(ctx, entities.AuditDefectUpdate, "defect", item.DefectId, nil,
+				map[string]interface{}{"quality_grade_id": req.QualityGradeId})
+		}
+	}
+	if req.Operation == entities.BulkAttachWorkOrder {
+		s.audit.Record(ctx, entities.AuditWorkOrderAttach, "work_order", req.WorkOrderId, nil,
+			map[string]interface{}{"defect_ids": ids})
+	}
+	// сводная запись — об операции целиком, а не о дефекте: id в журнале — имя операции
+	s.audit.Record(ctx, entities.AuditDefectBulk, "bulk_operation", req.Operation, nil, map[string]interface{}{
+		"operation":  req.Operation,
+		"defect_ids": ids,
+	})
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+)
+
+var (
+	ErrCommentForbidden = fmt.Errorf("only the author can change this comment")
+	ErrCommentNoAuthor  = fmt.Errorf("comments can be written by users only")
+	ErrCommentDeleted   = fmt.Errorf("comment is deleted")
+)
+
+const maxCommentLength = 10000
+
+// mentionPattern — @ivanov или @ivanov@company.kz; @ внутри email (a@b.kz) упоминанием не считается
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([\w.%+\-]+(?:@[\w\-]+(?:\.[\w\-]+)+)?)`)
+
+type CommentProvider interface {
+	List(ctx context.Context, defectId uint, page, limit int) ([]entities.Comment, int64, error)
+	Create(ctx context.Context, defectId uint, in entities.CommentInput) (*entities.Comment, error)
+	Update(ctx context.Context, commentId uint, in entities.CommentInput, moderator bool) (*entities.Comment, error)
+	Delete(ctx context.Context, commentId uint, moderator bool) (*entities.Comment, error)
+}
+
+type CommentService struct {
+	repo    *repository.CommentRepository
+	defects *repository.DefectRepository
+	audit   *AuditService
+}
+
+func NewCommentService(repo *repository.CommentRepository, defects *repository.DefectRepository, audit *AuditService) *CommentService {
+	return &CommentService{
+		repo:    repo,
+		defects: defects,
+		audit:   audit,
+	}
+}
+
+// parseMentions возвращает уникальные упоминания в нижнем регистре в порядке появления
+func parseMentions(body string) []string {
+	seen := map[string]bool{}
+	var handles []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(strings.TrimRight(m[1], "."))
+		if handle != "" && !seen[handle] {
+			seen[handle] = true
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+func validateComment(in entities.CommentInput) (string, error) {
+	body := strings.TrimSpace(in.Body)
+	verr := &entities.ValidationError{}
+	if body == "" {
+		verr.Add("body", "required")
+	}
+	if len([]rune(body)) > maxCommentLength {
+		verr.Addf("body", "must be at most %d characters", maxCommentLength)
+	}
+	return body, verr.Err()
+}
+
+func (s *CommentService) List(ctx context.Context, defectId uint, page, limit int) ([]entities.Comment, int64, error) {
+	if _, err := s.defects.GetDefect(ctx, defectId); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListThreads(ctx, defectId, page, limit)
+}
+
+func (s *CommentService) Create(ctx context.Context, defectId uint, in entities.CommentInput) (*entities.Comment, error) {
+	actor := entities.ActorFromContext(ctx)
+	if actor.UserId == 0 {
+		return nil, ErrCommentNoAuthor
+	}
+	if _, err := s.defects.GetDefect(ctx, defectId); err != nil {
+		return nil, err
+	}
+
+	body, err := validateComment(in)
+	if err != nil {
+		return nil, err
+	}
+
+	model := models.DefectComment{
+		DefectId:   defectId,
+		AuthorId:   actor.UserId,
+		Body:       body,
+		IsDecision: in.IsDecision,
+	}
+	if in.ParentId != nil {
+		parent, err := s.repo.GetComment(ctx, *in.ParentId)
+		if err != nil && !errors.Is(err, repository.ErrCommentNotFound) {
+			return nil, err
+		}
+		if err != nil || parent.DefectId != defectId {
+			verr := &entities.ValidationError{}
+			verr.Add("parent_id", "comment not found in this defect")
+			return nil, verr.Err()
+		}
+		// ответ на ответ остается в той же ветке
+		rootId := parent.CommentId
+		if parent.ParentId != nil {
+			rootId = *parent.ParentId
+		}
+		model.ParentId = &rootId
+	}
+
+	mentions, err := s.repo.ResolveMentions(ctx, parseMentions(body))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateComment(ctx, &model, mentions); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditCommentCreate, "comment", model.CommentId, nil,
+		map[string]interface{}{"defect_id": defectId, "body": body, "is_decision": in.IsDecision, "parent_id": model.ParentId})
+	return s.get(ctx, model.CommentId)
+}
+
+func (s *CommentService) Update(ctx context.Context, commentId uint, in entities.CommentInput, moderator bool) (*entities.Comment, error) {
+	before, err := s.editable(ctx, commentId, moderator)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := validateComment(in)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.repo.ResolveMentions(ctx, parseMentions(body))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateComment(ctx, commentId, body, in.IsDecision, mentions); err != nil {
+		return nil, err
+	}
+
+	after, err := s.get(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditCommentUpdate, "comment", commentId,
+		map[string]interface{}{"body": before.Body, "is_decision": before.IsDecision},
+		map[string]interface{}{"body": after.Body, "is_decision": after.IsDecision})
+	return after, nil
+}
+
+func (s *CommentService) Delete(ctx context.Context, commentId uint, moderator bool) (*entities.Comment, error) {
+	before, err := s.editable(ctx, commentId, moderator)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteComment(ctx, commentId); err != nil {
+		return nil, err
+	}
+
+	after, err := s.get(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditCommentDelete, "comment", commentId,
+		map[string]interface{}{"body": before.Body, "defect_id": before.DefectId}, nil)
+	return after, nil
+}
+
+// editable проверяет, что комментарий виден пользователю, не удален и принадлежит ему (или пользователь — модератор)
+func (s *CommentService) editable(ctx context.Context, commentId uint, moderator bool) (*models.DefectComment, error) {
+	comment, err := s.repo.GetComment(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.defects.GetDefect(ctx, comment.DefectId); err != nil {
+		if errors.Is(err, repository.ErrDefectNotFound) {
+			return nil, repository.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	if comment.DeletedAt != nil {
+		return nil, ErrCommentDeleted
+	}
+
+	actor := entities.ActorFromContext(ctx)
+	if !moderator && (actor.UserId == 0 || actor.UserId != comment.AuthorId) {
+		return nil, ErrCommentForbidden
+	}
+	return comment, nil
+}
+
+func (s *CommentService) get(ctx context.Context, commentId uint) (*entities.Comment, error) {
+	model, err := s.repo.GetComment(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	comment := repository.CommentToEntity(*model)
+	return &comment, nil
+}
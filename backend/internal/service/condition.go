@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+const (
+	defaultConditionHistory = 50
+	maxConditionHistory     = 500
+	dashboardWorstPipelines = 5
+)
+
+type ConditionProvider interface {
+	Settings(ctx context.Context) (entities.ConditionSettings, error)
+	UpdateSettings(ctx context.Context, settings entities.ConditionSettings) (*entities.ConditionSettings, error)
+	Recompute(ctx context.Context, pipelineId uint) error
+	PipelineCondition(ctx context.Context, pipelineId uint, limit int) (*entities.ConditionReport, error)
+	ObjectCondition(ctx context.Context, objectId uint, limit int) (*entities.ConditionReport, error)
+	Overview(ctx context.Context) (*entities.ConditionOverview, error)
+}
+
+// ConditionService считает индекс состояния трубопроводов и объектов.
+//
+// Индекс — число от 0 (аварийное) до 100 (исправное): взвешенное среднее пяти составляющих,
+// каждая от 0 до 1, умноженное на 100. Веса настраиваются и нормируются на сумму.
+//
+//   - severity — худший открытый дефект: 1 − rank / максимальный rank каталога оценок;
+//   - density — плотность открытых дефектов: 1 − min(n / density_per_object, 1) для объекта,
+//     для трубопровода — на километр паспортной длины (density_per_km), без длины — в среднем на объект;
+//   - risk — 1 − последняя предсказанная вероятность отказа; у трубопровода — худший объект.
+//     Без предсказаний составляющая не учитывается, ее вес выпадает из нормировки;
+//   - inspection — давность последней диагностики или обследования: 1 − min(дни / inspection_max_age_days, 1),
+//     объект без диагностик — 0; у трубопровода — среднее по объектам;
+//   - sensor — доля открытых дефектов без аномальной вибрации (ниже vibration_limit).
+//     Отдельного хранилища показаний датчиков нет, поэтому аномалии берутся из замеров вибрации дефектов.
+//
+// Индекс пересчитывается после импорта CSV, AI-прогноза и смены настроек; в историю пишутся
+// только изменения больше 0.05.
+type ConditionService struct {
+	repo      *repository.ConditionRepository
+	objects   *repository.ObjectRepository
+	pipelines *repository.PipelineRepository
+	audit     *AuditService
+
+	// пересчеты после импорта и прогнозов не должны идти параллельно и затирать друг друга
+	mu sync.Mutex
+}
+
+func NewConditionService(repo *repository.ConditionRepository, objects *repository.ObjectRepository, pipelines *repository.PipelineRepository, audit *AuditService) *ConditionService {
+	return &ConditionService{
+		repo:      repo,
+		objects:   objects,
+		pipelines: pipelines,
+		audit:     audit,
+	}
+}
+
+func (s *ConditionService) Settings(ctx context.Context) (entities.ConditionSettings, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+func validateConditionSettings(settings entities.ConditionSettings) error {
+	verr := &entities.ValidationError{}
+
+	w := settings.Weights
+	weights := []struct {
+		field string
+		value float64
+	}{{"severity", w.Severity}, {"density", w.Density}, {"risk", w.Risk}, {"inspection", w.Inspection}, {"sensor", w.Sensor}}
+	var sum float64
+	for _, wt := range weights {
+		if wt.value < 0 {
+			verr.Add("weights."+wt.field, "must not be negative")
+		}
+		sum += wt.value
+	}
+	if sum <= 0 {
+		verr.Add("weights", "at least one weight must be positive")
+	}
+
+	if settings.DensityPerObject <= 0 {
+		verr.Add("density_per_object", "must be positive")
+	}
+	if settings.DensityPerKm <= 0 {
+		verr.Add("density_per_km", "must be positive")
+	}
+	if settings.InspectionMaxAgeDays <= 0 {
+		verr.Add("inspection_max_age_days", "must be positive")
+	}
+	if settings.VibrationLimit <= 0 {
+		verr.Add("vibration_limit", "must be positive")
+	}
+	return verr.Err()
+}
+
+// UpdateSettings сохраняет настройки и сразу пересчитывает индекс по всем трубопроводам
+func (s *ConditionService) UpdateSettings(ctx context.Context, settings entities.ConditionSettings) (*entities.ConditionSettings, error) {
+	if err := validateConditionSettings(settings); err != nil {
+		return nil, err
+	}
+	before, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	if err := s.Recompute(ctx, 0); err != nil {
+		return nil, err
+	}
+
+	after, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	before.UpdatedAt, settings.UpdatedAt = nil, nil
+	s.audit.Record(ctx, entities.AuditConditionSettings, "condition_settings", "settings", before, settings)
+	return &after, nil
+}
+
+// Recompute пересчитывает индекс объектов трубопровода и его самого; pipelineId == 0 — всех трубопроводов
+func (s *ConditionService) Recompute(ctx context.Context, pipelineId uint) error {
+	// индекс общий для всех пользователей, поэтому считается по всем объектам, а не по видимым вызвавшему
+	ctx = entities.ContextWithScope(ctx, entities.AccessScope{All: true})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	maxRank, err := s.repo.MaxRank(ctx)
+	if err != nil {
+		return err
+	}
+	inputs, err := s.repo.ListInputs(ctx, pipelineId, settings.VibrationLimit)
+	if err != nil {
+		return err
+	}
+	lengths, err := s.repo.ListPipelineLengths(ctx, pipelineId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	objects := make(map[uint]entities.ConditionPoint, len(inputs))
+	byPipeline := make(map[uint][]repository.ConditionInput)
+	for _, in := range inputs {
+		objects[in.ObjectId] = objectCondition(in, settings, maxRank, now)
+		byPipeline[in.PipelineId] = append(byPipeline[in.PipelineId], in)
+	}
+	// трубопровод без объектов не оценивается: данных для индекса нет
+	pipelines := make(map[uint]entities.ConditionPoint, len(byPipeline))
+	for id, objs := range byPipeline {
+		if _, ok := lengths[id]; ok {
+			pipelines[id] = pipelineCondition(objs, lengths[id], settings, maxRank, now)
+		}
+	}
+
+	if err := s.repo.SaveConditions(ctx, entities.ConditionEntityObject, objects); err != nil {
+		return err
+	}
+	return s.repo.SaveConditions(ctx, entities.ConditionEntityPipeline, pipelines)
+}
+
+func severityHealth(rank, maxRank int) float64 {
+	if maxRank <= 0 {
+		return 1
+	}
+	return 1 - clamp01(float64(rank)/float64(maxRank))
+}
+
+func inspectionHealth(last *time.Time, maxAgeDays int, now time.Time) float64 {
+	if last == nil {
+		return 0
+	}
+	days := now.Sub(*last).Hours() / 24
+	return 1 - clamp01(days/float64(maxAgeDays))
+}
+
+func sensorHealth(anomalies, open int) float64 {
+	if open == 0 {
+		return 1
+	}
+	return 1 - clamp01(float64(anomalies)/float64(open))
+}
+
+func objectCondition(in repository.ConditionInput, settings entities.ConditionSettings, maxRank int, now time.Time) entities.ConditionPoint {
+	c := entities.ConditionComponents{
+		Severity:   severityHealth(in.MaxOpenRank, maxRank),
+		Density:    1 - clamp01(float64(in.OpenDefects)/settings.DensityPerObject),
+		Inspection: inspectionHealth(in.LastInspection, settings.InspectionMaxAgeDays, now),
+		Sensor:     sensorHealth(in.Anomalies, in.OpenDefects),
+	}
+	if in.Probability != nil {
+		risk := 1 - clamp01(*in.Probability)
+		c.Risk = &risk
+	}
+	return conditionPoint(c, settings.Weights, now)
+}
+
+func pipelineCondition(objects []repository.ConditionInput, lengthKm float64, settings entities.ConditionSettings, maxRank int, now time.Time) entities.ConditionPoint {
+	var (
+		worstRank, open, anomalies int
+		inspection                 float64
+		worstProbability           *float64
+	)
+	for _, o := range objects {
+		if o.MaxOpenRank > worstRank {
+			worstRank = o.MaxOpenRank
+		}
+		open += o.OpenDefects
+		anomalies += o.Anomalies
+		inspection += inspectionHealth(o.LastInspection, settings.InspectionMaxAgeDays, now)
+		if o.Probability != nil && (worstProbability == nil || *o.Probability > *worstProbability) {
+			worstProbability = o.Probability
+		}
+	}
+
+	density := 1 - clamp01(float64(open)/float64(len(objects))/settings.DensityPerObject)
+	if lengthKm > 0 {
+		density = 1 - clamp01(float64(open)/lengthKm/settings.DensityPerKm)
+	}
+	c := entities.ConditionComponents{
+		Severity:   severityHealth(worstRank, maxRank),
+		Density:    density,
+		Inspection: inspection / float64(len(objects)),
+		Sensor:     sensorHealth(anomalies, open),
+	}
+	if worstProbability != nil {
+		risk := 1 - clamp01(*worstProbability)
+		c.Risk = &risk
+	}
+	return conditionPoint(c, settings.Weights, now)
+}
+
+// conditionPoint сводит составляющие в индекс; вес составляющей без данных выпадает из нормировки
+func conditionPoint(c entities.ConditionComponents, w entities.ConditionWeights, now time.Time) entities.ConditionPoint {
+	sum := w.Severity*c.Severity + w.Density*c.Density + w.Inspection*c.Inspection + w.Sensor*c.Sensor
+	total := w.Severity + w.Density + w.Inspection + w.Sensor
+	if c.Risk != nil {
+		sum += w.Risk * *c.Risk
+		total += w.Risk
+	}
+
+	value := 100.0
+	if total > 0 {
+		value = math.Round(1000*sum/total) / 10
+	}
+	return entities.ConditionPoint{
+		Condition:  value,
+		Band:       entities.ConditionBand(value),
+		Components: c,
+		ComputedAt: now,
+	}
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func conditionLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return defaultConditionHistory
+	case limit > maxConditionHistory:
+		return maxConditionHistory
+	}
+	return limit
+}
+
+// report собирает текущее значение и историю; составляющие текущего значения — из последней записи истории
+func (s *ConditionService) report(ctx context.Context, entityType string, entityId uint, current *float64, at *time.Time, limit int) (*entities.ConditionReport, error) {
+	history, err := s.repo.History(ctx, entityType, entityId, conditionLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	report := &entities.ConditionReport{EntityType: entityType, EntityId: entityId, History: history}
+	if current != nil && at != nil {
+		point := entities.ConditionPoint{Condition: *current, Band: entities.ConditionBand(*current), ComputedAt: *at}
+		if len(history) > 0 {
+			point.Components = history[0].Components
+		}
+		report.Current = &point
+	}
+	return report, nil
+}
+
+func (s *ConditionService) PipelineCondition(ctx context.Context, pipelineId uint, limit int) (*entities.ConditionReport, error) {
+	pipeline, err := s.pipelines.GetPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	return s.report(ctx, entities.ConditionEntityPipeline, pipelineId, &pipeline.Condition, pipeline.ConditionAt, limit)
+}
+
+func (s *ConditionService) ObjectCondition(ctx context.Context, objectId uint, limit int) (*entities.ConditionReport, error) {
+	object, err := s.objects.GetObject(ctx, objectId)
+	if err != nil {
+		return nil, err
+	}
+	return s.report(ctx, entities.ConditionEntityObject, objectId, object.Condition, object.ConditionAt, limit)
+}
+
+func (s *ConditionService) Overview(ctx context.Context) (*entities.ConditionOverview, error) {
+	return s.repo.Overview(ctx, dashboardWorstPipelines)
+}
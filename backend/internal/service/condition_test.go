@@ -0,0 +1,135 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+func TestObjectCondition(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	settings := entities.DefaultConditionSettings
+	yearAgo := now.AddDate(-1, 0, -1) // 366 дней
+	today := now
+
+	tests := []struct {
+		name string
+		in   repository.ConditionInput
+		want float64
+		band string
+		risk bool
+	}{
+		{
+			// без дефектов и предсказаний, обследован сегодня: все учтенные составляющие равны 1
+			name: "healthy",
+			in:   repository.ConditionInput{LastInspection: &today},
+			want: 100,
+			band: entities.ConditionGood,
+		},
+		{
+			// severity 1−2/4 = 0.5, density 1−5/10 = 0.5, risk 1−0.4 = 0.6,
+			// inspection 1−366/730, sensor 1−1/5 = 0.8
+			name: "all components",
+			in: repository.ConditionInput{MaxOpenRank: 2, OpenDefects: 5, Anomalies: 1,
+				Probability: ptr(0.4), LastInspection: &yearAgo},
+			want: math.Round(1000*(0.35*0.5+0.15*0.5+0.25*0.6+0.15*(1-366.0/730)+0.10*0.8)) / 10,
+			band: entities.ConditionFair,
+			risk: true,
+		},
+		{
+			// никогда не обследовался, худшая оценка, дефектов больше порога плотности, все с вибрацией;
+			// без предсказаний вес risk выпадает из нормировки
+			name: "worst without prediction",
+			in:   repository.ConditionInput{MaxOpenRank: 4, OpenDefects: 20, Anomalies: 20},
+			want: 0,
+			band: entities.ConditionPoor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := objectCondition(tt.in, settings, 4, now)
+			if p.Condition != tt.want {
+				t.Errorf("condition = %v, want %v", p.Condition, tt.want)
+			}
+			if p.Band != tt.band {
+				t.Errorf("band = %s, want %s", p.Band, tt.band)
+			}
+			if (p.Components.Risk != nil) != tt.risk {
+				t.Errorf("risk set = %v, want %v", p.Components.Risk != nil, tt.risk)
+			}
+			if !p.ComputedAt.Equal(now) {
+				t.Errorf("computed at = %v, want %v", p.ComputedAt, now)
+			}
+		})
+	}
+}
+
+func TestPipelineCondition(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	settings := entities.DefaultConditionSettings
+	objects := []repository.ConditionInput{
+		{MaxOpenRank: 1, OpenDefects: 2, Probability: ptr(0.2), LastInspection: &now},
+		{MaxOpenRank: 3, OpenDefects: 4, Anomalies: 3, Probability: ptr(0.7)},
+	}
+
+	tests := []struct {
+		name     string
+		lengthKm float64
+		density  float64
+	}{
+		// 6 дефектов на 2 объекта, порог 10 на объект
+		{"without length", 0, 1 - 3.0/10},
+		// 6 дефектов на 10 км, порог 2 на км
+		{"per km", 10, 1 - 0.6/2},
+		{"dense", 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := pipelineCondition(objects, tt.lengthKm, settings, 4, now)
+			c := p.Components
+
+			if c.Severity != 0.25 {
+				t.Errorf("severity = %v, want 0.25 (worst object)", c.Severity)
+			}
+			if math.Abs(c.Density-tt.density) > 1e-9 {
+				t.Errorf("density = %v, want %v", c.Density, tt.density)
+			}
+			if c.Risk == nil || math.Abs(*c.Risk-0.3) > 1e-9 {
+				t.Errorf("risk = %v, want 0.3 (worst prediction)", c.Risk)
+			}
+			if c.Inspection != 0.5 {
+				t.Errorf("inspection = %v, want 0.5 (average of 1 and 0)", c.Inspection)
+			}
+			if c.Sensor != 0.5 {
+				t.Errorf("sensor = %v, want 0.5", c.Sensor)
+			}
+
+			want := math.Round(1000*(0.35*0.25+0.15*tt.density+0.25*0.3+0.15*0.5+0.10*0.5)) / 10
+			if p.Condition != want {
+				t.Errorf("condition = %v, want %v", p.Condition, want)
+			}
+		})
+	}
+}
+
+func TestConditionPointWeights(t *testing.T) {
+	now := time.Now()
+	c := entities.ConditionComponents{Severity: 1, Density: 0, Inspection: 0, Sensor: 0}
+
+	// веса нормируются на сумму, масштаб не важен
+	a := conditionPoint(c, entities.ConditionWeights{Severity: 1, Density: 1}, now)
+	b := conditionPoint(c, entities.ConditionWeights{Severity: 10, Density: 10}, now)
+	if a.Condition != 50 || b.Condition != 50 {
+		t.Errorf("condition = %v and %v, want 50", a.Condition, b.Condition)
+	}
+
+	// без весов оценить нечего — считаем исправным
+	if p := conditionPoint(c, entities.ConditionWeights{}, now); p.Condition != 100 {
+		t.Errorf("condition with zero weights = %v, want 100", p.Condition)
+	}
+}
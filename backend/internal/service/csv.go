@@ -10,18 +10,22 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
 	"github.com/rwrrioe/integrity/backend/internal/repository/models"
 	"github.com/rwrrioe/integrity/backend/internal/storage"
 	"gorm.io/gorm"
 )
 
 type SCVParser struct {
-	redis storage.RedisStorage
-	db    *gorm.DB
+	redis      storage.RedisStorage
+	db         *gorm.DB
+	conditions *ConditionService
+	audit      *AuditService
 }
 
-func NewScvParser(redis storage.RedisStorage, db *gorm.DB) *SCVParser {
-	return &SCVParser{redis: redis, db: db}
+func NewScvParser(redis storage.RedisStorage, db *gorm.DB, conditions *ConditionService, audit *AuditService) *SCVParser {
+	return &SCVParser{redis: redis, db: db, conditions: conditions, audit: audit}
 }
 
 // --- Хелперы ---
@@ -61,14 +65,18 @@ func parseDate(s string) time.Time {
 	return t
 }
 
+// formatGeoPoint возвращает точку в EWKT — так ее принимает колонка geography
 func formatGeoPoint(lat, lon float64) string {
-	return fmt.Sprintf("ST_MakePoint(%f,%f),4326", lon, lat)
+	return fmt.Sprintf("SRID=4326;POINT(%f %f)", lon, lat)
 }
 
 // --- Импорт Объектов (из Redis) ---
 
 // ImportObjects принимает ключ Redis (или ID для формирования ключа), достает CSV строку и парсит её
 func (s *SCVParser) ImportObjects(ctx context.Context, redisKey string) error {
+	// импорт — системная операция: строки сопоставляются со всеми объектами, а не с видимыми автору
+	ctx = entities.ContextWithScope(ctx, entities.AccessScope{All: true})
+
 	// 1. Получаем данные из Redis (предполагаем, что там лежит содержимое CSV файла как строка)
 	csvContent, err := s.redis.Get(ctx, redisKey)
 	if err != nil {
@@ -83,6 +91,7 @@ func (s *SCVParser) ImportObjects(ctx context.Context, redisKey string) error {
 		return err
 	}
 
+	var saved, failed int
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
@@ -100,7 +109,7 @@ func (s *SCVParser) ImportObjects(ctx context.Context, redisKey string) error {
 		pipelineName := record[3]
 		lat := parseFloat(record[4])
 		lon := parseFloat(record[5])
-		// year := record[6]
+		year := int(parseUint(record[6]))
 		material := record[7]
 
 		// Логика БД
@@ -117,27 +126,41 @@ func (s *SCVParser) ImportObjects(ctx context.Context, redisKey string) error {
 		}
 
 		object := models.Object{
-			ObjectId:     objID,
-			ObjectName:   objName,
-			ObjectTypeId: objType.ObjectTypeId,
-			PipelineId:   pipeline.PipelineId,
-			Lat:          lat,
-			Lon:          lon,
-			Location:     formatGeoPoint(lat, lon),
-			Material:     material,
+			ObjectId:         objID,
+			ObjectName:       objName,
+			ObjectTypeId:     objType.ObjectTypeId,
+			PipelineId:       pipeline.PipelineId,
+			Lat:              lat,
+			Lon:              lon,
+			Location:         formatGeoPoint(lat, lon),
+			Material:         material,
+			InstallationYear: year,
 		}
 
 		if err := s.db.Save(&object).Error; err != nil {
 			log.Printf("Ошибка сохранения объекта %d: %v", objID, err)
+			failed++
+			continue
 		}
+		saved++
 	}
 
+	s.snapRoutes(ctx)
+	s.assignDistricts(ctx)
+	s.recomputeConditions(ctx)
+
+	s.audit.Record(ctx, entities.AuditImportObjects, "import", redisKey, nil, map[string]interface{}{
+		"objects_saved": saved, "objects_failed": failed,
+	})
 	return nil
 }
 
 // --- Импорт Диагностики и Дефектов (из Redis) ---
 
 func (s *SCVParser) ImportDiagnostics(ctx context.Context, redisKey string) error {
+	// как и ImportObjects, без ограничения области видимости
+	ctx = entities.ContextWithScope(ctx, entities.AccessScope{All: true})
+
 	// 1. Получаем данные
 	csvContent, err := s.redis.Get(ctx, redisKey)
 	if err != nil {
@@ -155,6 +178,9 @@ func (s *SCVParser) ImportDiagnostics(ctx context.Context, redisKey string) erro
 	var defaultDefectType models.DefectType
 	s.db.FirstOrCreate(&defaultDefectType, models.DefectType{Name: "General"})
 
+	defectRepo := repository.NewDefectRepo(s.db)
+
+	var diagnostics, defects, remeasured, failed int
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
@@ -173,7 +199,7 @@ func (s *SCVParser) ImportDiagnostics(ctx context.Context, redisKey string) erro
 		illum := parseFloat(record[6])
 		hasDefect := parseBool(record[7])
 		defDesc := record[8]
-		qGradeName := record[9]
+		qGradeName := entities.NormalizeGradeCode(record[9])
 		param1 := parseFloat(record[10])
 		param2 := parseFloat(record[11])
 		mlLabel := record[13]
@@ -195,8 +221,10 @@ func (s *SCVParser) ImportDiagnostics(ctx context.Context, redisKey string) erro
 		// Обращаемся к s.db
 		if err := s.db.Create(&diagnostic).Error; err != nil {
 			log.Printf("Ошибка сохранения диагностики для объекта %d: %v", objID, err)
+			failed++
 			continue
 		}
+		diagnostics++
 
 		// 3. ProbabilityHistory
 		var prob float64
@@ -224,13 +252,37 @@ func (s *SCVParser) ImportDiagnostics(ctx context.Context, redisKey string) erro
 			// Ищем координаты родительского объекта
 			var parentObj models.Object
 			if err := s.db.Select("Lat", "Lon").First(&parentObj, objID).Error; err == nil {
+				measurement := models.DefectMeasurement{
+					DiagnosticId: &diagnostic.DiagnosticId,
+					MeasuredAt:   dateVal,
+					Depth:        param1,
+					Source:       entities.MeasurementImport,
+				}
+
+				// Та же аномалия, замеренная повторно, продолжает трек существующего дефекта.
+				// Несколько аномалий объекта за одну дату — разные дефекты, в один трек они не сливаются.
+				track, err := defectRepo.FindTrack(ctx, objID, defaultDefectType.DefectTypeId, parentObj.Lat, parentObj.Lon, trackMatchRadius, dateVal)
+				if err != nil {
+					log.Printf("Ошибка поиска трека дефекта для объекта %d: %v", objID, err)
+					continue
+				}
+				if track != nil {
+					measurement.DefectId = track.DefectId
+					measurement.Length, measurement.Width = track.Length, track.Width
+					if err := defectRepo.AddMeasurement(ctx, &measurement); err != nil {
+						log.Printf("Ошибка сохранения замера дефекта %d: %v", track.DefectId, err)
+					} else {
+						remeasured++
+					}
+					continue
+				}
 
 				defect := models.Defect{
 					ObjectId:       objID,
 					DefectTypeId:   defaultDefectType.DefectTypeId,
 					QualityGradeId: qGrade.QualityGradeId, // Исправлено: QualityGradeIdId -> QualityGradeId
 					Description:    defDesc,
-					Status:         "New",
+					Status:         entities.DefectNew,
 					Date:           dateVal,
 					Depth:          param1,
 					Vibration:      param2,
@@ -241,6 +293,12 @@ func (s *SCVParser) ImportDiagnostics(ctx context.Context, redisKey string) erro
 
 				if err := s.db.Create(&defect).Error; err != nil {
 					log.Printf("Ошибка сохранения дефекта: %v", err)
+				} else {
+					defects++
+					measurement.DefectId = defect.DefectId
+					if err := defectRepo.AddMeasurement(ctx, &measurement); err != nil {
+						log.Printf("Ошибка сохранения замера дефекта %d: %v", defect.DefectId, err)
+					}
 				}
 			} else {
 				log.Printf("Не удалось найти объект %d для привязки координат дефекта", objID)
@@ -248,5 +306,33 @@ func (s *SCVParser) ImportDiagnostics(ctx context.Context, redisKey string) erro
 		}
 	}
 
+	s.snapRoutes(ctx)
+	s.recomputeConditions(ctx)
+
+	s.audit.Record(ctx, entities.AuditImportDiagnostics, "import", redisKey, nil, map[string]interface{}{
+		"diagnostics_saved": diagnostics, "defects_saved": defects, "defects_remeasured": remeasured, "diagnostics_failed": failed,
+	})
 	return nil
 }
+
+// snapRoutes привязывает импортированные объекты и дефекты к трассам трубопроводов.
+// Ошибка привязки не отменяет импорт: пикеты пересчитаются при следующей загрузке трассы.
+func (s *SCVParser) snapRoutes(ctx context.Context) {
+	if _, _, err := repository.NewPipelineRepository(s.db).SnapPipeline(ctx, 0); err != nil {
+		log.Printf("Ошибка привязки к трассам: %v", err)
+	}
+}
+
+// assignDistricts привязывает объекты к районам по границам; как и трассы, ошибка импорт не отменяет
+func (s *SCVParser) assignDistricts(ctx context.Context) {
+	if _, err := repository.NewDistrictRepository(s.db).AssignObjects(ctx); err != nil {
+		log.Printf("Ошибка привязки объектов к районам: %v", err)
+	}
+}
+
+// recomputeConditions пересчитывает индекс состояния после импорта; ошибка импорт не отменяет
+func (s *SCVParser) recomputeConditions(ctx context.Context) {
+	if err := s.conditions.Recompute(ctx, 0); err != nil {
+		log.Printf("Ошибка пересчета индекса состояния: %v", err)
+	}
+}
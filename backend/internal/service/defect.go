@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"time"
 
 	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
 	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
 	"github.com/rwrrioe/integrity/backend/internal/storage"
 )
 
@@ -17,22 +21,55 @@ type DefectProvider interface {
 	DefectsByYears(ctx context.Context, year1, year2, year3, year4, year5 int) (*[]entities.DefectsByYear, error)
 	Top5Defects(ctx context.Context) (*[]entities.DefectStateMetrics, error)
 	DefectsByCriticality(ctx context.Context) (*[]entities.DefectStateMetrics, error)
+	Transition(ctx context.Context, defectId uint, req entities.TransitionRequest) (*entities.DefectStatusChange, error)
+	Growth(ctx context.Context, defectId uint) (*entities.DefectGrowth, error)
+	AddMeasurement(ctx context.Context, defectId uint, in entities.MeasurementInput) (*entities.DefectGrowth, error)
+	StatusHistory(ctx context.Context, defectId uint) ([]entities.DefectStatusChange, error)
+	CreateDefect(ctx context.Context, in entities.DefectInput) (*entities.Defect, error)
+	UpdateDefect(ctx context.Context, defectId uint, in entities.DefectInput) (*entities.Defect, error)
+	DeleteDefect(ctx context.Context, defectId uint) error
+	ListDefects(ctx context.Context, f entities.DefectFilter) (*entities.DefectPage, error)
+	HeatmapPoints(ctx context.Context, f entities.DefectFilter) ([]entities.HeatPoint, error)
+	ExportDefects(ctx context.Context, f entities.DefectFilter, w io.Writer) error
 }
 
+// Допустимые размеры дефекта, мм
+const (
+	maxDefectDepth = 100.0
+	maxDefectSize  = 10000.0
+)
+
 type DefectService struct {
-	repo  *repository.DefectRepository
-	redis *storage.RedisStorage
+	repo     *repository.DefectRepository
+	redis    *storage.RedisStorage
+	heatmaps *HeatmapService
+	audit    *AuditService
 }
 
-func NewDefectService(repo *repository.DefectRepository, redis *storage.RedisStorage) *DefectService {
+func NewDefectService(repo *repository.DefectRepository, redis *storage.RedisStorage, heatmaps *HeatmapService, audit *AuditService) *DefectService {
 	return &DefectService{
-		repo:  repo,
-		redis: redis,
+		repo:     repo,
+		redis:    redis,
+		heatmaps: heatmaps,
+		audit:    audit,
+	}
+}
+
+const defectCachePrefix = "defectserv:"
+
+// invalidate сбрасывает тепловую карту и закэшированную статистику дефектов всех областей видимости.
+// Ошибка кэша не отменяет уже сохраненное изменение — только логируется.
+func (s *DefectService) invalidate(ctx context.Context) {
+	if err := s.heatmaps.Invalidate(ctx); err != nil {
+		log.Printf("defects: failed to invalidate heatmap cache: %v", err)
+	}
+	if err := s.redis.DeletePrefix(ctx, defectCachePrefix); err != nil {
+		log.Printf("defects: failed to invalidate stats cache: %v", err)
 	}
 }
 
 func (s *DefectService) GetPipelineMetrics(ctx context.Context, pipelineId uint) (*entities.DefectMetrics, error) {
-	key := fmt.Sprintf("defectserv:pipestats:%d", pipelineId)
+	key := fmt.Sprintf("defectserv:pipestats:%d:%s", pipelineId, entities.ScopeFromContext(ctx).CacheKey())
 	result, err := s.redis.Get(ctx, key)
 	if err == nil {
 		var stats entities.DefectMetrics
@@ -40,34 +77,18 @@ func (s *DefectService) GetPipelineMetrics(ctx context.Context, pipelineId uint)
 		return &stats, nil
 	}
 
-	var stateMetrics []entities.DefectStateMetrics
-
-	solved, err := s.repo.CountByStatus(ctx, pipelineId, "Solved")
+	distribution, err := s.repo.CountStatuses(ctx, pipelineId)
 	if err != nil {
 		return nil, err
 	}
-	stateMetrics = append(stateMetrics, entities.DefectStateMetrics{
-		Status: "solved",
-		Count:  solved.Count,
-	})
 
-	created, err := s.repo.CountByStatus(ctx, pipelineId, "Created")
-	if err != nil {
-		return nil, err
+	stateMetrics := make([]entities.DefectStateMetrics, 0, len(entities.DefectStatuses))
+	for _, status := range entities.DefectStatuses {
+		stateMetrics = append(stateMetrics, entities.DefectStateMetrics{
+			Status: status,
+			Count:  int(distribution[status]),
+		})
 	}
-	stateMetrics = append(stateMetrics, entities.DefectStateMetrics{
-		Status: "created",
-		Count:  created.Count,
-	})
-
-	processing, err := s.repo.CountByStatus(ctx, pipelineId, "Processing")
-	if err != nil {
-		return nil, err
-	}
-	stateMetrics = append(stateMetrics, entities.DefectStateMetrics{
-		Status: "processing",
-		Count:  processing.Count,
-	})
 
 	avgImp, err := s.repo.GetAvgImportanceByPipeline(ctx, pipelineId)
 	if err != nil {
@@ -99,11 +120,14 @@ func (s *DefectService) AutoEmployeeAssign(ctx context.Context, objId uint, num
 		return nil, err
 	}
 
+	s.audit.Record(ctx, entities.AuditEmployeesAssign, "object", objId, nil, map[string]interface{}{
+		"employee_ids": employeeIds,
+	})
 	return emps, nil
 }
 
 func (s *DefectService) DefectsByYears(ctx context.Context, year1, year2, year3, year4, year5 int) (*[]entities.DefectsByYear, error) {
-	key := fmt.Sprintf("defectserv:byyears:%d:%d:%d:%d:%d", year1, year2, year3, year4, year5)
+	key := fmt.Sprintf("defectserv:byyears:%d:%d:%d:%d:%d:%s", year1, year2, year3, year4, year5, entities.ScopeFromContext(ctx).CacheKey())
 	result, err := s.redis.Get(ctx, key)
 	if err == nil {
 		var stats []entities.DefectsByYear
@@ -177,7 +201,7 @@ func (s *DefectService) DefectsByYears(ctx context.Context, year1, year2, year3,
 }
 
 func (s *DefectService) Top5Defects(ctx context.Context) (*[]entities.DefectStateMetrics, error) {
-	key := "defectserv:top5"
+	key := "defectserv:top5:" + entities.ScopeFromContext(ctx).CacheKey()
 	result, err := s.redis.Get(ctx, key)
 	if err == nil {
 		var metrics []entities.DefectStateMetrics
@@ -195,7 +219,7 @@ func (s *DefectService) Top5Defects(ctx context.Context) (*[]entities.DefectStat
 }
 
 func (s *DefectService) DefectsByCriticality(ctx context.Context) (*[]entities.DefectStateMetrics, error) {
-	key := "defectserv:byCriticality"
+	key := "defectserv:byCriticality:" + entities.ScopeFromContext(ctx).CacheKey()
 	result, err := s.redis.Get(ctx, key)
 	if err == nil {
 		var metrics []entities.DefectStateMetrics
@@ -210,3 +234,266 @@ func (s *DefectService) DefectsByCriticality(ctx context.Context) (*[]entities.D
 	s.redis.Set(ctx, key, *res)
 	return res, nil
 }
+
+// checkDimensions проверяет размеры дефекта в мм и складывает ошибки в verr. nil — поле не передано.
+// Общая для ручного ввода и замеров трека, чтобы пределы были одинаковыми.
+func checkDimensions(verr *entities.ValidationError, depth, length, width *float64) {
+	checkRange := func(field string, v *float64, max float64) {
+		if v != nil && (*v < 0 || *v > max) {
+			verr.Addf(field, "must be between 0 and %g mm", max)
+		}
+	}
+	checkRange("depth", depth, maxDefectDepth)
+	checkRange("length", length, maxDefectSize)
+	checkRange("width", width, maxDefectSize)
+}
+
+// validateDefect проверяет входные данные. create — все обязательные поля должны быть заданы;
+// при изменении проверяются только переданные поля.
+func (s *DefectService) validateDefect(ctx context.Context, in entities.DefectInput, create bool) error {
+	verr := &entities.ValidationError{}
+
+	required := func(field string, set bool) bool {
+		if !set && create {
+			verr.Add(field, "required")
+		}
+		return set
+	}
+
+	if required("object_id", in.ObjectId != nil) {
+		ok, err := s.repo.ObjectExists(ctx, *in.ObjectId)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			verr.Add("object_id", "object not found")
+		}
+	}
+	if required("defect_type_id", in.DefectTypeId != nil) {
+		ok, err := s.repo.DefectTypeExists(ctx, *in.DefectTypeId)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			verr.Add("defect_type_id", "defect type not found")
+		}
+	}
+	if required("quality_grade_id", in.QualityGradeId != nil) {
+		ok, err := s.repo.QualityGradeExists(ctx, *in.QualityGradeId)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			verr.Add("quality_grade_id", "quality grade not found")
+		}
+	}
+
+	checkDimensions(verr, in.Depth, in.Length, in.Width)
+	if in.Vibration != nil && *in.Vibration < 0 {
+		verr.Add("vibration", "must not be negative")
+	}
+
+	// координаты задаются только парой
+	required("lat", in.Lat != nil)
+	required("lon", in.Lon != nil)
+	if !create && (in.Lat == nil) != (in.Lon == nil) {
+		verr.Add("lat", "lat and lon must be set together")
+	}
+	if in.Lat != nil && (*in.Lat < -90 || *in.Lat > 90) {
+		verr.Add("lat", "must be between -90 and 90")
+	}
+	if in.Lon != nil && (*in.Lon < -180 || *in.Lon > 180) {
+		verr.Add("lon", "must be between -180 and 180")
+	}
+	if in.Lat != nil && in.Lon != nil && *in.Lat == 0 && *in.Lon == 0 {
+		verr.Add("lat", "coordinates are not set")
+	}
+
+	if in.Date != nil && in.Date.After(time.Now().Add(24*time.Hour)) {
+		verr.Add("date", "must not be in the future")
+	}
+
+	return verr.Err()
+}
+
+func (s *DefectService) CreateDefect(ctx context.Context, in entities.DefectInput) (*entities.Defect, error) {
+	if err := s.validateDefect(ctx, in, true); err != nil {
+		return nil, err
+	}
+
+	model := models.Defect{
+		ObjectId:       *in.ObjectId,
+		DefectTypeId:   *in.DefectTypeId,
+		QualityGradeId: *in.QualityGradeId,
+		Status:         entities.DefectNew,
+		Date:           time.Now(),
+		Lat:            *in.Lat,
+		Lon:            *in.Lon,
+		Location:       formatGeoPoint(*in.Lat, *in.Lon),
+	}
+	if in.Description != nil {
+		model.Description = *in.Description
+	}
+	if in.Date != nil {
+		model.Date = *in.Date
+	}
+	if in.Depth != nil {
+		model.Depth = *in.Depth
+	}
+	if in.Length != nil {
+		model.Length = *in.Length
+	}
+	if in.Width != nil {
+		model.Width = *in.Width
+	}
+	if in.Vibration != nil {
+		model.Vibration = *in.Vibration
+	}
+
+	// дефект, его пикет и первый замер трека создаются вместе или не создаются вовсе
+	err := s.repo.Transaction(ctx, func(tx *repository.DefectRepository) error {
+		if err := tx.CreateDefect(ctx, &model); err != nil {
+			return err
+		}
+		if err := tx.SnapDefect(ctx, model.DefectId); err != nil {
+			return err
+		}
+		return tx.AddMeasurement(ctx, &models.DefectMeasurement{
+			DefectId:   model.DefectId,
+			MeasuredAt: model.Date,
+			Depth:      model.Depth,
+			Length:     model.Length,
+			Width:      model.Width,
+			Source:     entities.MeasurementManual,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+
+	defect, err := s.repo.GetDefect(ctx, model.DefectId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditDefectCreate, "defect", model.DefectId, nil, defect)
+	return defect, nil
+}
+
+func (s *DefectService) UpdateDefect(ctx context.Context, defectId uint, in entities.DefectInput) (*entities.Defect, error) {
+	if err := s.validateDefect(ctx, in, false); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if in.ObjectId != nil {
+		fields["object_id"] = *in.ObjectId
+	}
+	if in.DefectTypeId != nil {
+		fields["defect_type_id"] = *in.DefectTypeId
+	}
+	if in.QualityGradeId != nil {
+		fields["quality_grade_id"] = *in.QualityGradeId
+	}
+	if in.Description != nil {
+		fields["description"] = *in.Description
+	}
+	if in.Date != nil {
+		fields["date"] = *in.Date
+	}
+	if in.Depth != nil {
+		fields["depth"] = *in.Depth
+	}
+	if in.Length != nil {
+		fields["length"] = *in.Length
+	}
+	if in.Width != nil {
+		fields["width"] = *in.Width
+	}
+	if in.Vibration != nil {
+		fields["vibration"] = *in.Vibration
+	}
+	if in.Lat != nil && in.Lon != nil {
+		fields["lat"] = *in.Lat
+		fields["lon"] = *in.Lon
+		fields["location"] = formatGeoPoint(*in.Lat, *in.Lon)
+	}
+
+	// состояние до правки читается под блокировкой: параллельная правка не разведет аудит и трек замеров
+	var before *entities.Defect
+	err := s.repo.Transaction(ctx, func(tx *repository.DefectRepository) error {
+		if _, err := tx.LockDefect(ctx, defectId); err != nil {
+			return err
+		}
+		current, err := tx.GetDefect(ctx, defectId)
+		if err != nil {
+			return err
+		}
+		before = current
+
+		if len(fields) > 0 {
+			if err := tx.UpdateDefectFields(ctx, defectId, fields); err != nil {
+				return err
+			}
+		}
+		// пикет зависит от координат и трассы трубопровода объекта
+		if in.ObjectId != nil || in.Lat != nil {
+			if err := tx.SnapDefect(ctx, defectId); err != nil {
+				return err
+			}
+		}
+		// ручная правка размеров — новый замер трека, иначе серия роста разойдется с размерами дефекта
+		if m, changed := dimensionsMeasurement(*before, in); changed {
+			m.DefectId = defectId
+			return tx.AddMeasurement(ctx, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+
+	after, err := s.repo.GetDefect(ctx, defectId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditDefectUpdate, "defect", defectId, before, after)
+	return after, nil
+}
+
+// dimensionsMeasurement собирает замер из размеров дефекта после правки in; changed = false, если размеры те же
+func dimensionsMeasurement(before entities.Defect, in entities.DefectInput) (*models.DefectMeasurement, bool) {
+	m := &models.DefectMeasurement{
+		MeasuredAt: time.Now(),
+		Depth:      before.Depth,
+		Length:     before.Length,
+		Width:      before.Width,
+		Source:     entities.MeasurementManual,
+	}
+	if in.Depth != nil {
+		m.Depth = *in.Depth
+	}
+	if in.Length != nil {
+		m.Length = *in.Length
+	}
+	if in.Width != nil {
+		m.Width = *in.Width
+	}
+	changed := m.Depth != before.Depth || m.Length != before.Length || m.Width != before.Width
+	return m, changed
+}
+
+func (s *DefectService) DeleteDefect(ctx context.Context, defectId uint) error {
+	before, err := s.repo.GetDefect(ctx, defectId)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteDefect(ctx, defectId); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+
+	s.audit.Record(ctx, entities.AuditDefectDelete, "defect", defectId, before, nil)
+	return nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+)
+
+// Выгрузка идет курсором по страницам, чтобы не держать всю выборку в памяти
+const (
+	exportBatchSize = 100
+	exportMaxRows   = 50000
+)
+
+var defectExportHeader = []string{
+	"defect_id", "object", "defect_type", "quality_grade", "status", "date",
+	"depth", "length", "width", "vibration", "lat", "lon", "chainage_km", "description",
+}
+
+func validateDefectFilter(f entities.DefectFilter) error {
+	verr := &entities.ValidationError{}
+
+	if f.Sort != "" && !contains(entities.DefectSortFields, f.Sort) {
+		verr.Addf("sort", "unknown field %q", f.Sort)
+	}
+	if f.Cursor != "" {
+		cursor, err := entities.DecodeDefectCursor(f.Cursor)
+		sort := f.Sort
+		if sort == "" {
+			sort = entities.DefectSortDate
+		}
+		if err != nil || cursor.Sort != sort {
+			verr.Add("cursor", "malformed or issued for another sort order")
+		}
+	}
+	for _, status := range f.Statuses {
+		if !contains(entities.DefectStatuses, status) {
+			verr.Addf("status", "unknown status %q", status)
+		}
+	}
+
+	ranges := []struct {
+		field string
+		r     entities.FloatRange
+	}{{"depth", f.Depth}, {"length", f.Length}, {"vibration", f.Vibration}, {"km", f.Chainage}}
+	for _, rg := range ranges {
+		if rg.r.Min != nil && rg.r.Max != nil && *rg.r.Min > *rg.r.Max {
+			verr.Addf(rg.field, "min must not exceed max")
+		}
+	}
+
+	if b := f.BBox; b != nil {
+		if !validCoord(b.MinLat, b.MinLon) || !validCoord(b.MaxLat, b.MaxLon) {
+			verr.Add("bbox", "coordinates out of range")
+		} else if b.MinLat > b.MaxLat || b.MinLon > b.MaxLon {
+			verr.Add("bbox", "expected min_lon,min_lat,max_lon,max_lat")
+		}
+	}
+	if len(f.Polygon) > 0 {
+		if len(f.Polygon) < 3 {
+			verr.Add("polygon", "at least 3 points required")
+		}
+		for _, p := range f.Polygon {
+			if !validCoord(p.Lat, p.Lon) {
+				verr.Add("polygon", "coordinates out of range")
+				break
+			}
+		}
+	}
+
+	if !f.DateFrom.IsZero() && !f.DateTo.IsZero() && f.DateFrom.After(f.DateTo) {
+		verr.Add("date_from", "must not be after date_to")
+	}
+	return verr.Err()
+}
+
+func validCoord(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+func (s *DefectService) ListDefects(ctx context.Context, f entities.DefectFilter) (*entities.DefectPage, error) {
+	if err := validateDefectFilter(f); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, f)
+}
+
+func (s *DefectService) HeatmapPoints(ctx context.Context, f entities.DefectFilter) ([]entities.HeatPoint, error) {
+	if err := validateDefectFilter(f); err != nil {
+		return nil, err
+	}
+	points, err := s.repo.GetHeatmapPoints(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if points == nil {
+		return []entities.HeatPoint{}, nil
+	}
+	return *points, nil
+}
+
+// ExportDefects пишет в w CSV со всеми дефектами по фильтру (в порядке его сортировки).
+// Page и Cursor фильтра игнорируются; выгрузка ограничена exportMaxRows строками.
+func (s *DefectService) ExportDefects(ctx context.Context, f entities.DefectFilter, w io.Writer) error {
+	f.Page, f.Cursor, f.Limit = 0, "", exportBatchSize
+	if err := validateDefectFilter(f); err != nil {
+		return err
+	}
+
+	// BOM — чтобы Excel открыл кириллицу без выбора кодировки
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(defectExportHeader); err != nil {
+		return err
+	}
+
+	for rows := 0; rows < exportMaxRows; {
+		page, err := s.repo.List(ctx, f)
+		if err != nil {
+			return err
+		}
+		for _, d := range page.Data {
+			if err := out.Write(defectRecord(d)); err != nil {
+				return err
+			}
+		}
+		rows += len(page.Data)
+		if page.NextCursor == "" {
+			break
+		}
+		f.Cursor = page.NextCursor
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func defectRecord(d entities.Defect) []string {
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	chainage := ""
+	if d.ChainageKm != nil {
+		chainage = strconv.FormatFloat(*d.ChainageKm, 'f', 3, 64)
+	}
+	return []string{
+		strconv.FormatUint(uint64(d.DefectId), 10),
+		csvText(d.ObjectName),
+		csvText(d.DefectType),
+		csvText(d.QualityGrade),
+		csvText(d.Status),
+		d.Date.Format(time.DateOnly),
+		num(d.Depth), num(d.Length), num(d.Width), num(d.Vibration),
+		num(d.Lat), num(d.Lon),
+		chainage,
+		csvText(d.Description),
+	}
+}
+
+// csvText не дает текстовой ячейке стать формулой в Excel: значение, которое начинается с =, +, -, @
+// (или с табуляции и перевода строки), экранируется апострофом. Числовые колонки не трогаем — им минус нужен.
+func csvText(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
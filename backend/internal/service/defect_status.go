@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+)
+
+var (
+	ErrUnknownStatus     = fmt.Errorf("unknown defect status")
+	ErrInvalidTransition = fmt.Errorf("transition is not allowed")
+)
+
+// validateTransition проверяет обязательные для целевого статуса поля
+func validateTransition(req entities.TransitionRequest) error {
+	verr := &entities.ValidationError{}
+	switch req.To {
+	case entities.DefectTriaged:
+		if req.QualityGradeId == 0 {
+			verr.Addf("quality_grade_id", "required for %s", req.To)
+		}
+	case entities.DefectScheduled:
+		if req.PlannedDate == nil {
+			verr.Addf("planned_date", "required for %s", req.To)
+		}
+		if len(req.EmployeeIds) == 0 {
+			verr.Addf("employee_ids", "required for %s", req.To)
+		}
+	case entities.DefectVerified, entities.DefectRejected:
+		if strings.TrimSpace(req.Comment) == "" {
+			verr.Addf("comment", "required for %s", req.To)
+		}
+	}
+	return verr.Err()
+}
+
+// checkTransitionRefs проверяет, что оценка и исполнители из запроса существуют в справочниках.
+// Без этого Association.Replace в AssignEmployees заведет пустых сотрудников с чужими id.
+func checkTransitionRefs(ctx context.Context, tx *repository.DefectRepository, req entities.TransitionRequest) error {
+	verr := &entities.ValidationError{}
+	switch req.To {
+	case entities.DefectTriaged:
+		ok, err := tx.QualityGradeExists(ctx, req.QualityGradeId)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			verr.Add("quality_grade_id", "quality grade not found")
+		}
+	case entities.DefectScheduled:
+		count, err := tx.CountEmployees(ctx, req.EmployeeIds)
+		if err != nil {
+			return err
+		}
+		if count != int64(len(req.EmployeeIds)) {
+			verr.Add("employee_ids", "unknown employee")
+		}
+	}
+	return verr.Err()
+}
+
+// Transition переводит дефект в новый статус. Проверка перехода, изменение дефекта и запись истории
+// выполняются в одной транзакции под блокировкой строки.
+func (s *DefectService) Transition(ctx context.Context, defectId uint, req entities.TransitionRequest) (*entities.DefectStatusChange, error) {
+	var change *entities.DefectStatusChange
+
+	err := s.repo.Transaction(ctx, func(tx *repository.DefectRepository) error {
+		var err error
+		change, err = applyTransition(ctx, tx, defectId, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, entities.AuditDefectTransition, "defect", defectId,
+		map[string]interface{}{"status": change.FromStatus}, map[string]interface{}{"status": change.ToStatus})
+	return change, nil
+}
+
+// applyTransition — сама смена статуса на переданном (транзакционном) репозитории.
+// Вынесена отдельно, чтобы наряды на работы могли двигать дефекты внутри своей транзакции.
+func applyTransition(ctx context.Context, tx *repository.DefectRepository, defectId uint, req entities.TransitionRequest) (*entities.DefectStatusChange, error) {
+	if _, ok := entities.DefectTransitions[req.To]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStatus, req.To)
+	}
+
+	defect, err := tx.LockDefect(ctx, defectId)
+	if err != nil {
+		return nil, err
+	}
+	if !entities.CanTransition(defect.Status, req.To) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, defect.Status, req.To)
+	}
+	req.EmployeeIds = uniqueIds(req.EmployeeIds)
+	if err := validateTransition(req); err != nil {
+		return nil, err
+	}
+	if err := checkTransitionRefs(ctx, tx, req); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{"status": req.To}
+	details := map[string]interface{}{}
+	switch req.To {
+	case entities.DefectTriaged:
+		fields["quality_grade_id"] = req.QualityGradeId
+		details["quality_grade_id"] = req.QualityGradeId
+	case entities.DefectScheduled:
+		fields["planned_date"] = *req.PlannedDate
+		details["planned_date"] = *req.PlannedDate
+		details["employee_ids"] = req.EmployeeIds
+		if err := tx.AssignEmployees(ctx, defectId, req.EmployeeIds); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.UpdateDefectFields(ctx, defectId, fields); err != nil {
+		return nil, err
+	}
+
+	actor := entities.ActorFromContext(ctx)
+	history := models.DefectStatusHistory{
+		DefectId:   defectId,
+		FromStatus: defect.Status,
+		ToStatus:   req.To,
+		Comment:    req.Comment,
+	}
+	if len(details) > 0 {
+		history.Details = details
+	}
+	if actor.UserId != 0 {
+		history.ChangedBy = &actor.UserId
+	}
+	if actor.ApiKeyId != 0 {
+		history.ChangedByApiKey = &actor.ApiKeyId
+	}
+	if err := tx.AddStatusHistory(ctx, &history); err != nil {
+		return nil, err
+	}
+
+	change := repository.StatusHistoryToEntity(history)
+	return &change, nil
+}
+
+// StatusHistory возвращает историю переходов; GetDefect заодно проверяет доступ к дефекту
+func (s *DefectService) StatusHistory(ctx context.Context, defectId uint) ([]entities.DefectStatusChange, error) {
+	if _, err := s.repo.GetDefect(ctx, defectId); err != nil {
+		return nil, err
+	}
+	return s.repo.ListStatusHistory(ctx, defectId)
+}
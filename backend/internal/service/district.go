@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/pkg/geo"
+)
+
+type DistrictProvider interface {
+	List(ctx context.Context, filter entities.DistrictFilter) ([]entities.DistrictFullInfo, error)
+	GetDistrictInfo(ctx context.Context, districtId uint) (*entities.DistrictFullInfo, error)
+	Boundaries(ctx context.Context, filter entities.DistrictFilter) (json.RawMessage, error)
+	ImportBoundaries(ctx context.Context, data []byte, level string) (*entities.DistrictImport, error)
+}
+
+// DistrictService — административные районы с границами и сводки по ним для карты
+type DistrictService struct {
+	repo  *repository.DistrictRepository
+	audit *AuditService
+}
+
+func NewDistrictService(repo *repository.DistrictRepository, audit *AuditService) *DistrictService {
+	return &DistrictService{
+		repo:  repo,
+		audit: audit,
+	}
+}
+
+func validateDistrictFilter(filter entities.DistrictFilter) error {
+	verr := &entities.ValidationError{}
+	if filter.Level != "" && !contains(entities.DistrictLevels, filter.Level) {
+		verr.Addf("level", "unknown level %q", filter.Level)
+	}
+	return verr.Err()
+}
+
+func (s *DistrictService) List(ctx context.Context, filter entities.DistrictFilter) ([]entities.DistrictFullInfo, error) {
+	if err := validateDistrictFilter(filter); err != nil {
+		return nil, err
+	}
+	districts, err := s.repo.FindDistricts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(districts))
+	for i, d := range districts {
+		ids[i] = d.DistrictId
+	}
+	infos, err := s.repo.GetDistrictInfo(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]entities.DistrictFullInfo, 0, len(districts))
+	for _, d := range districts {
+		info := infos[d.DistrictId]
+		info.District = d
+		list = append(list, *info)
+	}
+	return list, nil
+}
+
+func (s *DistrictService) GetDistrictInfo(ctx context.Context, districtId uint) (*entities.DistrictFullInfo, error) {
+	district, err := s.repo.GetDistrict(ctx, districtId)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := s.repo.GetDistrictInfo(ctx, []uint{districtId})
+	if err != nil {
+		return nil, err
+	}
+	info := infos[districtId]
+	info.District = *district
+	return info, nil
+}
+
+func (s *DistrictService) Boundaries(ctx context.Context, filter entities.DistrictFilter) (json.RawMessage, error) {
+	if err := validateDistrictFilter(filter); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBoundaries(ctx, filter)
+}
+
+// ImportBoundaries загружает границы из GeoJSON. У каждого объекта нужны свойства name и code;
+// level и parent_code необязательны, level по умолчанию берется из запроса.
+// После загрузки все объекты заново привязываются к районам.
+func (s *DistrictService) ImportBoundaries(ctx context.Context, data []byte, level string) (*entities.DistrictImport, error) {
+	verr := &entities.ValidationError{}
+	if level != "" && !contains(entities.DistrictLevels, level) {
+		verr.Addf("level", "unknown level %q", level)
+		return nil, verr
+	}
+
+	features, err := geo.ParseFeatures(data)
+	if err != nil {
+		verr.Add("file", err.Error())
+		return nil, verr
+	}
+
+	boundaries := make([]repository.DistrictBoundary, 0, len(features))
+	seen := make(map[string]bool, len(features))
+	for i, f := range features {
+		field := fmt.Sprintf("features[%d]", i)
+		b := repository.DistrictBoundary{
+			Name:       f.Prop("name", "name_ru", "name_kk"),
+			Code:       f.Prop("code", "id"),
+			Level:      f.Prop("level"),
+			ParentCode: f.Prop("parent_code"),
+			WKT:        geo.PolygonsWKT(f.Polygons),
+		}
+		if b.Level == "" {
+			b.Level = level
+		}
+
+		switch {
+		case b.Name == "":
+			verr.Add(field+".name", "required")
+		case b.Code == "":
+			verr.Add(field+".code", "required")
+		case seen[b.Code]:
+			verr.Addf(field+".code", "duplicate code %q", b.Code)
+		case b.Level != "" && !contains(entities.DistrictLevels, b.Level):
+			verr.Addf(field+".level", "unknown level %q", b.Level)
+		}
+		seen[b.Code] = true
+		boundaries = append(boundaries, b)
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	created, updated, err := s.repo.ImportBoundaries(ctx, boundaries)
+	if err != nil {
+		return nil, err
+	}
+	assigned, err := s.repo.AssignObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entities.DistrictImport{Created: created, Updated: updated, AssignedObjects: assigned}
+	s.audit.Record(ctx, entities.AuditDistrictImport, "district", "import", nil, result)
+	return result, nil
+}
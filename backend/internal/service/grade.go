@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type QualityGradeProvider interface {
+	List(ctx context.Context) ([]entities.QualityGrade, error)
+	Update(ctx context.Context, qualityGradeId uint, in entities.QualityGradeInput) (*entities.QualityGrade, error)
+}
+
+// QualityGradeService ведет каталог оценок. После правки каталога сбрасывается кэш тепловых карт:
+// веса точек берутся из каталога.
+type QualityGradeService struct {
+	repo     *repository.QualityGradeRepository
+	heatmaps *HeatmapService
+	audit    *AuditService
+}
+
+func NewQualityGradeService(repo *repository.QualityGradeRepository, heatmaps *HeatmapService, audit *AuditService) *QualityGradeService {
+	return &QualityGradeService{
+		repo:     repo,
+		heatmaps: heatmaps,
+		audit:    audit,
+	}
+}
+
+func (s *QualityGradeService) List(ctx context.Context) ([]entities.QualityGrade, error) {
+	return s.repo.ListGrades(ctx)
+}
+
+func (s *QualityGradeService) Update(ctx context.Context, qualityGradeId uint, in entities.QualityGradeInput) (*entities.QualityGrade, error) {
+	verr := &entities.ValidationError{}
+	fields := make(map[string]interface{})
+
+	if in.Rank != nil {
+		if *in.Rank < 1 {
+			verr.Add("rank", "must be at least 1")
+		}
+		fields["rank"] = *in.Rank
+	}
+	if in.HeatWeight != nil {
+		if *in.HeatWeight < 0 || *in.HeatWeight > 1 {
+			verr.Add("heat_weight", "must be between 0 and 1")
+		}
+		fields["heat_weight"] = *in.HeatWeight
+	}
+	if in.Color != nil {
+		if !colorPattern.MatchString(*in.Color) {
+			verr.Add("color", "must be a hex colour like #FF9800")
+		}
+		fields["color"] = strings.ToUpper(*in.Color)
+	}
+	if in.LabelRu != nil {
+		fields["label_ru"] = strings.TrimSpace(*in.LabelRu)
+	}
+	if in.LabelEn != nil {
+		fields["label_en"] = strings.TrimSpace(*in.LabelEn)
+	}
+	if in.Critical != nil {
+		fields["critical"] = *in.Critical
+	}
+	if in.Priority != nil {
+		if !contains(entities.Priorities, *in.Priority) {
+			verr.Addf("priority", "must be one of %s", strings.Join(entities.Priorities, ", "))
+		}
+		fields["priority"] = *in.Priority
+	}
+	if len(fields) == 0 {
+		verr.Add("body", "no fields to update")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	before, err := s.repo.GetGrade(ctx, qualityGradeId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateGrade(ctx, qualityGradeId, fields); err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetGrade(ctx, qualityGradeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.heatmaps.Invalidate(ctx); err != nil {
+		log.Printf("quality grades: failed to invalidate heatmap cache: %v", err)
+	}
+	s.audit.Record(ctx, entities.AuditQualityGradeUpdate, "quality_grade", qualityGradeId, before, after)
+	return after, nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+)
+
+const (
+	// criticalDepthRatio — дефект критический, когда глубина достигает 80% толщины стенки
+	criticalDepthRatio = 0.8
+	// criticalDefectDepth — критическая глубина, мм, когда толщина стенки объекта не задана:
+	// 80% от типовой толщины стенки 10 мм
+	criticalDefectDepth = 8.0
+	// maxProjectionYears — дальше прогноз не строим: дефект, который растет так медленно, считаем стабильным.
+	// Заодно time.Duration не переполняется (предел — около 292 лет).
+	maxProjectionYears = 200.0
+	// trackMatchRadius — насколько далеко, м, может лежать повторный замер той же аномалии
+	trackMatchRadius = 10.0
+	// minGrowthRate — рост медленнее, мм/год, считаем погрешностью замеров
+	minGrowthRate = 0.01
+
+	yearDuration = 365.25 * 24 * time.Hour
+)
+
+// growthRate — наклон прямой по методу наименьших квадратов, мм/год. Нулевые значения (параметр не замерялся)
+// пропускаются; для оценки нужны хотя бы два замера в разные даты.
+func growthRate(series []entities.DefectMeasurement, value func(entities.DefectMeasurement) float64) *float64 {
+	var n, sumX, sumY, sumXY, sumXX float64
+	var first time.Time
+	for _, m := range series {
+		v := value(m)
+		if v <= 0 {
+			continue
+		}
+		if first.IsZero() {
+			first = m.MeasuredAt
+		}
+		x := float64(m.MeasuredAt.Sub(first)) / float64(yearDuration)
+		n++
+		sumX += x
+		sumY += v
+		sumXY += x * v
+		sumXX += x * x
+	}
+
+	denom := n*sumXX - sumX*sumX
+	if n < 2 || denom < 1e-9 {
+		return nil
+	}
+	rate := math.Round((n*sumXY-sumX*sumY)/denom*1000) / 1000
+	return &rate
+}
+
+// criticalDepth — критическая глубина для трубы с толщиной стенки wallThickness, мм
+func criticalDepth(wallThickness float64) float64 {
+	if wallThickness > 0 {
+		return criticalDepthRatio * wallThickness
+	}
+	return criticalDefectDepth
+}
+
+// computeGrowth оценивает трек дефекта: скорости роста и дату, когда глубина достигнет критической
+// при сохранении текущей скорости. series должна быть отсортирована по дате замера.
+func computeGrowth(defectId uint, series []entities.DefectMeasurement, criticalDepth float64) entities.DefectGrowth {
+	growth := entities.DefectGrowth{
+		DefectId:      defectId,
+		Status:        entities.GrowthInsufficientData,
+		Measurements:  series,
+		DepthRate:     growthRate(series, func(m entities.DefectMeasurement) float64 { return m.Depth }),
+		LengthRate:    growthRate(series, func(m entities.DefectMeasurement) float64 { return m.Length }),
+		WidthRate:     growthRate(series, func(m entities.DefectMeasurement) float64 { return m.Width }),
+		CriticalDepth: criticalDepth,
+	}
+	if growth.Measurements == nil {
+		growth.Measurements = []entities.DefectMeasurement{}
+	}
+	if len(series) == 0 {
+		return growth
+	}
+
+	last := series[len(series)-1]
+	switch {
+	case last.Depth >= criticalDepth:
+		growth.Status = entities.GrowthCritical
+		reached := last.MeasuredAt
+		growth.ProjectedCriticalDate = &reached
+	case growth.DepthRate == nil:
+		// одного замера мало для оценки
+	case *growth.DepthRate < minGrowthRate:
+		growth.Status = entities.GrowthStable
+	case (criticalDepth-last.Depth) / *growth.DepthRate > maxProjectionYears:
+		// растет, но критической глубины в обозримом будущем не достигнет
+		growth.Status = entities.GrowthStable
+	default:
+		growth.Status = entities.GrowthGrowing
+		years := (criticalDepth - last.Depth) / *growth.DepthRate
+		projected := last.MeasuredAt.Add(time.Duration(years * float64(yearDuration)))
+		growth.ProjectedCriticalDate = &projected
+	}
+	return growth
+}
+
+// Growth — трек дефекта с оценкой скорости роста
+func (s *DefectService) Growth(ctx context.Context, defectId uint) (*entities.DefectGrowth, error) {
+	defect, err := s.repo.GetDefect(ctx, defectId)
+	if err != nil {
+		return nil, err
+	}
+	wall, err := s.repo.WallThickness(ctx, defect.ObjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := s.repo.ListMeasurements(ctx, []uint{defectId})
+	if err != nil {
+		return nil, err
+	}
+	growth := computeGrowth(defectId, series[defectId], criticalDepth(wall))
+	return &growth, nil
+}
+
+// AddMeasurement добавляет повторный замер; если он самый свежий, размеры дефекта обновляются до него
+func (s *DefectService) AddMeasurement(ctx context.Context, defectId uint, in entities.MeasurementInput) (*entities.DefectGrowth, error) {
+	defect, err := s.repo.GetDefect(ctx, defectId)
+	if err != nil {
+		return nil, err
+	}
+
+	verr := &entities.ValidationError{}
+	if in.Depth == nil && in.Length == nil && in.Width == nil {
+		verr.Add("depth", "at least one of depth, length, width is required")
+	}
+	checkDimensions(verr, in.Depth, in.Length, in.Width)
+	measuredAt := time.Now()
+	if in.MeasuredAt != nil {
+		if in.MeasuredAt.After(time.Now()) {
+			verr.Add("measured_at", "must not be in the future")
+		}
+		measuredAt = *in.MeasuredAt
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	model := models.DefectMeasurement{
+		DefectId:     defectId,
+		DiagnosticId: in.DiagnosticId,
+		MeasuredAt:   measuredAt,
+		Depth:        valueOr(in.Depth, defect.Depth),
+		Length:       valueOr(in.Length, defect.Length),
+		Width:        valueOr(in.Width, defect.Width),
+		Source:       entities.MeasurementManual,
+	}
+
+	if err := s.repo.AddMeasurement(ctx, &model); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, entities.AuditDefectMeasure, "defect", defectId, nil, repository.MeasurementToEntity(model))
+	return s.Growth(ctx, defectId)
+}
+
+func valueOr(v *float64, fallback float64) float64 {
+	if v != nil {
+		return *v
+	}
+	return fallback
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+)
+
+func measurement(at time.Time, depth float64) entities.DefectMeasurement {
+	return entities.DefectMeasurement{MeasuredAt: at, Depth: depth}
+}
+
+func TestGrowthRate(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	year := func(n float64) time.Time { return start.Add(time.Duration(n * float64(yearDuration))) }
+	depth := func(m entities.DefectMeasurement) float64 { return m.Depth }
+
+	tests := []struct {
+		name   string
+		series []entities.DefectMeasurement
+		want   *float64
+	}{
+		{"empty", nil, nil},
+		{"single measurement", []entities.DefectMeasurement{measurement(start, 2)}, nil},
+		{"same date", []entities.DefectMeasurement{measurement(start, 2), measurement(start, 3)}, nil},
+		{"linear", []entities.DefectMeasurement{measurement(year(0), 2), measurement(year(1), 2.5), measurement(year(2), 3)}, ptr(0.5)},
+		{"least squares", []entities.DefectMeasurement{measurement(year(0), 1), measurement(year(1), 3), measurement(year(2), 2)}, ptr(0.5)},
+		{"zero skipped", []entities.DefectMeasurement{measurement(year(0), 0), measurement(year(1), 2), measurement(year(3), 3)}, ptr(0.5)},
+		{"shrinking", []entities.DefectMeasurement{measurement(year(0), 3), measurement(year(2), 2)}, ptr(-0.5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := growthRate(tt.series, depth)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("rate = %v, want nil", *got)
+			case tt.want != nil && got == nil:
+				t.Errorf("rate = nil, want %v", *tt.want)
+			case tt.want != nil && *got != *tt.want:
+				t.Errorf("rate = %v, want %v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeGrowth(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := start.Add(2 * yearDuration)
+
+	tests := []struct {
+		name      string
+		series    []entities.DefectMeasurement
+		status    string
+		projected *time.Time
+	}{
+		{"no data", nil, entities.GrowthInsufficientData, nil},
+		{"one measurement", []entities.DefectMeasurement{measurement(start, 2)}, entities.GrowthInsufficientData, nil},
+		{"stable", []entities.DefectMeasurement{measurement(start, 2), measurement(later, 2)}, entities.GrowthStable, nil},
+		{"already critical", []entities.DefectMeasurement{measurement(start, 8.5)}, entities.GrowthCritical, &start},
+		// 1 мм/год, от 4 мм до критических 8 мм — еще 4 года после последнего замера
+		{"growing", []entities.DefectMeasurement{measurement(start, 2), measurement(later, 4)}, entities.GrowthGrowing, timePtr(later.Add(4 * yearDuration))},
+		// 0.02 мм/год от 1 мм до 8 мм — 350 лет, дальше горизонта прогноза: даты нет, а не дата в прошлом
+		{"beyond horizon", []entities.DefectMeasurement{measurement(start, 0.96), measurement(later, 1)}, entities.GrowthStable, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := computeGrowth(1, tt.series, criticalDefectDepth)
+			if g.Status != tt.status {
+				t.Errorf("status = %s, want %s", g.Status, tt.status)
+			}
+			if g.Measurements == nil {
+				t.Error("measurements must not be nil")
+			}
+			switch {
+			case tt.projected == nil && g.ProjectedCriticalDate != nil:
+				t.Errorf("projected = %v, want nil", *g.ProjectedCriticalDate)
+			case tt.projected != nil && g.ProjectedCriticalDate == nil:
+				t.Errorf("projected = nil, want %v", *tt.projected)
+			case tt.projected != nil && !g.ProjectedCriticalDate.Equal(*tt.projected):
+				t.Errorf("projected = %v, want %v", *g.ProjectedCriticalDate, *tt.projected)
+			}
+		})
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func timePtr(v time.Time) *time.Time { return &v }
+
+func TestCriticalDepth(t *testing.T) {
+	tests := []struct {
+		wall, want float64
+	}{
+		{0, criticalDefectDepth},
+		{-1, criticalDefectDepth},
+		{9.525, 7.62},
+		{20, 16},
+	}
+
+	for _, tt := range tests {
+		if got := criticalDepth(tt.wall); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("criticalDepth(%v) = %v, want %v", tt.wall, got, tt.want)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 type HeatmapProvider interface {
 	BuildHeatMap(ctx context.Context) error
 	GetHeatMap(ctx context.Context) (*entities.Heatmap, error)
+	Invalidate(ctx context.Context) error
 }
 
 type HeatmapService struct {
@@ -23,8 +24,20 @@ func NewHeatmapService(redis *storage.RedisStorage, repo *repository.DefectRepos
 	return &HeatmapService{redis: redis, repo: repo}
 }
 
+const heatmapKeyPrefix = "heatmapserv:heatmap:"
+
+// heatmapKey — у каждой области видимости своя закэшированная карта
+func heatmapKey(ctx context.Context) string {
+	return heatmapKeyPrefix + entities.ScopeFromContext(ctx).CacheKey()
+}
+
+// Invalidate сбрасывает закэшированные карты всех областей видимости
+func (s *HeatmapService) Invalidate(ctx context.Context) error {
+	return s.redis.DeletePrefix(ctx, heatmapKeyPrefix)
+}
+
 func (s *HeatmapService) BuildHeatMap(ctx context.Context) error {
-	key := "heatmapserv:heatmap"
+	key := heatmapKey(ctx)
 	heatmap, err := s.repo.PrepareHeatmap(ctx)
 	if err != nil {
 		return err
@@ -35,7 +48,7 @@ func (s *HeatmapService) BuildHeatMap(ctx context.Context) error {
 }
 
 func (s *HeatmapService) GetHeatMap(ctx context.Context) (*entities.Heatmap, error) {
-	key := "heatmapserv:heatmap"
+	key := heatmapKey(ctx)
 	result, err := s.redis.Get(ctx, key)
 	if err == nil {
 		var heatmap entities.Heatmap
@@ -25,7 +25,7 @@ func NewInspectionService(repo *repository.DiagnosticRepository, redis *storage.
 	}
 }
 func (s InspectionService) InspectionsByYears(ctx context.Context, year1, year2, year3 int) (*[]entities.DiagnosticByYear, error) {
-	key := fmt.Sprintf("inspectserv:byyears:%d:%d:%d", year1, year2, year3)
+	key := fmt.Sprintf("inspectserv:byyears:%d:%d:%d:%s", year1, year2, year3, entities.ScopeFromContext(ctx).CacheKey())
 	result, err := s.redis.Get(ctx, key)
 	if err == nil {
 		var stats []entities.DiagnosticByYear
@@ -11,8 +11,11 @@ import (
 
 type ObjectProvider interface {
 	GetObjectInfo(ctx context.Context, objectId uint) (*entities.ObjectFullInfo, error)
-	GetDistrictInfo(ctx context.Context, districtId uint) (*entities.DistrictFullInfo, error)
 	PredictCondition(ctx context.Context, objectId uint) (*entities.ConditionMessage, error)
+	ListObjects(ctx context.Context, f entities.ObjectFilter) ([]entities.ObjectSummary, int64, error)
+	CreateObject(ctx context.Context, in entities.ObjectInput) (*entities.Object, error)
+	UpdateObject(ctx context.Context, objectId uint, in entities.ObjectInput) (*entities.Object, error)
+	DeleteObject(ctx context.Context, objectId uint) error
 }
 
 type ObjectService struct {
@@ -20,14 +23,20 @@ type ObjectService struct {
 	objrepo         *repository.ObjectRepository
 	defrepo         *repository.DefectRepository
 	diagnosticsrepo *repository.DiagnosticRepository
+	pipelines       *repository.PipelineRepository
+	conditions      *ConditionService
+	audit           *AuditService
 }
 
-func NewObjectService(objrepo *repository.ObjectRepository, defrepo *repository.DefectRepository, diagnosticsrepo *repository.DiagnosticRepository, grpcClient *grpc_client.Client) *ObjectService {
+func NewObjectService(objrepo *repository.ObjectRepository, defrepo *repository.DefectRepository, diagnosticsrepo *repository.DiagnosticRepository, pipelines *repository.PipelineRepository, conditions *ConditionService, grpcClient *grpc_client.Client, audit *AuditService) *ObjectService {
 	return &ObjectService{
 		objrepo:         objrepo,
 		defrepo:         defrepo,
 		diagnosticsrepo: diagnosticsrepo,
+		pipelines:       pipelines,
+		conditions:      conditions,
 		grpcClient:      grpcClient,
+		audit:           audit,
 	}
 }
 
@@ -57,17 +66,37 @@ func (s *ObjectService) GetObjectInfo(ctx context.Context, objectId uint) (*enti
 		return nil, err
 	}
 
+	defectIds := make([]uint, 0, len(*defects))
+	for _, d := range *defects {
+		defectIds = append(defectIds, d.DefectId)
+	}
+	series, err := s.defrepo.ListMeasurements(ctx, defectIds)
+	if err != nil {
+		return nil, err
+	}
+	growth := make([]entities.DefectGrowth, 0, len(defectIds))
+	for _, id := range defectIds {
+		growth = append(growth, computeGrowth(id, series[id], criticalDepth(object.Pipe.WallThickness)))
+	}
+
 	info := entities.ObjectFullInfo{
 		Object:      *object,
 		Employees:   *employees,
 		Defect:      *defects,
 		History:     *history,
 		Diagnostics: *diagnostics,
+		Growth:      growth,
 	}
 	return &info, nil
 }
 
 func (s *ObjectService) ExposeAlert(ctx context.Context, objectId uint) (*entities.ConditionMessage, error) {
+	// проверяем, что объект входит в зону ответственности пользователя
+	object, err := s.objrepo.GetObject(ctx, objectId)
+	if err != nil {
+		return nil, err
+	}
+
 	objStat, err := s.objrepo.GetAvgStatistics(ctx, objectId)
 	if err != nil {
 		return nil, err
@@ -88,11 +117,20 @@ func (s *ObjectService) ExposeAlert(ctx context.Context, objectId uint) (*entiti
 		return nil, err
 	}
 
-	return &entities.ConditionMessage{
+	msg := &entities.ConditionMessage{
 		ObjectId:    objectId,
 		Condition:   resp.Class,
 		Probability: resp.Probability,
-	}, nil
+	}
+	// модель отдает проценты, история и зоны риска хранятся долей
+	if err := s.objrepo.AddProbability(ctx, objectId, resp.Probability/100); err != nil {
+		return nil, err
+	}
+	if err := s.conditions.Recompute(ctx, object.PipelineId); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAIPrediction, "object", objectId, nil, msg)
+	return msg, nil
 }
 
 func (s *ObjectService) AutoEmployeeAssign(ctx context.Context, defectId uint, num int) (*[]entities.Employee, error) {
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+const (
+	maxObjectName     = 200
+	maxObjectMaterial = 100
+	minInstallYear    = 1900
+)
+
+func validateObjectFilter(f entities.ObjectFilter) error {
+	verr := &entities.ValidationError{}
+
+	if f.Sort != "" && !contains(entities.ObjectSortFields, f.Sort) {
+		verr.Addf("sort", "unknown field %q", f.Sort)
+	}
+	for _, band := range f.RiskBands {
+		if !contains(entities.RiskBands, band) {
+			verr.Addf("risk", "unknown risk band %q", band)
+		}
+	}
+	if b := f.BBox; b != nil {
+		if !validCoord(b.MinLat, b.MinLon) || !validCoord(b.MaxLat, b.MaxLon) {
+			verr.Add("bbox", "coordinates out of range")
+		} else if b.MinLat > b.MaxLat || b.MinLon > b.MaxLon {
+			verr.Add("bbox", "expected min_lon,min_lat,max_lon,max_lat")
+		}
+	}
+	return verr.Err()
+}
+
+func (s *ObjectService) ListObjects(ctx context.Context, f entities.ObjectFilter) ([]entities.ObjectSummary, int64, error) {
+	if err := validateObjectFilter(f); err != nil {
+		return nil, 0, err
+	}
+	return s.objrepo.ListObjects(ctx, f)
+}
+
+// validate нормализует ввод и проверяет ссылки. Объект можно завести только на трубопровод,
+// назначенный пользователю напрямую: иначе созданный объект сразу выпадет из его области видимости.
+func (s *ObjectService) validate(ctx context.Context, in *entities.ObjectInput, create bool) error {
+	verr := &entities.ValidationError{}
+
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		in.Name = &name
+	}
+	switch {
+	case in.Name == nil && create, in.Name != nil && *in.Name == "":
+		verr.Add("name", "required")
+	case in.Name != nil && len([]rune(*in.Name)) > maxObjectName:
+		verr.Addf("name", "must be at most %d characters", maxObjectName)
+	}
+
+	switch {
+	case in.TypeId == nil && create:
+		verr.Add("type_id", "required")
+	case in.TypeId != nil:
+		ok, err := s.objrepo.ObjectTypeExists(ctx, *in.TypeId)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			verr.Add("type_id", "unknown object type")
+		}
+	}
+
+	switch {
+	case in.PipelineId == nil && create:
+		verr.Add("pipeline_id", "required")
+	case in.PipelineId != nil:
+		pipeline, err := s.pipelines.GetPipeline(ctx, *in.PipelineId)
+		switch {
+		case errors.Is(err, repository.ErrPipelineNotFound):
+			verr.Add("pipeline_id", "unknown pipeline")
+		case err != nil:
+			return err
+		case pipeline.ArchivedAt != nil:
+			verr.Add("pipeline_id", "pipeline is archived")
+		default:
+			scope := entities.ScopeFromContext(ctx)
+			if !scope.All && !containsId(scope.PipelineIds, pipeline.PipelineId) {
+				verr.Add("pipeline_id", "pipeline is not assigned to you")
+			}
+		}
+	}
+
+	if create && (in.Lat == nil || in.Lon == nil) {
+		verr.Add("lat", "lat and lon are required")
+	} else if in.Lat != nil && in.Lon != nil && !validCoord(*in.Lat, *in.Lon) {
+		verr.Add("lat", "coordinates out of range")
+	}
+
+	if in.Material != nil {
+		material := strings.TrimSpace(*in.Material)
+		in.Material = &material
+		if len([]rune(material)) > maxObjectMaterial {
+			verr.Addf("material", "must be at most %d characters", maxObjectMaterial)
+		}
+	}
+	if y := in.InstallationYear; y != nil && *y != 0 && (*y < minInstallYear || *y > time.Now().Year()) {
+		verr.Addf("installation_year", "must be between %d and %d", minInstallYear, time.Now().Year())
+	}
+	return verr.Err()
+}
+
+func containsId(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ObjectService) CreateObject(ctx context.Context, in entities.ObjectInput) (*entities.Object, error) {
+	if err := s.validate(ctx, &in, true); err != nil {
+		return nil, err
+	}
+
+	object := entities.Object{
+		Name:       *in.Name,
+		TypeId:     *in.TypeId,
+		PipelineId: *in.PipelineId,
+		Lat:        *in.Lat,
+		Lon:        *in.Lon,
+	}
+	if in.Material != nil {
+		object.Material = *in.Material
+	}
+	if in.InstallationYear != nil {
+		object.InstallationYear = *in.InstallationYear
+	}
+	if err := s.objrepo.AddObject(ctx, &object); err != nil {
+		return nil, err
+	}
+	if err := s.objrepo.SnapObject(ctx, object.ObjectId); err != nil {
+		return nil, err
+	}
+	if err := s.objrepo.AssignDistrict(ctx, object.ObjectId); err != nil {
+		return nil, err
+	}
+
+	created, err := s.objrepo.GetObject(ctx, object.ObjectId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditObjectCreate, "object", created.ObjectId, nil, created)
+	return created, nil
+}
+
+func (s *ObjectService) UpdateObject(ctx context.Context, objectId uint, in entities.ObjectInput) (*entities.Object, error) {
+	before, err := s.objrepo.GetObject(ctx, objectId)
+	if err != nil {
+		return nil, err
+	}
+	// координаты меняются парой: недостающую берем из текущих
+	if in.Lat != nil && in.Lon == nil {
+		in.Lon = &before.Lon
+	}
+	if in.Lon != nil && in.Lat == nil {
+		in.Lat = &before.Lat
+	}
+	if err := s.validate(ctx, &in, false); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if in.Name != nil {
+		fields["object_name"] = *in.Name
+	}
+	if in.TypeId != nil {
+		fields["object_type_id"] = *in.TypeId
+	}
+	if in.PipelineId != nil {
+		fields["pipeline_id"] = *in.PipelineId
+	}
+	if in.Lat != nil {
+		fields["lat"], fields["lon"] = *in.Lat, *in.Lon
+	}
+	if in.Material != nil {
+		fields["material"] = *in.Material
+	}
+	if in.InstallationYear != nil {
+		fields["installation_year"] = *in.InstallationYear
+	}
+	if len(fields) == 0 {
+		return before, nil
+	}
+	if err := s.objrepo.UpdateObjectFields(ctx, objectId, fields); err != nil {
+		return nil, err
+	}
+	if in.PipelineId != nil || in.Lat != nil {
+		if err := s.objrepo.SnapObject(ctx, objectId); err != nil {
+			return nil, err
+		}
+	}
+	if in.Lat != nil {
+		if err := s.objrepo.AssignDistrict(ctx, objectId); err != nil {
+			return nil, err
+		}
+	}
+
+	after, err := s.objrepo.GetObject(ctx, objectId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditObjectUpdate, "object", objectId, before, after)
+	return after, nil
+}
+
+func (s *ObjectService) DeleteObject(ctx context.Context, objectId uint) error {
+	before, err := s.objrepo.GetObject(ctx, objectId)
+	if err != nil {
+		return err
+	}
+	if err := s.objrepo.DeleteObject(ctx, objectId); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, entities.AuditObjectDelete, "object", objectId, before, nil)
+	return nil
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+)
+
+const (
+	maxPipelineName     = 200
+	minCommissionedYear = 1900
+	maxDesignPressure   = 25.0 // МПа, выше — почти наверняка ошибка единиц
+)
+
+type PipelineProvider interface {
+	List(ctx context.Context, filter entities.PipelineFilter) ([]entities.Pipeline, int64, error)
+	Get(ctx context.Context, pipelineId uint) (*entities.Pipeline, error)
+	Create(ctx context.Context, in entities.PipelineInput) (*entities.Pipeline, error)
+	Update(ctx context.Context, pipelineId uint, in entities.PipelineInput) (*entities.Pipeline, error)
+	Archive(ctx context.Context, pipelineId uint) (*entities.Pipeline, error)
+	Restore(ctx context.Context, pipelineId uint) (*entities.Pipeline, error)
+	SetRoute(ctx context.Context, pipelineId uint, data []byte, format string, startKm float64) (*entities.RouteImport, error)
+	Route(ctx context.Context, pipelineId uint) (*entities.PipelineRoute, error)
+	Locate(ctx context.Context, pipelineId uint, lat, lon float64) (*entities.Chainage, error)
+	ObjectsAlong(ctx context.Context, pipelineId uint, km entities.FloatRange) ([]entities.Object, error)
+}
+
+// PipelineService ведет реестр трубопроводов. Архивный трубопровод скрыт из списка и не редактируется,
+// но его объекты, дефекты и отчеты остаются доступны.
+type PipelineService struct {
+	repo    *repository.PipelineRepository
+	defects *repository.DefectRepository
+	audit   *AuditService
+}
+
+func NewPipelineService(repo *repository.PipelineRepository, defects *repository.DefectRepository, audit *AuditService) *PipelineService {
+	return &PipelineService{
+		repo:    repo,
+		defects: defects,
+		audit:   audit,
+	}
+}
+
+func (s *PipelineService) List(ctx context.Context, filter entities.PipelineFilter) ([]entities.Pipeline, int64, error) {
+	rows, total, err := s.repo.ListPipelines(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pipelines := make([]entities.Pipeline, 0, len(rows))
+	for _, m := range rows {
+		pipeline := repository.PipelineToEntity(m)
+		if err := s.withStats(ctx, &pipeline); err != nil {
+			return nil, 0, err
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+	return pipelines, total, nil
+}
+
+func (s *PipelineService) Get(ctx context.Context, pipelineId uint) (*entities.Pipeline, error) {
+	m, err := s.repo.GetPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := repository.PipelineToEntity(*m)
+	if err := s.withStats(ctx, &pipeline); err != nil {
+		return nil, err
+	}
+	return &pipeline, nil
+}
+
+// withStats дополняет паспорт статистикой по объектам и дефектам в области видимости пользователя
+func (s *PipelineService) withStats(ctx context.Context, pipeline *entities.Pipeline) error {
+	stats, err := s.defects.GetPipelineStats(ctx, pipeline.PipelineId)
+	if err != nil {
+		return err
+	}
+	avg, err := s.defects.GetAvgImportanceByPipeline(ctx, pipeline.PipelineId)
+	if err != nil {
+		return err
+	}
+	pipeline.Stats = stats
+	pipeline.AvgImportance = avg
+	return nil
+}
+
+func (s *PipelineService) validate(ctx context.Context, pipelineId uint, in *entities.PipelineInput, create bool) error {
+	verr := &entities.ValidationError{}
+
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		in.Name = &name
+	}
+	switch {
+	case in.Name == nil && create, in.Name != nil && *in.Name == "":
+		verr.Add("name", "required")
+	case in.Name != nil && len([]rune(*in.Name)) > maxPipelineName:
+		verr.Addf("name", "must be at most %d characters", maxPipelineName)
+	case in.Name != nil:
+		taken, err := s.repo.NameTaken(ctx, *in.Name, pipelineId)
+		if err != nil {
+			return err
+		}
+		if taken {
+			verr.Add("name", "pipeline with this name already exists")
+		}
+	}
+
+	if in.Operator != nil {
+		operator := strings.TrimSpace(*in.Operator)
+		in.Operator = &operator
+	}
+	if in.Product != nil {
+		product := strings.TrimSpace(*in.Product)
+		in.Product = &product
+	}
+	if y := in.CommissioningYear; y != nil && *y != 0 && (*y < minCommissionedYear || *y > time.Now().Year()) {
+		verr.Addf("commissioning_year", "must be between %d and %d", minCommissionedYear, time.Now().Year())
+	}
+	if in.LengthKm != nil && *in.LengthKm < 0 {
+		verr.Add("length_km", "must not be negative")
+	}
+	if p := in.DesignPressure; p != nil && (*p < 0 || *p > maxDesignPressure) {
+		verr.Addf("design_pressure", "must be between 0 and %g MPa", maxDesignPressure)
+	}
+	return verr.Err()
+}
+
+func (s *PipelineService) Create(ctx context.Context, in entities.PipelineInput) (*entities.Pipeline, error) {
+	if err := s.validate(ctx, 0, &in, true); err != nil {
+		return nil, err
+	}
+
+	model := models.Pipeline{Name: *in.Name}
+	if in.Operator != nil {
+		model.Operator = *in.Operator
+	}
+	if in.Product != nil {
+		model.Product = *in.Product
+	}
+	if in.CommissioningYear != nil {
+		model.CommissioningYear = *in.CommissioningYear
+	}
+	if in.LengthKm != nil {
+		model.LengthKm = *in.LengthKm
+	}
+	if in.DesignPressure != nil {
+		model.DesignMaop = *in.DesignPressure
+	}
+	if err := s.repo.CreatePipeline(ctx, &model); err != nil {
+		return nil, err
+	}
+
+	pipeline := repository.PipelineToEntity(model)
+	s.audit.Record(ctx, entities.AuditPipelineCreate, "pipeline", model.PipelineId, nil, pipeline)
+	pipeline.Stats = &entities.PipelineStats{StatusDistribution: map[string]int64{}}
+	return &pipeline, nil
+}
+
+func (s *PipelineService) Update(ctx context.Context, pipelineId uint, in entities.PipelineInput) (*entities.Pipeline, error) {
+	before, err := s.repo.GetPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	if before.ArchivedAt != nil {
+		return nil, repository.ErrPipelineArchived
+	}
+	if err := s.validate(ctx, pipelineId, &in, false); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if in.Name != nil {
+		fields["name"] = *in.Name
+	}
+	if in.Operator != nil {
+		fields["operator"] = *in.Operator
+	}
+	if in.Product != nil {
+		fields["product"] = *in.Product
+	}
+	if in.CommissioningYear != nil {
+		fields["commissioning_year"] = *in.CommissioningYear
+	}
+	if in.LengthKm != nil {
+		fields["length_km"] = *in.LengthKm
+	}
+	if in.DesignPressure != nil {
+		fields["design_maop"] = *in.DesignPressure
+	}
+	if len(fields) > 0 {
+		if err := s.repo.UpdatePipelineFields(ctx, pipelineId, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.finish(ctx, pipelineId, entities.AuditPipelineUpdate, before)
+}
+
+func (s *PipelineService) Archive(ctx context.Context, pipelineId uint) (*entities.Pipeline, error) {
+	before, err := s.repo.GetPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	if before.ArchivedAt != nil {
+		return nil, repository.ErrPipelineArchived
+	}
+	if err := s.repo.UpdatePipelineFields(ctx, pipelineId, map[string]interface{}{"archived_at": time.Now()}); err != nil {
+		return nil, err
+	}
+	return s.finish(ctx, pipelineId, entities.AuditPipelineArchive, before)
+}
+
+func (s *PipelineService) Restore(ctx context.Context, pipelineId uint) (*entities.Pipeline, error) {
+	before, err := s.repo.GetPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	if before.ArchivedAt == nil {
+		return s.Get(ctx, pipelineId)
+	}
+	if err := s.repo.UpdatePipelineFields(ctx, pipelineId, map[string]interface{}{"archived_at": nil}); err != nil {
+		return nil, err
+	}
+	return s.finish(ctx, pipelineId, entities.AuditPipelineRestore, before)
+}
+
+func (s *PipelineService) finish(ctx context.Context, pipelineId uint, action string, before *models.Pipeline) (*entities.Pipeline, error) {
+	after, err := s.Get(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	// в журнал — только паспорт, статистика к изменению не относится
+	passport := *after
+	passport.Stats, passport.AvgImportance = nil, 0
+	s.audit.Record(ctx, action, "pipeline", pipelineId, repository.PipelineToEntity(*before), passport)
+	return after, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/pkg/geo"
+)
+
+// SetRoute загружает трассу из GeoJSON или KML и заново привязывает к ней объекты и дефекты трубопровода.
+// startKm — пикет начала трассы: трасса может начинаться не с нулевого километра.
+func (s *PipelineService) SetRoute(ctx context.Context, pipelineId uint, data []byte, format string, startKm float64) (*entities.RouteImport, error) {
+	before, err := s.repo.GetPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+
+	verr := &entities.ValidationError{}
+	if startKm < 0 {
+		verr.Add("start_km", "must not be negative")
+	}
+	lines, err := geo.ParseLines(data, format)
+	if errors.Is(err, geo.ErrNoGeometry) || errors.Is(err, geo.ErrInvalidGeometry) {
+		verr.Add("file", err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	wkt := geo.LinesWKT(lines)
+	kind, length, err := s.repo.MergeRoute(ctx, wkt)
+	if err != nil {
+		return nil, err
+	}
+	if kind != "LINESTRING" {
+		verr.Add("file", "route segments are not connected into a single line")
+		return nil, verr.Err()
+	}
+
+	if err := s.repo.SetRoute(ctx, pipelineId, wkt, startKm); err != nil {
+		return nil, err
+	}
+	objects, defects, err := s.repo.SnapPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	route, err := s.repo.GetRoute(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, entities.AuditPipelineRoute, "pipeline", pipelineId,
+		map[string]interface{}{"has_route": before.Route != nil, "start_km": before.StartKm},
+		map[string]interface{}{"has_route": true, "start_km": startKm, "length_km": fmt.Sprintf("%.3f", length)})
+	return &entities.RouteImport{Route: *route, SnappedObjects: objects, SnappedDefects: defects}, nil
+}
+
+func (s *PipelineService) Route(ctx context.Context, pipelineId uint) (*entities.PipelineRoute, error) {
+	return s.repo.GetRoute(ctx, pipelineId)
+}
+
+// Locate переводит координаты в пикет трассы
+func (s *PipelineService) Locate(ctx context.Context, pipelineId uint, lat, lon float64) (*entities.Chainage, error) {
+	if !validCoord(lat, lon) {
+		verr := &entities.ValidationError{}
+		verr.Add("lat", "coordinates out of range")
+		return nil, verr.Err()
+	}
+	return s.repo.Locate(ctx, pipelineId, lat, lon)
+}
+
+// ObjectsAlong — объекты по порядку вдоль трассы, при необходимости только в диапазоне пикетов
+func (s *PipelineService) ObjectsAlong(ctx context.Context, pipelineId uint, km entities.FloatRange) ([]entities.Object, error) {
+	if km.Min != nil && km.Max != nil && *km.Min > *km.Max {
+		verr := &entities.ValidationError{}
+		verr.Add("km", "min must not exceed max")
+		return nil, verr.Err()
+	}
+	if _, err := s.repo.GetPipeline(ctx, pipelineId); err != nil {
+		return nil, err
+	}
+	return s.repo.ListObjectsAlong(ctx, pipelineId, km)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,22 +14,31 @@ import (
 )
 
 type ReportService struct {
-	repo     repository.ReportRepo
-	pyClient pb.AnalyticsServiceClient
-	pdfGen   *generators.PDFGenerator
+	repo        repository.ReportRepo
+	pyClient    pb.AnalyticsServiceClient
+	pdfGen      *generators.PDFGenerator
+	assessments *AssessmentService
+	audit       *AuditService
 }
 
-func NewReportService(repo repository.ReportRepo, pyClient pb.AnalyticsServiceClient, pdfGen *generators.PDFGenerator) *ReportService {
+func NewReportService(repo repository.ReportRepo, pyClient pb.AnalyticsServiceClient, pdfGen *generators.PDFGenerator, assessments *AssessmentService, audit *AuditService) *ReportService {
 	return &ReportService{
-		repo:     repo,
-		pyClient: pyClient,
-		pdfGen:   pdfGen,
+		repo:        repo,
+		pyClient:    pyClient,
+		pdfGen:      pdfGen,
+		assessments: assessments,
+		audit:       audit,
 	}
 }
 
 // --- 1. EXECUTIVE REPORT ---
 
 func (s *ReportService) GetExecutiveReport(ctx context.Context, pipelineID uint, dateFrom, dateTo time.Time) (*entities.ExecutiveReport, error) {
+	pipelineName, err := s.repo.GetPipelineName(ctx, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 1. Получение статистики (Total, Critical, Resolved, Inspections)
 	stats, err := s.repo.GetPipelineStats(ctx, pipelineID, dateFrom, dateTo)
 	if err != nil {
@@ -51,9 +61,6 @@ func (s *ReportService) GetExecutiveReport(ctx context.Context, pipelineID uint,
 	}
 
 	// 4. Подготовка данных и вызов Python (AI Analysis)
-	// TODO: Получить реальное имя трубопровода из БД через отдельный метод репозитория
-	pipelineName := "Water Supply Network C"
-
 	grpcDefects := s.mapDefectsToProto(defects)
 
 	pyResp, err := s.pyClient.GenerateExecutiveAnalytics(ctx, &pb.ExecutiveRequest{
@@ -128,6 +135,16 @@ func (s *ReportService) GetSingleDefectReport(ctx context.Context, defectID uint
 		MapImageBase64: base64.StdEncoding.EncodeToString(pyResp.MapImage),
 	}
 
+	// 4. Остаточная прочность; без параметров трубы у объекта раздел просто не выводится
+	assessment, err := s.assessments.Assess(ctx, defectID, "")
+	var verr *entities.ValidationError
+	switch {
+	case err == nil:
+		report.Assessment = assessment
+	case !errors.As(err, &verr):
+		return nil, fmt.Errorf("assessment: %w", err)
+	}
+
 	return report, nil
 }
 
@@ -138,7 +155,15 @@ func (s *ReportService) GenerateExecutivePDF(ctx context.Context, pipelineID uin
 	if err != nil {
 		return nil, err
 	}
-	return s.pdfGen.GenerateExecutive(data)
+	pdf, err := s.pdfGen.GenerateExecutive(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, entities.AuditReportGenerate, "pipeline", pipelineID, nil, map[string]interface{}{
+		"report": "executive", "date_from": dateFrom, "date_to": dateTo,
+	})
+	return pdf, nil
 }
 
 func (s *ReportService) GenerateDefectAnalysisPDF(ctx context.Context, defectId uint) ([]byte, error) {
@@ -146,7 +171,15 @@ func (s *ReportService) GenerateDefectAnalysisPDF(ctx context.Context, defectId
 	if err != nil {
 		return nil, err
 	}
-	return s.pdfGen.GenerateSingleDefect(data)
+	pdf, err := s.pdfGen.GenerateSingleDefect(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, entities.AuditReportGenerate, "defect", defectId, nil, map[string]interface{}{
+		"report": "defect_analysis",
+	})
+	return pdf, nil
 }
 
 func (s *ReportService) mapDefectsToProto(defects []entities.Defect) []*pb.DefectSummary {
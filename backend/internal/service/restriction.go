@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+	"github.com/rwrrioe/integrity/backend/pkg/assessment"
+)
+
+// maopMethod — методика, по которой снижается МДРД участка
+const maopMethod = assessment.MethodModifiedB31G
+
+type RestrictionProvider interface {
+	Impose(ctx context.Context, in entities.RestrictionInput) (*entities.PressureRestriction, error)
+	Lift(ctx context.Context, restrictionId uint, reason string) (*entities.PressureRestriction, error)
+	Get(ctx context.Context, restrictionId uint) (*entities.PressureRestriction, error)
+	List(ctx context.Context, filter entities.RestrictionFilter) ([]entities.PressureRestriction, int64, error)
+	PipelineMaop(ctx context.Context, pipelineId uint) (*entities.PipelineMaop, error)
+	SegmentMaop(ctx context.Context, objectId uint) (*entities.SegmentMaop, error)
+	RestrictedSegments(ctx context.Context) ([]entities.SegmentMaop, error)
+	SetDesignMaop(ctx context.Context, pipelineId uint, maop float64) (*entities.PipelineMaop, error)
+}
+
+// RestrictionService считает допустимое давление участков по открытым дефектам
+// и ведет реестр ограничений давления, введенных вручную
+type RestrictionService struct {
+	repo      *repository.RestrictionRepository
+	pipelines *repository.PipelineRepository
+	defects   *repository.DefectRepository
+	audit     *AuditService
+}
+
+func NewRestrictionService(repo *repository.RestrictionRepository, pipelines *repository.PipelineRepository, defects *repository.DefectRepository, audit *AuditService) *RestrictionService {
+	return &RestrictionService{
+		repo:      repo,
+		pipelines: pipelines,
+		defects:   defects,
+		audit:     audit,
+	}
+}
+
+func (s *RestrictionService) Impose(ctx context.Context, in entities.RestrictionInput) (*entities.PressureRestriction, error) {
+	in.Reason = strings.TrimSpace(in.Reason)
+
+	verr := &entities.ValidationError{}
+	if in.Reason == "" {
+		verr.Add("reason", "required")
+	}
+	if in.MaxPressure <= 0 {
+		verr.Add("max_pressure", "must be positive")
+	}
+	if in.ValidUntil != nil && !in.ValidUntil.After(time.Now()) {
+		verr.Add("valid_until", "must be in the future")
+	}
+
+	pipeline, err := s.pipelines.GetPipeline(ctx, in.PipelineId)
+	switch {
+	case errors.Is(err, repository.ErrPipelineNotFound):
+		verr.Add("pipeline_id", "pipeline not found")
+	case err != nil:
+		return nil, err
+	case pipeline.DesignMaop > 0 && in.MaxPressure >= pipeline.DesignMaop && in.ObjectId == nil:
+		verr.Addf("max_pressure", "must be below the design MAOP %.3g MPa", pipeline.DesignMaop)
+	}
+
+	if in.ObjectId != nil {
+		segments, err := s.repo.ListSegments(ctx, repository.SegmentQuery{ObjectId: *in.ObjectId})
+		if err != nil {
+			return nil, err
+		}
+		if len(segments) == 0 || segments[0].PipelineId != in.PipelineId {
+			verr.Add("object_id", "object not found on this pipeline")
+		} else if design := designMaop(segments[0]); design > 0 && in.MaxPressure >= design {
+			verr.Addf("max_pressure", "must be below the design MAOP %.3g MPa", design)
+		}
+	}
+
+	if in.DefectId != nil {
+		defect, err := s.defects.GetDefect(ctx, *in.DefectId)
+		switch {
+		case errors.Is(err, repository.ErrDefectNotFound):
+			verr.Add("defect_id", "defect not found")
+		case err != nil:
+			return nil, err
+		case in.ObjectId != nil && defect.ObjectId != *in.ObjectId:
+			verr.Add("defect_id", "defect belongs to another object")
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	model := models.PressureRestriction{
+		PipelineId:  in.PipelineId,
+		ObjectId:    in.ObjectId,
+		DefectId:    in.DefectId,
+		MaxPressure: in.MaxPressure,
+		Reason:      in.Reason,
+		ImposedAt:   time.Now(),
+		ValidUntil:  in.ValidUntil,
+	}
+	if actor := entities.ActorFromContext(ctx); actor.UserId != 0 {
+		model.ImposedBy = &actor.UserId
+	}
+	if err := s.repo.CreateRestriction(ctx, &model); err != nil {
+		return nil, err
+	}
+
+	restriction, err := s.repo.GetRestriction(ctx, model.RestrictionId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditRestrictionImpose, "pressure_restriction", restriction.RestrictionId, nil, restriction)
+	return restriction, nil
+}
+
+func (s *RestrictionService) Lift(ctx context.Context, restrictionId uint, reason string) (*entities.PressureRestriction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		verr := &entities.ValidationError{}
+		verr.Add("reason", "required")
+		return nil, verr.Err()
+	}
+
+	before, err := s.repo.GetRestriction(ctx, restrictionId)
+	if err != nil {
+		return nil, err
+	}
+
+	var liftedBy *uint
+	if actor := entities.ActorFromContext(ctx); actor.UserId != 0 {
+		liftedBy = &actor.UserId
+	}
+	if err := s.repo.LiftRestriction(ctx, restrictionId, liftedBy, reason); err != nil {
+		return nil, err
+	}
+
+	after, err := s.repo.GetRestriction(ctx, restrictionId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditRestrictionLift, "pressure_restriction", restrictionId, before, after)
+	return after, nil
+}
+
+func (s *RestrictionService) Get(ctx context.Context, restrictionId uint) (*entities.PressureRestriction, error) {
+	return s.repo.GetRestriction(ctx, restrictionId)
+}
+
+func (s *RestrictionService) List(ctx context.Context, filter entities.RestrictionFilter) ([]entities.PressureRestriction, int64, error) {
+	return s.repo.ListRestrictions(ctx, filter)
+}
+
+func (s *RestrictionService) PipelineMaop(ctx context.Context, pipelineId uint) (*entities.PipelineMaop, error) {
+	pipeline, err := s.pipelines.GetPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := s.segments(ctx, repository.SegmentQuery{PipelineId: pipelineId})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &entities.PipelineMaop{
+		PipelineId:    pipeline.PipelineId,
+		Name:          pipeline.Name,
+		DesignMaop:    pipeline.DesignMaop,
+		EffectiveMaop: pipeline.DesignMaop,
+		Segments:      segments,
+	}
+	// трубопровод целиком держит давление не выше самого слабого участка;
+	// участки без проектного МДРД не считаются
+	known := res.EffectiveMaop > 0
+	for _, seg := range segments {
+		if seg.DesignMaop == 0 {
+			continue
+		}
+		if !known || seg.EffectiveMaop < res.EffectiveMaop {
+			res.EffectiveMaop = seg.EffectiveMaop
+			known = true
+		}
+	}
+	return res, nil
+}
+
+func (s *RestrictionService) SegmentMaop(ctx context.Context, objectId uint) (*entities.SegmentMaop, error) {
+	segments, err := s.segments(ctx, repository.SegmentQuery{ObjectId: objectId})
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, repository.ErrObjectNotFound
+	}
+	return &segments[0], nil
+}
+
+// RestrictedSegments — участки, которые сейчас работают на пониженном давлении
+func (s *RestrictionService) RestrictedSegments(ctx context.Context) ([]entities.SegmentMaop, error) {
+	segments, err := s.segments(ctx, repository.SegmentQuery{OnlyCandidates: true})
+	if err != nil {
+		return nil, err
+	}
+
+	restricted := make([]entities.SegmentMaop, 0, len(segments))
+	for _, seg := range segments {
+		if seg.Restricted() {
+			restricted = append(restricted, seg)
+		}
+	}
+	return restricted, nil
+}
+
+func (s *RestrictionService) SetDesignMaop(ctx context.Context, pipelineId uint, maop float64) (*entities.PipelineMaop, error) {
+	if maop <= 0 {
+		verr := &entities.ValidationError{}
+		verr.Add("design_maop", "must be positive")
+		return nil, verr.Err()
+	}
+
+	before, err := s.pipelines.GetPipeline(ctx, pipelineId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pipelines.SetDesignMaop(ctx, pipelineId, maop); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, entities.AuditPipelineMaop, "pipeline", pipelineId,
+		map[string]interface{}{"design_maop": before.DesignMaop}, map[string]interface{}{"design_maop": maop})
+	return s.PipelineMaop(ctx, pipelineId)
+}
+
+// designMaop — проектное МДРД участка: собственное значение объекта или, если не задано, трубопровода
+func designMaop(object models.Object) float64 {
+	if object.Maop > 0 {
+		return object.Maop
+	}
+	return object.Pipeline.DesignMaop
+}
+
+func (s *RestrictionService) segments(ctx context.Context, query repository.SegmentQuery) ([]entities.SegmentMaop, error) {
+	objects, err := s.repo.ListSegments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	objectIds := make([]uint, 0, len(objects))
+	pipelineIds := make([]uint, 0)
+	for _, o := range objects {
+		objectIds = append(objectIds, o.ObjectId)
+		pipelineIds = append(pipelineIds, o.PipelineId)
+	}
+
+	defects, err := s.repo.ListOpenDefects(ctx, objectIds, true)
+	if err != nil {
+		return nil, err
+	}
+	byObject := make(map[uint][]entities.Defect, len(objects))
+	for _, d := range defects {
+		byObject[d.ObjectId] = append(byObject[d.ObjectId], d)
+	}
+	other, err := s.repo.ListOpenDefects(ctx, objectIds, false)
+	if err != nil {
+		return nil, err
+	}
+	otherByObject := make(map[uint][]uint, len(objects))
+	for _, d := range other {
+		otherByObject[d.ObjectId] = append(otherByObject[d.ObjectId], d.DefectId)
+	}
+
+	restrictions, err := s.repo.ActiveRestrictions(ctx, uniqueIds(pipelineIds))
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]entities.SegmentMaop, 0, len(objects))
+	for _, o := range objects {
+		seg := s.segment(o, byObject[o.ObjectId], otherByObject[o.ObjectId])
+		for _, r := range restrictions {
+			if r.PipelineId != o.PipelineId || (r.ObjectId != nil && *r.ObjectId != o.ObjectId) {
+				continue
+			}
+			seg.Restrictions = append(seg.Restrictions, r)
+			if seg.OperatingLimit == 0 || r.MaxPressure < seg.OperatingLimit {
+				seg.OperatingLimit = r.MaxPressure
+			}
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// segment снижает проектное МДРД участка до минимального безопасного давления его открытых дефектов
+// потери металла. Дефекты, которые нельзя посчитать (не заданы параметры трубы), и дефекты других
+// типов (other — трещины, вмятины) возвращаются в Unassessed и на расчет не влияют.
+func (s *RestrictionService) segment(object models.Object, defects []entities.Defect, other []uint) entities.SegmentMaop {
+	seg := entities.SegmentMaop{
+		ObjectId:     object.ObjectId,
+		ObjectName:   object.ObjectName,
+		PipelineId:   object.PipelineId,
+		PipelineName: object.Pipeline.Name,
+		DesignMaop:   designMaop(object),
+		OpenDefects:  len(defects) + len(other),
+		Unassessed:   append(make([]uint, 0, len(other)), other...),
+		Restrictions: make([]entities.PressureRestriction, 0),
+	}
+	seg.EffectiveMaop = seg.DesignMaop
+
+	pipe := entities.PipeProperties{
+		OuterDiameter: object.OuterDiameter,
+		WallThickness: object.WallThickness,
+		Smys:          object.Smys,
+		Maop:          seg.DesignMaop,
+	}
+	assessable := validatePipe(pipe) == nil
+
+	for _, d := range defects {
+		if !assessable {
+			seg.Unassessed = append(seg.Unassessed, d.DefectId)
+			continue
+		}
+		res, err := evaluate(maopMethod, d, pipe)
+		if err != nil {
+			seg.Unassessed = append(seg.Unassessed, d.DefectId)
+			continue
+		}
+		if res.SafePressure < seg.EffectiveMaop {
+			seg.EffectiveMaop = res.SafePressure
+			id := d.DefectId
+			seg.LimitingDefectId = &id
+		}
+	}
+
+	seg.Derated = seg.DesignMaop > 0 && seg.EffectiveMaop < seg.DesignMaop
+	seg.OperatingLimit = seg.EffectiveMaop
+	return seg
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+const (
+	searchMinQuery     = 2
+	searchMaxQuery     = 200
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+)
+
+type SearchProvider interface {
+	Search(ctx context.Context, q string, types []string, limit int) ([]entities.SearchResult, error)
+}
+
+// SearchService — глобальный поиск: ищет по каждому типу отдельно и сводит выдачу в один рейтинг
+type SearchService struct {
+	repo *repository.SearchRepository
+}
+
+func NewSearchService(repo *repository.SearchRepository) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+func (s *SearchService) Search(ctx context.Context, q string, types []string, limit int) ([]entities.SearchResult, error) {
+	q = strings.TrimSpace(q)
+	if limit <= 0 {
+		limit = searchDefaultLimit
+	}
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+
+	verr := &entities.ValidationError{}
+	if n := utf8.RuneCountInString(q); n < searchMinQuery || n > searchMaxQuery {
+		verr.Addf("q", "must be between %d and %d characters", searchMinQuery, searchMaxQuery)
+	}
+	for _, t := range types {
+		if !contains(entities.SearchTypes, t) {
+			verr.Addf("types", "unknown type %q, expected one of %s", t, strings.Join(entities.SearchTypes, ", "))
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	search := map[string]func(context.Context, string, int) ([]entities.SearchResult, error){
+		entities.SearchDefect:   s.repo.SearchDefects,
+		entities.SearchObject:   s.repo.SearchObjects,
+		entities.SearchPipeline: s.repo.SearchPipelines,
+	}
+
+	results := make([]entities.SearchResult, 0)
+	for _, t := range types {
+		found, err := search[t](ctx, q, limit)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/repository/models"
+)
+
+var (
+	ErrWorkOrderState = fmt.Errorf("work order state does not allow this action")
+	ErrWorkOrderScope = fmt.Errorf("work order includes defects outside your access scope")
+)
+
+type WorkOrderProvider interface {
+	Create(ctx context.Context, in entities.WorkOrderInput) (*entities.WorkOrder, error)
+	Get(ctx context.Context, workOrderId uint) (*entities.WorkOrder, error)
+	List(ctx context.Context, filter entities.WorkOrderFilter) ([]entities.WorkOrder, int64, error)
+	Assign(ctx context.Context, workOrderId uint, in entities.WorkOrderAssignment) (*entities.WorkOrder, error)
+	Start(ctx context.Context, workOrderId uint) (*entities.WorkOrder, error)
+	Complete(ctx context.Context, workOrderId uint, report string) (*entities.WorkOrder, error)
+	Cancel(ctx context.Context, workOrderId uint, reason string) (*entities.WorkOrder, error)
+}
+
+// WorkOrderService ведет наряды на ремонт. Каждое действие с нарядом двигает связанные дефекты
+// по их жизненному циклу в той же транзакции, что и смена статуса наряда.
+type WorkOrderService struct {
+	repo  *repository.WorkOrderRepository
+	audit *AuditService
+}
+
+func NewWorkOrderService(repo *repository.WorkOrderRepository, audit *AuditService) *WorkOrderService {
+	return &WorkOrderService{
+		repo:  repo,
+		audit: audit,
+	}
+}
+
+func (s *WorkOrderService) Create(ctx context.Context, in entities.WorkOrderInput) (*entities.WorkOrder, error) {
+	in.Title = strings.TrimSpace(in.Title)
+	if in.Priority == "" {
+		in.Priority = entities.PriorityMedium
+	}
+
+	verr := &entities.ValidationError{}
+	if in.Title == "" {
+		verr.Add("title", "required")
+	}
+	if !contains(entities.Priorities, in.Priority) {
+		verr.Addf("priority", "must be one of %s", strings.Join(entities.Priorities, ", "))
+	}
+	if in.PlannedStart != nil && in.PlannedFinish != nil && in.PlannedFinish.Before(*in.PlannedStart) {
+		verr.Add("planned_finish", "must not be before planned_start")
+	}
+	if in.MethodId != nil {
+		ok, err := s.repo.MethodExists(ctx, *in.MethodId)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			verr.Add("method_id", "inspection method not found")
+		}
+	}
+	in.DefectIds = uniqueIds(in.DefectIds)
+	if len(in.DefectIds) == 0 {
+		verr.Add("defect_ids", "at least one defect is required")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	var created models.WorkOrder
+	err := s.repo.Transaction(ctx, func(orders *repository.WorkOrderRepository, defects *repository.DefectRepository) error {
+		// в наряд берем только разобранные дефекты: с оценкой, но еще не в ремонте
+		for _, id := range in.DefectIds {
+			defect, err := defects.LockDefect(ctx, id)
+			if errors.Is(err, repository.ErrDefectNotFound) {
+				verr.Addf("defect_ids", "defect %d not found", id)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if defect.Status != entities.DefectTriaged && defect.Status != entities.DefectScheduled {
+				verr.Addf("defect_ids", "defect %d is %s, expected %s or %s", id, defect.Status, entities.DefectTriaged, entities.DefectScheduled)
+			}
+		}
+		// занятость проверяем под блокировкой дефектов: параллельный наряд с тем же дефектом
+		// дождется этой транзакции и увидит уже созданную связь
+		busy, err := orders.DefectsInActiveOrders(ctx, in.DefectIds)
+		if err != nil {
+			return err
+		}
+		for _, id := range busy {
+			verr.Addf("defect_ids", "defect %d already belongs to an active work order", id)
+		}
+		if err := verr.Err(); err != nil {
+			return err
+		}
+
+		created = models.WorkOrder{
+			Title:         in.Title,
+			Description:   in.Description,
+			Status:        entities.WorkOrderPlanned,
+			Priority:      in.Priority,
+			MethodId:      in.MethodId,
+			PlannedStart:  in.PlannedStart,
+			PlannedFinish: in.PlannedFinish,
+		}
+		if actor := entities.ActorFromContext(ctx); actor.UserId != 0 {
+			created.CreatedBy = &actor.UserId
+		}
+		return orders.CreateWorkOrder(ctx, &created, in.DefectIds)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.repo.GetWorkOrder(ctx, created.WorkOrderId)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditWorkOrderCreate, "work_order", order.WorkOrderId, nil, order)
+	return order, nil
+}
+
+func (s *WorkOrderService) Get(ctx context.Context, workOrderId uint) (*entities.WorkOrder, error) {
+	return s.repo.GetWorkOrder(ctx, workOrderId)
+}
+
+func (s *WorkOrderService) List(ctx context.Context, filter entities.WorkOrderFilter) ([]entities.WorkOrder, int64, error) {
+	return s.repo.ListWorkOrders(ctx, filter)
+}
+
+// Assign назначает исполнителей. Разобранные дефекты наряда переходят в Scheduled
+// с плановой датой начала работ и теми же исполнителями.
+func (s *WorkOrderService) Assign(ctx context.Context, workOrderId uint, in entities.WorkOrderAssignment) (*entities.WorkOrder, error) {
+	in.EmployeeIds = uniqueIds(in.EmployeeIds)
+
+	verr := &entities.ValidationError{}
+	if len(in.EmployeeIds) == 0 {
+		verr.Add("employee_ids", "at least one employee is required")
+	} else {
+		count, err := s.repo.CountEmployees(ctx, in.EmployeeIds)
+		if err != nil {
+			return nil, err
+		}
+		if count != int64(len(in.EmployeeIds)) {
+			verr.Add("employee_ids", "unknown employee")
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	var before *models.WorkOrder
+	err := s.repo.Transaction(ctx, func(orders *repository.WorkOrderRepository, defects *repository.DefectRepository) error {
+		order, err := orders.LockWorkOrder(ctx, workOrderId)
+		if err != nil {
+			return err
+		}
+		if order.Status != entities.WorkOrderPlanned && order.Status != entities.WorkOrderAssigned {
+			return fmt.Errorf("%w: cannot assign %s work order", ErrWorkOrderState, order.Status)
+		}
+		before = order
+
+		start, finish := order.PlannedStart, order.PlannedFinish
+		if in.PlannedStart != nil {
+			start = in.PlannedStart
+		}
+		if in.PlannedFinish != nil {
+			finish = in.PlannedFinish
+		}
+		if start == nil {
+			verr.Add("planned_start", "required to schedule the defects")
+		} else if finish != nil && finish.Before(*start) {
+			verr.Add("planned_finish", "must not be before planned_start")
+		}
+		if err := verr.Err(); err != nil {
+			return err
+		}
+
+		fields := map[string]interface{}{
+			"status":         entities.WorkOrderAssigned,
+			"planned_start":  start,
+			"planned_finish": finish,
+		}
+		if err := orders.UpdateWorkOrderFields(ctx, workOrderId, fields); err != nil {
+			return err
+		}
+		if err := orders.SetEmployees(ctx, workOrderId, in.EmployeeIds); err != nil {
+			return err
+		}
+
+		return s.moveDefects(ctx, orders, defects, workOrderId, func(status string) []entities.TransitionRequest {
+			if status == entities.DefectTriaged {
+				return []entities.TransitionRequest{{To: entities.DefectScheduled, PlannedDate: start, EmployeeIds: in.EmployeeIds,
+					Comment: fmt.Sprintf("work order #%d assigned", workOrderId)}}
+			}
+			return nil
+		}, func(defectId uint) error {
+			// уже запланированному дефекту только переназначаем исполнителей, статус не меняется
+			return defects.AssignEmployees(ctx, defectId, in.EmployeeIds)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.finish(ctx, workOrderId, entities.AuditWorkOrderAssign, before)
+}
+
+// Start — бригада приступила к работам: дефекты переходят в InRepair
+func (s *WorkOrderService) Start(ctx context.Context, workOrderId uint) (*entities.WorkOrder, error) {
+	var before *models.WorkOrder
+	err := s.repo.Transaction(ctx, func(orders *repository.WorkOrderRepository, defects *repository.DefectRepository) error {
+		order, err := orders.LockWorkOrder(ctx, workOrderId)
+		if err != nil {
+			return err
+		}
+		if order.Status != entities.WorkOrderAssigned {
+			return fmt.Errorf("%w: cannot start %s work order", ErrWorkOrderState, order.Status)
+		}
+		before = order
+
+		fields := map[string]interface{}{
+			"status":       entities.WorkOrderInProgress,
+			"actual_start": time.Now(),
+		}
+		if err := orders.UpdateWorkOrderFields(ctx, workOrderId, fields); err != nil {
+			return err
+		}
+
+		return s.moveDefects(ctx, orders, defects, workOrderId, func(status string) []entities.TransitionRequest {
+			if status == entities.DefectScheduled {
+				return []entities.TransitionRequest{{To: entities.DefectInRepair, Comment: fmt.Sprintf("work order #%d started", workOrderId)}}
+			}
+			return nil
+		}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.finish(ctx, workOrderId, entities.AuditWorkOrderStart, before)
+}
+
+// Complete закрывает наряд с отчетом о выполненных работах; отремонтированные дефекты
+// уходят на проверку (Verified) с отчетом в качестве комментария
+func (s *WorkOrderService) Complete(ctx context.Context, workOrderId uint, report string) (*entities.WorkOrder, error) {
+	report = strings.TrimSpace(report)
+	if report == "" {
+		verr := &entities.ValidationError{}
+		verr.Add("completion_report", "required")
+		return nil, verr.Err()
+	}
+
+	var before *models.WorkOrder
+	err := s.repo.Transaction(ctx, func(orders *repository.WorkOrderRepository, defects *repository.DefectRepository) error {
+		order, err := orders.LockWorkOrder(ctx, workOrderId)
+		if err != nil {
+			return err
+		}
+		if order.Status != entities.WorkOrderInProgress {
+			return fmt.Errorf("%w: cannot complete %s work order", ErrWorkOrderState, order.Status)
+		}
+		before = order
+
+		fields := map[string]interface{}{
+			"status":            entities.WorkOrderCompleted,
+			"actual_finish":     time.Now(),
+			"completion_report": report,
+		}
+		if err := orders.UpdateWorkOrderFields(ctx, workOrderId, fields); err != nil {
+			return err
+		}
+
+		return s.moveDefects(ctx, orders, defects, workOrderId, func(status string) []entities.TransitionRequest {
+			if status == entities.DefectInRepair {
+				return []entities.TransitionRequest{{To: entities.DefectVerified, Comment: report}}
+			}
+			return nil
+		}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.finish(ctx, workOrderId, entities.AuditWorkOrderComplete, before)
+}
+
+// Cancel отменяет наряд; дефекты, которые он успел запланировать или взять в ремонт,
+// возвращаются в Triaged, чтобы их можно было включить в другой наряд
+func (s *WorkOrderService) Cancel(ctx context.Context, workOrderId uint, reason string) (*entities.WorkOrder, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		verr := &entities.ValidationError{}
+		verr.Add("reason", "required")
+		return nil, verr.Err()
+	}
+
+	var before *models.WorkOrder
+	err := s.repo.Transaction(ctx, func(orders *repository.WorkOrderRepository, defects *repository.DefectRepository) error {
+		order, err := orders.LockWorkOrder(ctx, workOrderId)
+		if err != nil {
+			return err
+		}
+		if order.Status == entities.WorkOrderCompleted || order.Status == entities.WorkOrderCancelled {
+			return fmt.Errorf("%w: cannot cancel %s work order", ErrWorkOrderState, order.Status)
+		}
+		before = order
+
+		fields := map[string]interface{}{
+			"status":        entities.WorkOrderCancelled,
+			"cancel_reason": reason,
+		}
+		if err := orders.UpdateWorkOrderFields(ctx, workOrderId, fields); err != nil {
+			return err
+		}
+
+		comment := fmt.Sprintf("work order #%d cancelled: %s", workOrderId, reason)
+		return s.moveDefects(ctx, orders, defects, workOrderId, func(status string) []entities.TransitionRequest {
+			switch status {
+			case entities.DefectInRepair:
+				return []entities.TransitionRequest{
+					{To: entities.DefectScheduled, Comment: comment},
+					{To: entities.DefectTriaged, Comment: comment},
+				}
+			case entities.DefectScheduled:
+				return []entities.TransitionRequest{{To: entities.DefectTriaged, Comment: comment}}
+			}
+			return nil
+		}, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.finish(ctx, workOrderId, entities.AuditWorkOrderCancel, before)
+}
+
+// moveDefects проводит каждый дефект наряда через переходы, которые plan возвращает для его текущего статуса.
+// Обязательные поля целевого статуса, которых нет в запросе, берутся из самого дефекта.
+// untouched вызывается для дефектов, которым переход не нужен.
+//
+// Наряд виден, если виден хоть один его дефект, но менять его статус можно, только если видны все:
+// иначе пользователь двигал бы чужие дефекты.
+func (s *WorkOrderService) moveDefects(ctx context.Context, orders *repository.WorkOrderRepository, defects *repository.DefectRepository,
+	workOrderId uint, plan func(status string) []entities.TransitionRequest, untouched func(defectId uint) error) error {
+	hidden, err := orders.CountHiddenDefects(ctx, workOrderId)
+	if err != nil {
+		return err
+	}
+	if hidden > 0 {
+		return fmt.Errorf("%w: %d defect(s) of work order #%d", ErrWorkOrderScope, hidden, workOrderId)
+	}
+
+	ids, err := orders.ListDefectIds(ctx, workOrderId)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		defect, err := defects.LockDefect(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		steps := plan(defect.Status)
+		if len(steps) == 0 {
+			if untouched != nil {
+				if err := untouched(id); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for _, req := range steps {
+			if req.To == entities.DefectTriaged && req.QualityGradeId == 0 {
+				req.QualityGradeId = defect.QualityGradeId
+			}
+			if req.To == entities.DefectScheduled && req.PlannedDate == nil {
+				req.PlannedDate = defect.PlannedDate
+				if req.PlannedDate == nil {
+					now := time.Now()
+					req.PlannedDate = &now
+				}
+			}
+			if req.To == entities.DefectScheduled && len(req.EmployeeIds) == 0 {
+				req.EmployeeIds, err = orders.ListEmployeeIds(ctx, workOrderId)
+				if err != nil {
+					return err
+				}
+			}
+			if _, err := applyTransition(ctx, defects, id, req); err != nil {
+				return fmt.Errorf("defect %d: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *WorkOrderService) finish(ctx context.Context, workOrderId uint, action string, before *models.WorkOrder) (*entities.WorkOrder, error) {
+	order, err := s.repo.GetWorkOrder(ctx, workOrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev interface{}
+	if before != nil {
+		b := repository.WorkOrderToEntity(*before)
+		b.Defects, b.Employees = order.Defects, nil
+		prev = b
+	}
+	s.audit.Record(ctx, action, "work_order", workOrderId, prev, order)
+	return order, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueIds(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
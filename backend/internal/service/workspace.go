@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+var ErrNoEmployee = fmt.Errorf("user is not bound to an employee")
+
+type WorkspaceProvider interface {
+	Me(ctx context.Context, userId uint) (*entities.Workspace, error)
+	MyDefects(ctx context.Context, userId uint, page, limit int) ([]entities.Defect, int64, error)
+	MyObjects(ctx context.Context, userId uint, page, limit int) ([]entities.Object, int64, error)
+	MyQueue(ctx context.Context, userId uint) ([]entities.WorkItem, error)
+	MyWorkOrders(ctx context.Context, userId uint) ([]entities.WorkOrder, error)
+}
+
+// WorkspaceService — рабочее место сотрудника: данные, назначенные сотруднику, к которому привязан пользователь
+type WorkspaceService struct {
+	users      *repository.UserRepository
+	employees  *repository.EmployeeRepository
+	workOrders *repository.WorkOrderRepository
+}
+
+func NewWorkspaceService(users *repository.UserRepository, employees *repository.EmployeeRepository, workOrders *repository.WorkOrderRepository) *WorkspaceService {
+	return &WorkspaceService{
+		users:      users,
+		employees:  employees,
+		workOrders: workOrders,
+	}
+}
+
+func (s *WorkspaceService) Me(ctx context.Context, userId uint) (*entities.Workspace, error) {
+	user, err := s.users.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &entities.Workspace{User: *user}
+	if user.EmployeeId != nil {
+		ws.Employee, err = s.employees.GetEmployee(ctx, *user.EmployeeId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ws, nil
+}
+
+func (s *WorkspaceService) employeeId(ctx context.Context, userId uint) (uint, error) {
+	user, err := s.users.GetUser(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	if user.EmployeeId == nil {
+		return 0, ErrNoEmployee
+	}
+	return *user.EmployeeId, nil
+}
+
+func (s *WorkspaceService) MyDefects(ctx context.Context, userId uint, page, limit int) ([]entities.Defect, int64, error) {
+	employeeId, err := s.employeeId(ctx, userId)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.employees.ListDefects(ctx, employeeId, page, limit)
+}
+
+func (s *WorkspaceService) MyObjects(ctx context.Context, userId uint, page, limit int) ([]entities.Object, int64, error) {
+	employeeId, err := s.employeeId(ctx, userId)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.employees.ListObjects(ctx, employeeId, page, limit)
+}
+
+func (s *WorkspaceService) MyQueue(ctx context.Context, userId uint) ([]entities.WorkItem, error) {
+	employeeId, err := s.employeeId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.employees.WorkQueue(ctx, employeeId)
+}
+
+// MyWorkOrders — незавершенные наряды, в которых сотрудник указан исполнителем
+func (s *WorkspaceService) MyWorkOrders(ctx context.Context, userId uint) ([]entities.WorkOrder, error) {
+	employeeId, err := s.employeeId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	orders, _, err := s.workOrders.ListWorkOrders(ctx, entities.WorkOrderFilter{EmployeeId: employeeId, ActiveOnly: true, Page: 1, Limit: 100})
+	return orders, err
+}
@@ -0,0 +1,16 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+var ErrNotFound = fmt.Errorf("blob not found")
+
+// BlobStore хранит содержимое файлов вложений; метаданные лежат в БД
+type BlobStore interface {
+	Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
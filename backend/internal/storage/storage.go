@@ -8,6 +8,9 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrNotFound — ключа нет; отличает отсутствие значения от ошибки самого Redis
+var ErrNotFound = redis.Nil
+
 type RedisStorage struct {
 	ttl    time.Duration
 	сlient *redis.Client
@@ -48,3 +51,80 @@ func (s *RedisStorage) Set(ctx context.Context, key string, value interface{}) e
 
 	return nil
 }
+
+func (s *RedisStorage) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return s.сlient.Set(ctx, key, b, ttl).Err()
+}
+
+// compareAndSwap заменяет значение, только если оно не изменилось с момента чтения
+var compareAndSwap = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+return 0`)
+
+// CompareAndSwap атомарно записывает value с новым ttl, если по ключу все еще лежит old (сырое значение из Get).
+// false — значение успело измениться или ключ удален.
+func (s *RedisStorage) CompareAndSwap(ctx context.Context, key, old string, value interface{}, ttl time.Duration) (bool, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	swapped, err := compareAndSwap.Run(ctx, s.сlient, []string{key}, old, b, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
+
+func (s *RedisStorage) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.сlient.Del(ctx, keys...).Err()
+}
+
+// DeletePrefix удаляет все ключи с префиксом prefix (через SCAN, без блокировки Redis)
+func (s *RedisStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := s.сlient.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return s.Delete(ctx, keys...)
+}
+
+func (s *RedisStorage) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := s.сlient.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// AddToSet добавляет member в множество key и продлевает время жизни множества
+func (s *RedisStorage) AddToSet(ctx context.Context, key, member string, ttl time.Duration) error {
+	pipe := s.сlient.TxPipeline()
+	pipe.SAdd(ctx, key, member)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStorage) SetMembers(ctx context.Context, key string) ([]string, error) {
+	return s.сlient.SMembers(ctx, key).Result()
+}
+
+func (s *RedisStorage) RemoveFromSet(ctx context.Context, key, member string) error {
+	return s.сlient.SRem(ctx, key, member).Err()
+}
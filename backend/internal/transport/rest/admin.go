@@ -0,0 +1,348 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/service"
+)
+
+// GET /admin/users?page=1&limit=20
+func (h *Handler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	users, total, err := h.accessService.ListUsers(c.Request.Context(), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listUsers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": users,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// PUT /admin/users/:id/role
+func (h *Handler) SetUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.accessService.SetUserRole(c.Request.Context(), uint(id), req.Role); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRoleNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "setUserRole"})
+		}
+		return
+	}
+
+	// роль зашита в access-токен — заставляем пользователя перелогиниться
+	if err := h.authService.RevokeUserSessions(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "revokeSessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// POST /admin/users/:id/logout — принудительный выход на всех устройствах
+func (h *Handler) RevokeUserSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.authService.RevokeUserSessions(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "revokeSessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /admin/roles
+func (h *Handler) ListRoles(c *gin.Context) {
+	roles, err := h.accessService.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listRoles"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// GET /admin/permissions
+func (h *Handler) ListPermissions(c *gin.Context) {
+	perms, err := h.accessService.ListPermissions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listPermissions"})
+		return
+	}
+	c.JSON(http.StatusOK, perms)
+}
+
+// PUT /admin/roles/:name/permissions
+func (h *Handler) SetRolePermissions(c *gin.Context) {
+	var req struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.accessService.SetRolePermissions(c.Request.Context(), c.Param("name"), req.Permissions); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRoleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrUnknownPermission):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "setRolePermissions"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /admin/users/:id/assignments
+func (h *Handler) GetUserAssignments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	assignments, err := h.accessService.GetAssignments(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "getAssignments"})
+		return
+	}
+	c.JSON(http.StatusOK, assignments)
+}
+
+// PUT /admin/users/:id/assignments
+func (h *Handler) SetUserAssignments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req entities.UserAssignments
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.accessService.SetAssignments(c.Request.Context(), uint(id), req); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "setAssignments"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /admin/districts
+func (h *Handler) ListDistricts(c *gin.Context) {
+	districts, err := h.accessService.ListDistricts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listDistricts"})
+		return
+	}
+	c.JSON(http.StatusOK, districts)
+}
+
+// POST /admin/districts
+func (h *Handler) AddDistrict(c *gin.Context) {
+	var req entities.District
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	if err := h.accessService.AddDistrict(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "addDistrict"})
+		return
+	}
+	c.JSON(http.StatusCreated, req)
+}
+
+// GET /admin/api-keys
+func (h *Handler) ListApiKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listApiKeys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// POST /admin/api-keys — ключ возвращается в ответе один раз
+func (h *Handler) CreateApiKey(c *gin.Context) {
+	var req struct {
+		Name        string     `json:"name"`
+		Permissions []string   `json:"permissions"`
+		PipelineIds []uint     `json:"pipeline_ids"`
+		ExpiresAt   *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	principal, _ := principalFrom(c)
+	key, secret, err := h.apiKeyService.Create(c.Request.Context(), entities.ApiKey{
+		Name:        req.Name,
+		Permissions: req.Permissions,
+		PipelineIds: req.PipelineIds,
+		ExpiresAt:   req.ExpiresAt,
+		CreatedBy:   principal.UserId,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrApiKeyNameEmpty), errors.Is(err, repository.ErrUnknownPermission),
+			errors.Is(err, repository.ErrUnknownPipeline):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "createApiKey"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"api_key": key, "key": secret})
+}
+
+// POST /admin/api-keys/:id/rotate
+func (h *Handler) RotateApiKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	key, secret, err := h.apiKeyService.Rotate(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrApiKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "rotateApiKey"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_key": key, "key": secret})
+}
+
+// DELETE /admin/api-keys/:id
+func (h *Handler) RevokeApiKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repository.ErrApiKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "revokeApiKey"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /admin/audit?user_id=&api_key_id=&action=&entity_type=&entity_id=&date_from=&date_to=&page=1&limit=50
+func (h *Handler) ListAudit(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	userId, _ := strconv.Atoi(c.Query("user_id"))
+	apiKeyId, _ := strconv.Atoi(c.Query("api_key_id"))
+
+	filter := entities.AuditFilter{
+		ActorUserId:   uint(userId),
+		ActorApiKeyId: uint(apiKeyId),
+		Action:        c.Query("action"),
+		EntityType:    c.Query("entity_type"),
+		EntityId:      c.Query("entity_id"),
+		Page:          page,
+		Limit:         limit,
+	}
+
+	layout := "2006-01-02"
+	if val := c.Query("date_from"); val != "" {
+		filter.DateFrom, _ = time.Parse(layout, val)
+	}
+	if val := c.Query("date_to"); val != "" {
+		if dateTo, err := time.Parse(layout, val); err == nil {
+			// включаем последний день целиком
+			filter.DateTo = dateTo.Add(24 * time.Hour)
+		}
+	}
+
+	entries, total, err := h.auditService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listAudit"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": entries,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// PUT /admin/users/:id/employee {"employee_id": 12} — null отвязывает
+func (h *Handler) BindUserEmployee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req struct {
+		EmployeeId *uint `json:"employee_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.accessService.BindEmployee(c.Request.Context(), uint(id), req.EmployeeId); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound), errors.Is(err, repository.ErrEmployeeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrEmployeeBound):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "bindEmployee"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+func writeAssessmentError(c *gin.Context, err error, fallback string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrDefectNotFound), errors.Is(err, repository.ErrObjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GET /api/defects/:id/assessment?method=b31g|modified_b31g — остаточная прочность и безопасное давление
+func (h *Handler) GetDefectAssessment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	res, err := h.assessmentService.Assess(c.Request.Context(), uint(id), c.Query("method"))
+	if err != nil {
+		writeAssessmentError(c, err, "defectAssessment")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// PUT /api/objects/:id/pipe {"outer_diameter": 610, "wall_thickness": 9.5, "smys": 359, "maop": 7.4}
+func (h *Handler) SetObjectPipe(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req entities.PipeProperties
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	object, err := h.assessmentService.SetPipeProperties(c.Request.Context(), uint(id), req)
+	if err != nil {
+		writeAssessmentError(c, err, "setObjectPipe")
+		return
+	}
+	c.JSON(http.StatusOK, object)
+}
@@ -0,0 +1,179 @@
+package rest
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/service"
+	"github.com/rwrrioe/integrity/backend/internal/storage/blob"
+)
+
+// attachmentReadPermission — право на чтение вложения совпадает с правом на чтение его владельца
+var attachmentReadPermission = map[string]string{
+	entities.AttachmentOwnerDefect:     entities.PermDefectsRead,
+	entities.AttachmentOwnerObject:     entities.PermObjectsRead,
+	entities.AttachmentOwnerDiagnostic: entities.PermObjectsRead,
+}
+
+func writeAttachmentError(c *gin.Context, err error, op string) {
+	switch {
+	case errors.Is(err, repository.ErrAttachmentNotFound), errors.Is(err, repository.ErrAttachmentOwnerNotFound),
+		errors.Is(err, service.ErrNoThumbnail), errors.Is(err, blob.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentEmpty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnsupportedFileType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /api/{defects|objects|diagnostics}/:id/attachments
+func (h *Handler) ListAttachments(ownerType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+
+		attachments, err := h.attachmentService.List(c.Request.Context(), ownerType, uint(id))
+		if err != nil {
+			writeAttachmentError(c, err, "listAttachments")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": attachments})
+	}
+}
+
+// POST /api/{defects|objects|diagnostics}/:id/attachments (multipart/form-data, поле "file")
+func (h *Handler) UploadAttachment(ownerType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+
+		// запас на заголовки multipart; точный предел проверяет сервис
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachmentService.MaxSize()+1<<20)
+		header, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeAttachmentError(c, service.ErrAttachmentTooLarge, "uploadAttachment")
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		defer file.Close()
+
+		attachment, err := h.attachmentService.Upload(c.Request.Context(), ownerType, uint(id),
+			header.Filename, header.Header.Get("Content-Type"), file)
+		if err != nil {
+			writeAttachmentError(c, err, "uploadAttachment")
+			return
+		}
+		c.JSON(http.StatusCreated, attachment)
+	}
+}
+
+// attachmentFor загружает вложение и проверяет право на чтение его владельца
+func (h *Handler) attachmentFor(c *gin.Context) (*entities.Attachment, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	attachment, err := h.attachmentService.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		writeAttachmentError(c, err, "getAttachment")
+		return nil, false
+	}
+
+	principal, _ := principalFrom(c)
+	permission := attachmentReadPermission[attachment.OwnerType]
+	allowed, err := h.accessService.Allowed(c.Request.Context(), principal, permission)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permissions"})
+		return nil, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "permission": permission})
+		return nil, false
+	}
+	return attachment, true
+}
+
+// GET /api/attachments/:id
+func (h *Handler) GetAttachment(c *gin.Context) {
+	attachment, ok := h.attachmentFor(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, attachment)
+}
+
+// GET /api/attachments/:id/download
+func (h *Handler) DownloadAttachment(c *gin.Context) {
+	h.serveAttachment(c, false)
+}
+
+// GET /api/attachments/:id/thumbnail
+func (h *Handler) AttachmentThumbnail(c *gin.Context) {
+	h.serveAttachment(c, true)
+}
+
+func (h *Handler) serveAttachment(c *gin.Context, thumbnail bool) {
+	attachment, ok := h.attachmentFor(c)
+	if !ok {
+		return
+	}
+
+	_, r, err := h.attachmentService.Open(c.Request.Context(), attachment.AttachmentId, thumbnail)
+	if err != nil {
+		writeAttachmentError(c, err, "openAttachment")
+		return
+	}
+	defer r.Close()
+
+	contentType, size, disposition, etag := attachment.ContentType, attachment.Size, "attachment", attachment.Checksum
+	if thumbnail {
+		contentType, size, disposition, etag = "image/jpeg", -1, "inline", attachment.Checksum+"-thumb"
+	}
+	c.DataFromReader(http.StatusOK, size, contentType, r, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=3600",
+		"ETag":                   `"` + etag + `"`,
+	})
+}
+
+// DELETE /api/attachments/:id
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	attachment, ok := h.attachmentFor(c)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.Delete(c.Request.Context(), attachment.AttachmentId); err != nil {
+		writeAttachmentError(c, err, "deleteAttachment")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,297 @@
+package rest
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/service"
+)
+
+const principalKey = "user"
+
+// POST /register
+func (h *Handler) Register(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Name     string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	user, err := h.authService.Register(c.Request.Context(), req.Email, req.Password, req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidEmail), errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "register"})
+		}
+		return
+	}
+
+	if err := h.accountService.SendVerification(c.Request.Context(), user.UserId); err != nil {
+		log.Printf("register: failed to send verification email to %s: %v", user.Email, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "ok", "user": user})
+}
+
+// POST /password/forgot
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "forgotPassword"})
+		return
+	}
+	// ответ одинаковый вне зависимости от того, есть ли такой пользователь
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// POST /password/reset
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.accountService.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTokenInvalid), errors.Is(err, service.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "resetPassword"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /verify?token=... (ссылка из письма), POST /verify {"token": "..."}
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		var req struct {
+			Token string `json:"token"`
+		}
+		_ = c.ShouldBindJSON(&req)
+		token = req.Token
+	}
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(c.Request.Context(), token); err != nil {
+		if errors.Is(err, repository.ErrTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "verifyEmail"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// POST /verify/resend
+func (h *Handler) ResendVerification(c *gin.Context) {
+	principal, _ := principalFrom(c)
+	if err := h.accountService.SendVerification(c.Request.Context(), principal.UserId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "sendVerification"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// POST /login
+func (h *Handler) Login(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	tokens, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// POST /refresh
+func (h *Handler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrSessionRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "refresh"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// POST /logout
+func (h *Handler) Logout(c *gin.Context) {
+	principal, _ := principalFrom(c)
+	if err := h.authService.Logout(c.Request.Context(), principal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// POST /logout/all
+func (h *Handler) LogoutAll(c *gin.Context) {
+	principal, _ := principalFrom(c)
+	if err := h.authService.RevokeUserSessions(c.Request.Context(), principal.UserId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// bearerToken достает токен из X-Token (как шлет фронт) или из Authorization: Bearer.
+// Браузер не умеет ставить заголовки на WebSocket, поэтому для upgrade-запросов токен принимается в ?access_token=.
+func bearerToken(c *gin.Context) string {
+	if token := c.GetHeader("X-Token"); token != "" {
+		return token
+	}
+	auth := c.GetHeader("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		return c.Query("access_token")
+	}
+	return ""
+}
+
+// authenticate принимает либо API-ключ (X-Api-Key), либо access-токен пользователя
+func (h *Handler) authenticate(c *gin.Context) (*entities.Principal, error) {
+	if key := c.GetHeader("X-Api-Key"); key != "" {
+		return h.apiKeyService.Authenticate(c.Request.Context(), key)
+	}
+
+	token := bearerToken(c)
+	if token == "" {
+		return nil, service.ErrInvalidToken
+	}
+	return h.authService.Authenticate(c.Request.Context(), token)
+}
+
+func (h *Handler) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := h.authenticate(c)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrSessionRevoked),
+				errors.Is(err, service.ErrInvalidApiKey):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "auth"})
+			}
+			c.Abort()
+			return
+		}
+
+		scope, err := h.accessService.ResolveScope(c.Request.Context(), *principal)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "scope"})
+			c.Abort()
+			return
+		}
+
+		actor := entities.ActorFromContext(c.Request.Context())
+		actor.UserId, actor.ApiKeyId = principal.UserId, principal.ApiKeyId
+
+		// Область видимости уходит в контекст запроса — ее применяют репозитории, автора — журнал аудита
+		ctx := entities.ContextWithScope(c.Request.Context(), scope)
+		c.Request = c.Request.WithContext(entities.ContextWithActor(ctx, actor))
+		c.Set(principalKey, *principal)
+		c.Next()
+	}
+}
+
+// RequestActor кладет в контекст IP клиента; AuthMiddleware дополняет его пользователем или ключом
+func RequestActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := entities.ContextWithActor(c.Request.Context(), entities.Actor{Ip: c.ClientIP()})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequirePermission пропускает запрос, только если у роли пользователя (или у API-ключа) есть право permission
+func (h *Handler) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := principalFrom(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+
+		allowed, err := h.accessService.Allowed(c.Request.Context(), principal, permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "permissions"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "permission": permission})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func principalFrom(c *gin.Context) (entities.Principal, bool) {
+	raw, exists := c.Get(principalKey)
+	if !exists {
+		return entities.Principal{}, false
+	}
+	principal, ok := raw.(entities.Principal)
+	return principal, ok
+}
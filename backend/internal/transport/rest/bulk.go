@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/service"
+)
+
+// POST /api/defects/bulk — массовая операция над дефектами по списку id или фильтру.
+// dry_run: true — проверить и показать результат по каждому дефекту без сохранения.
+// Если хотя бы один дефект не прошел проверку, ничего не применяется и возвращается 409 с отчетом.
+func (h *Handler) BulkDefects(c *gin.Context) {
+	var req entities.BulkDefectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	// включать дефекты в наряды может только тот, кто ведет наряды
+	if req.Operation == entities.BulkAttachWorkOrder {
+		principal, _ := principalFrom(c)
+		allowed, err := h.accessService.Allowed(c.Request.Context(), principal, entities.PermWorkOrdersManage)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "permissions"})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "permission": entities.PermWorkOrdersManage})
+			return
+		}
+	}
+
+	res, err := h.bulkService.Apply(c.Request.Context(), req)
+	if err != nil {
+		var verr *entities.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			writeValidationError(c, verr)
+		case errors.Is(err, repository.ErrWorkOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrWorkOrderState):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "bulkDefects"})
+		}
+		return
+	}
+
+	if !res.DryRun && !res.Applied {
+		c.JSON(http.StatusConflict, res)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/service"
+	ws_handlers "github.com/rwrrioe/integrity/backend/internal/transport/ws/handlers"
+)
+
+// defectTopic — топик ws_hub, на который подписаны все, у кого открыта карточка дефекта
+func defectTopic(defectId uint) string {
+	return ws_handlers.DefectTopicPrefix + strconv.FormatUint(uint64(defectId), 10)
+}
+
+func writeCommentError(c *gin.Context, err error, op string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrCommentNotFound), errors.Is(err, repository.ErrDefectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCommentForbidden), errors.Is(err, service.ErrCommentNoAuthor):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCommentDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /api/defects/:id/comments?page=1&limit=20
+func (h *Handler) ListComments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	threads, total, err := h.commentService.List(c.Request.Context(), uint(id), page, limit)
+	if err != nil {
+		writeCommentError(c, err, "listComments")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": threads,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// POST /api/defects/:id/comments {"body": "@ivanov посмотри", "parent_id": 1, "is_decision": false}
+func (h *Handler) CreateComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in entities.CommentInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	comment, err := h.commentService.Create(c.Request.Context(), uint(id), in)
+	if err != nil {
+		writeCommentError(c, err, "createComment")
+		return
+	}
+
+	h.hub.Notify(defectTopic(comment.DefectId), entities.CommentEvent{Type: entities.CommentCreated, Comment: *comment})
+	c.JSON(http.StatusCreated, comment)
+}
+
+// PATCH /api/comments/:id {"body": "...", "is_decision": true}
+func (h *Handler) UpdateComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in entities.CommentInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	moderator, ok := h.canModerateComments(c)
+	if !ok {
+		return
+	}
+	comment, err := h.commentService.Update(c.Request.Context(), uint(id), in, moderator)
+	if err != nil {
+		writeCommentError(c, err, "updateComment")
+		return
+	}
+
+	h.hub.Notify(defectTopic(comment.DefectId), entities.CommentEvent{Type: entities.CommentUpdated, Comment: *comment})
+	c.JSON(http.StatusOK, comment)
+}
+
+// DELETE /api/comments/:id
+func (h *Handler) DeleteComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	moderator, ok := h.canModerateComments(c)
+	if !ok {
+		return
+	}
+	comment, err := h.commentService.Delete(c.Request.Context(), uint(id), moderator)
+	if err != nil {
+		writeCommentError(c, err, "deleteComment")
+		return
+	}
+
+	h.hub.Notify(defectTopic(comment.DefectId), entities.CommentEvent{Type: entities.CommentDeleted, Comment: *comment})
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /api/defects/:id/live — WebSocket: события обсуждения дефекта (comment.created/updated/deleted)
+func (h *Handler) DefectLive(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// подписаться можно только на дефект из своей области видимости
+	if _, err := h.defectRepo.GetDefect(c.Request.Context(), uint(id)); err != nil {
+		writeCommentError(c, err, "defectLive")
+		return
+	}
+
+	ws_handlers.NewHandler(h.hub).Subscribe(c, defectTopic(uint(id)))
+}
+
+func (h *Handler) canModerateComments(c *gin.Context) (bool, bool) {
+	principal, _ := principalFrom(c)
+	allowed, err := h.accessService.Allowed(c.Request.Context(), principal, entities.PermCommentsModerate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "permissions"})
+		return false, false
+	}
+	return allowed, true
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+func writeConditionError(c *gin.Context, err error, op string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrPipelineNotFound), errors.Is(err, repository.ErrObjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /api/pipelines/:id/condition?limit=50 — текущий индекс состояния, составляющие и история
+func (h *Handler) GetPipelineCondition(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	report, err := h.conditionService.PipelineCondition(c.Request.Context(), uint(id), limit)
+	if err != nil {
+		writeConditionError(c, err, "pipelineCondition")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GET /api/objects/:id/condition?limit=50
+func (h *Handler) GetObjectCondition(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	report, err := h.conditionService.ObjectCondition(c.Request.Context(), uint(id), limit)
+	if err != nil {
+		writeConditionError(c, err, "objectCondition")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GET /admin/condition-settings
+func (h *Handler) GetConditionSettings(c *gin.Context) {
+	settings, err := h.conditionService.Settings(c.Request.Context())
+	if err != nil {
+		writeConditionError(c, err, "conditionSettings")
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// PUT /admin/condition-settings {"weights": {"severity": 0.35, "density": 0.15, "risk": 0.25, "inspection": 0.15, "sensor": 0.1},
+// "density_per_object": 10, "density_per_km": 2, "inspection_max_age_days": 730, "vibration_limit": 10}
+// Индекс всех трубопроводов пересчитывается сразу.
+func (h *Handler) UpdateConditionSettings(c *gin.Context) {
+	var req entities.ConditionSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	settings, err := h.conditionService.UpdateSettings(c.Request.Context(), req)
+	if err != nil {
+		writeConditionError(c, err, "updateConditionSettings")
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
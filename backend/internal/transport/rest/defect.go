@@ -0,0 +1,219 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/service"
+)
+
+func writeValidationError(c *gin.Context, verr *entities.ValidationError) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "validation failed", "fields": verr.Fields})
+}
+
+// GET /api/defects/:id/transitions — история статусов и доступные переходы
+func (h *Handler) GetDefectTransitions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	defect, err := h.defectRepo.GetDefect(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrDefectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "getDefect"})
+		return
+	}
+
+	history, err := h.defectService.StatusHistory(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "statusHistory"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  defect.Status,
+		"allowed": entities.DefectTransitions[defect.Status],
+		"history": history,
+	})
+}
+
+// POST /api/defects/:id/transitions {"to": "Scheduled", "planned_date": "...", "employee_ids": [1]}
+func (h *Handler) TransitionDefect(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req entities.TransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	change, err := h.defectService.Transition(c.Request.Context(), uint(id), req)
+	if err != nil {
+		var verr *entities.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			writeValidationError(c, verr)
+		case errors.Is(err, repository.ErrDefectNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUnknownStatus):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "transitionDefect"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+func writeDefectError(c *gin.Context, err error, op string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrDefectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrDefectInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /api/defects/export?<фильтр списка> — CSV со всеми дефектами по фильтру
+func (h *Handler) ExportDefects(c *gin.Context) {
+	filter, err := defectFilterFromQuery(c)
+	if err != nil {
+		writeDefectError(c, err, "exportDefects")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.defectService.ExportDefects(c.Request.Context(), filter, &buf); err != nil {
+		writeDefectError(c, err, "exportDefects")
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=defects.csv")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// POST /api/defects
+func (h *Handler) CreateDefect(c *gin.Context) {
+	var req entities.DefectInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	defect, err := h.defectService.CreateDefect(c.Request.Context(), req)
+	if err != nil {
+		writeDefectError(c, err, "createDefect")
+		return
+	}
+	c.JSON(http.StatusCreated, defect)
+}
+
+// PATCH /api/defects/:id — меняются только переданные поля
+func (h *Handler) UpdateDefect(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req entities.DefectInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	defect, err := h.defectService.UpdateDefect(c.Request.Context(), uint(id), req)
+	if err != nil {
+		writeDefectError(c, err, "updateDefect")
+		return
+	}
+	c.JSON(http.StatusOK, defect)
+}
+
+// DELETE /api/defects/:id
+func (h *Handler) DeleteDefect(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.defectService.DeleteDefect(c.Request.Context(), uint(id)); err != nil {
+		writeDefectError(c, err, "deleteDefect")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /api/defects/:id/growth — серия замеров, скорости роста и прогноз критической глубины
+func (h *Handler) GetDefectGrowth(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	growth, err := h.defectService.Growth(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrDefectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "defectGrowth"})
+		return
+	}
+	c.JSON(http.StatusOK, growth)
+}
+
+// POST /api/defects/:id/measurements {"measured_at": "...", "depth": 2.4, "length": 40, "width": 12}
+func (h *Handler) AddDefectMeasurement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in entities.MeasurementInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	growth, err := h.defectService.AddMeasurement(c.Request.Context(), uint(id), in)
+	if err != nil {
+		var verr *entities.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			writeValidationError(c, verr)
+		case errors.Is(err, repository.ErrDefectNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "addMeasurement"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, growth)
+}
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+)
+
+// queryList — значения параметра и повтором (status=New&status=Closed), и через запятую (status=New,Closed)
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func queryIds(c *gin.Context, key string, verr *entities.ValidationError) []uint {
+	var ids []uint
+	for _, v := range queryList(c, key) {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil || id == 0 {
+			verr.Addf(key, "invalid id %q", v)
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
+
+func queryFloat(c *gin.Context, key string, verr *entities.ValidationError) *float64 {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		verr.Add(key, "must be a number")
+		return nil
+	}
+	return &v
+}
+
+func queryRange(c *gin.Context, key string, verr *entities.ValidationError) entities.FloatRange {
+	return entities.FloatRange{
+		Min: queryFloat(c, key+"_min", verr),
+		Max: queryFloat(c, key+"_max", verr),
+	}
+}
+
+// parseCoords разбирает "lon,lat,lon,lat,..." в список чисел
+func parseCoords(raw string) ([]float64, bool) {
+	parts := strings.Split(raw, ",")
+	coords := make([]float64, 0, len(parts))
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, false
+		}
+		coords = append(coords, v)
+	}
+	return coords, true
+}
+
+// queryBBox разбирает bbox=min_lon,min_lat,max_lon,max_lat; nil — параметр не задан
+func queryBBox(c *gin.Context, verr *entities.ValidationError) *entities.BBox {
+	raw := c.Query("bbox")
+	if raw == "" {
+		return nil
+	}
+	coords, ok := parseCoords(raw)
+	if !ok || len(coords) != 4 {
+		verr.Add("bbox", "expected min_lon,min_lat,max_lon,max_lat")
+		return nil
+	}
+	return &entities.BBox{MinLon: coords[0], MinLat: coords[1], MaxLon: coords[2], MaxLat: coords[3]}
+}
+
+// defectFilterFromQuery собирает DefectFilter из query-параметров; общий для списка, карты и выгрузки.
+//
+//	?search=коррозия&pipeline_id=1,2&object_id=5&severity=3,4&status=New,Triaged
+//	&defect_type_id=2&method=MFL&material=Сталь
+//	&bbox=min_lon,min_lat,max_lon,max_lat&polygon=lon,lat,lon,lat,lon,lat
+//	&depth_min=1&depth_max=5&length_min=&vibration_max=&km_min=120&km_max=160
+//	&date_from=2024-01-01&date_to=2024-12-31&sort=-depth&cursor=...&page=1&limit=20
+//
+// Ошибки формата возвращаются как ValidationError; смысловые проверки делает сервис.
+func defectFilterFromQuery(c *gin.Context) (entities.DefectFilter, error) {
+	verr := &entities.ValidationError{}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	f := entities.DefectFilter{
+		Page:          page,
+		Limit:         limit,
+		Cursor:        c.Query("cursor"),
+		Search:        c.Query("search"),
+		PipelineIds:   queryIds(c, "pipeline_id", verr),
+		ObjectIds:     queryIds(c, "object_id", verr),
+		Statuses:      queryList(c, "status"),
+		DefectTypeIds: queryIds(c, "defect_type_id", verr),
+		Methods:       queryList(c, "method"),
+		Materials:     queryList(c, "material"),
+		Depth:         queryRange(c, "depth", verr),
+		Length:        queryRange(c, "length", verr),
+		Vibration:     queryRange(c, "vibration", verr),
+		Chainage:      queryRange(c, "km", verr),
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		f.Desc = strings.HasPrefix(sort, "-")
+		f.Sort = strings.TrimPrefix(sort, "-")
+	}
+
+	for _, v := range queryList(c, "severity") {
+		rank, err := strconv.Atoi(v)
+		if err != nil {
+			verr.Addf("severity", "invalid rank %q", v)
+			continue
+		}
+		f.Severities = append(f.Severities, rank)
+	}
+
+	f.BBox = queryBBox(c, verr)
+	if raw := c.Query("polygon"); raw != "" {
+		coords, ok := parseCoords(raw)
+		if !ok || len(coords)%2 != 0 {
+			verr.Add("polygon", "expected lon,lat pairs")
+		} else {
+			for i := 0; i < len(coords); i += 2 {
+				f.Polygon = append(f.Polygon, entities.GeoPoint{Lon: coords[i], Lat: coords[i+1]})
+			}
+		}
+	}
+
+	layout := "2006-01-02"
+	if val := c.Query("date_from"); val != "" {
+		t, err := time.Parse(layout, val)
+		if err != nil {
+			verr.Add("date_from", "expected YYYY-MM-DD")
+		}
+		f.DateFrom = t
+	}
+	if val := c.Query("date_to"); val != "" {
+		t, err := time.Parse(layout, val)
+		if err != nil {
+			verr.Add("date_to", "expected YYYY-MM-DD")
+		} else {
+			// Добавляем 24 часа, чтобы захватить весь последний день
+			f.DateTo = t.Add(24 * time.Hour)
+		}
+	}
+
+	return f, verr.Err()
+}
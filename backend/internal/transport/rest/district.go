@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/pkg/geo"
+)
+
+func writeDistrictError(c *gin.Context, err error, op string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrDistrictNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+func districtFilterFromQuery(c *gin.Context) (entities.DistrictFilter, error) {
+	filter := entities.DistrictFilter{Level: c.Query("level")}
+	if raw := c.Query("parent_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			verr := &entities.ValidationError{}
+			verr.Add("parent_id", "invalid id")
+			return filter, verr
+		}
+		parentId := uint(id)
+		filter.ParentId = &parentId
+	}
+	return filter, nil
+}
+
+// GET /api/districts?level=oblast&parent_id=1 — районы со сводкой по объектам, дефектам и состоянию
+func (h *Handler) ListDistrictSummaries(c *gin.Context) {
+	filter, err := districtFilterFromQuery(c)
+	if err != nil {
+		writeDistrictError(c, err, "listDistricts")
+		return
+	}
+
+	districts, err := h.districtService.List(c.Request.Context(), filter)
+	if err != nil {
+		writeDistrictError(c, err, "listDistricts")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": districts})
+}
+
+// GET /api/districts/:id
+func (h *Handler) GetDistrictSummary(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	info, err := h.districtService.GetDistrictInfo(c.Request.Context(), uint(id))
+	if err != nil {
+		writeDistrictError(c, err, "getDistrict")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// GET /api/districts/boundaries?level=oblast — границы для карты (GeoJSON FeatureCollection)
+func (h *Handler) GetDistrictBoundaries(c *gin.Context) {
+	filter, err := districtFilterFromQuery(c)
+	if err != nil {
+		writeDistrictError(c, err, "districtBoundaries")
+		return
+	}
+
+	collection, err := h.districtService.Boundaries(c.Request.Context(), filter)
+	if err != nil {
+		writeDistrictError(c, err, "districtBoundaries")
+		return
+	}
+	c.Data(http.StatusOK, "application/geo+json", collection)
+}
+
+// PUT /api/districts/boundaries?level=district — загрузка границ из GeoJSON (файл в поле file или тело запроса).
+// Районы сопоставляются по свойству code; после загрузки объекты заново привязываются к районам.
+func (h *Handler) ImportDistrictBoundaries(c *gin.Context) {
+	data, format, ok := readGeoUpload(c)
+	if !ok {
+		return
+	}
+	if format == "" {
+		format = geo.DetectFormat(data)
+	}
+	if format != geo.FormatGeoJSON {
+		verr := &entities.ValidationError{}
+		verr.Add("format", "only GeoJSON is supported for boundaries")
+		writeValidationError(c, verr)
+		return
+	}
+
+	result, err := h.districtService.ImportBoundaries(c.Request.Context(), data, c.Query("level"))
+	if err != nil {
+		writeDistrictError(c, err, "importDistricts")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+// GET /api/quality-grades — каталог оценок: ранг, вес на карте, цвет, подписи, критичность
+func (h *Handler) ListQualityGrades(c *gin.Context) {
+	grades, err := h.gradeService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listQualityGrades"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": grades})
+}
+
+// PATCH /admin/quality-grades/:id {"rank": 3, "heat_weight": 0.7, "color": "#FF9800", "label_ru": "...", "label_en": "...", "critical": false, "priority": "high"}
+func (h *Handler) UpdateQualityGrade(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req entities.QualityGradeInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	grade, err := h.gradeService.Update(c.Request.Context(), uint(id), req)
+	if err != nil {
+		var verr *entities.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			writeValidationError(c, verr)
+		case errors.Is(err, repository.ErrQualityGradeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "updateQualityGrade"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, grade)
+}
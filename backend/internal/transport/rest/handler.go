@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,133 +18,239 @@ import (
 	"github.com/rwrrioe/integrity/backend/internal/transport/ws/ws_hub"
 )
 
-var jwtSecret = []byte("secretsecret")
-
-var users = map[string]struct {
-	Password string
-	Name     string
-	Role     string
-}{
-	"alice@gmail.com": {Password: "alice123", Name: "Alice", Role: "admin"},
-	"bob@example.com": {Password: "bob123", Name: "bob", Role: "employee"},
-}
-
 type Handler struct {
-	defectService     *service.DefectService
-	defectRepo        *repository.DefectRepository
-	hmapService       *service.HeatmapService
-	objsService       *service.ObjectService
-	inspectionService *service.InspectionService
-	csvService        *service.SCVParser
-	reportService     *service.ReportService
-	hub               *ws_hub.WebSocketHub
-	redis             *storage.RedisStorage
+	defectService      *service.DefectService
+	defectRepo         *repository.DefectRepository
+	hmapService        *service.HeatmapService
+	objsService        *service.ObjectService
+	inspectionService  *service.InspectionService
+	csvService         *service.SCVParser
+	reportService      *service.ReportService
+	authService        *service.AuthService
+	accessService      *service.AccessService
+	accountService     *service.AccountService
+	apiKeyService      *service.ApiKeyService
+	auditService       *service.AuditService
+	workspaceService   *service.WorkspaceService
+	workOrderService   *service.WorkOrderService
+	attachmentService  *service.AttachmentService
+	commentService     *service.CommentService
+	assessmentService  *service.AssessmentService
+	restrictionService *service.RestrictionService
+	gradeService       *service.QualityGradeService
+	searchService      *service.SearchService
+	bulkService        *service.BulkService
+	pipelineService    *service.PipelineService
+	districtService    *service.DistrictService
+	conditionService   *service.ConditionService
+	hub                *ws_hub.WebSocketHub
+	redis              *storage.RedisStorage
 }
 
-func NewHandler(dr *service.DefectService, repo *repository.DefectRepository, hmap *service.HeatmapService, objsService *service.ObjectService, inspectionService *service.InspectionService, csv *service.SCVParser, redis *storage.RedisStorage, rs *service.ReportService, ws *ws_hub.WebSocketHub) *Handler {
+func NewHandler(dr *service.DefectService, repo *repository.DefectRepository, hmap *service.HeatmapService, objsService *service.ObjectService, inspectionService *service.InspectionService, csv *service.SCVParser, redis *storage.RedisStorage, rs *service.ReportService, ws *ws_hub.WebSocketHub, auth *service.AuthService, access *service.AccessService, account *service.AccountService, apiKeys *service.ApiKeyService, audit *service.AuditService, workspace *service.WorkspaceService, workOrders *service.WorkOrderService, attachments *service.AttachmentService, comments *service.CommentService, assessments *service.AssessmentService, restrictions *service.RestrictionService, grades *service.QualityGradeService, search *service.SearchService, bulk *service.BulkService, pipelines *service.PipelineService, districts *service.DistrictService, conditions *service.ConditionService) *Handler {
 	return &Handler{
-		defectService:     dr,
-		inspectionService: inspectionService,
-		defectRepo:        repo,
-		objsService:       objsService,
-		csvService:        csv,
-		reportService:     rs,
-		hub:               ws,
-		hmapService:       hmap,
-	}
-}
-
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		token := c.GetHeader("X-Token")
-		if token == "" {
-			c.JSON(401, gin.H{"error": "unauthorized"})
-			c.Abort()
-			return
-		}
-		user, ok := users[token]
-		if !ok {
-			c.JSON(401, gin.H{"error": "unauthorized"})
-			c.Abort()
-			return
-		}
-		c.Set("user", user)
-		c.Next()
-	}
-}
-
-func AdminOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userRaw, exists := c.Get("user")
-		if !exists {
-			c.JSON(401, gin.H{"error": "unauthorized"})
-			c.Abort()
-			return
-		}
-
-		user := userRaw.(map[string]string)
-		role := user["role"]
-
-		if role != "admin" {
-			c.JSON(403, gin.H{"error": "forbidden, admin only"})
-			c.Abort()
-			return
-		}
-
-		c.Next()
+		defectService:      dr,
+		inspectionService:  inspectionService,
+		defectRepo:         repo,
+		objsService:        objsService,
+		csvService:         csv,
+		reportService:      rs,
+		hub:                ws,
+		hmapService:        hmap,
+		redis:              redis,
+		authService:        auth,
+		accessService:      access,
+		accountService:     account,
+		apiKeyService:      apiKeys,
+		auditService:       audit,
+		workspaceService:   workspace,
+		workOrderService:   workOrders,
+		attachmentService:  attachments,
+		commentService:     comments,
+		assessmentService:  assessments,
+		restrictionService: restrictions,
+		gradeService:       grades,
+		searchService:      search,
+		bulkService:        bulk,
+		pipelineService:    pipelines,
+		districtService:    districts,
+		conditionService:   conditions,
 	}
 }
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	r := gin.Default()
-
-	r.POST("/register", func(c *gin.Context) {
-		var req struct{ Email, Password, Name string }
-		c.BindJSON(&req)
-		users[req.Email] = struct{ Password, Name, Role string }{req.Password, req.Name, "employee"}
-		c.JSON(200, gin.H{"status": "ok"})
-	})
-
-	r.POST("/login", func(c *gin.Context) {
-		var req struct{ Email, Password string }
-		c.BindJSON(&req)
-		user, ok := users[req.Email]
-		if !ok || user.Password != req.Password {
-			c.JSON(401, gin.H{"error": "unauthorized"})
-			return
-		}
-		// просто возвращаем email как токен
-		c.JSON(200, gin.H{"token": req.Email})
-	})
-
-	admin := r.Group("/admin", AuthMiddleware(), AdminOnly())
-	admin.GET("/dashboard", h.GetDashboard)
+	// хендлеры передают *gin.Context как context.Context — нужен доступ к значениям c.Request.Context()
+	r.ContextWithFallback = true
+	r.Use(RequestActor())
+
+	r.POST("/register", h.Register)
+	r.POST("/login", h.Login)
+	r.POST("/refresh", h.Refresh)
+	r.POST("/password/forgot", h.ForgotPassword)
+	r.POST("/password/reset", h.ResetPassword)
+	r.GET("/verify", h.VerifyEmail)
+	r.POST("/verify", h.VerifyEmail)
+
+	session := r.Group("", h.AuthMiddleware())
+	session.POST("/logout", h.Logout)
+	session.POST("/logout/all", h.LogoutAll)
+	session.POST("/verify/resend", h.ResendVerification)
+
+	// Рабочее место сотрудника
+	me := r.Group("/me", h.AuthMiddleware())
+	me.GET("", h.Me)
+	me.GET("/defects", h.MyDefects)
+	me.GET("/objects", h.MyObjects)
+	me.GET("/queue", h.MyQueue)
 
 	// WebSocket
 	wsHandler := ws_handlers.NewHandler(h.hub)
 	r.GET("/ws", wsHandler.WebSocket)
 
-	api := r.Group("/api")
+	admin := r.Group("/admin", h.AuthMiddleware())
+	{
+		admin.GET("/dashboard", h.RequirePermission(entities.PermDashboardRead), h.GetDashboard)
+
+		users := admin.Group("/users", h.RequirePermission(entities.PermUsersManage))
+		users.GET("", h.ListUsers)
+		users.PUT("/:id/role", h.SetUserRole)
+		users.POST("/:id/logout", h.RevokeUserSessions)
+		users.GET("/:id/assignments", h.GetUserAssignments)
+		users.PUT("/:id/assignments", h.SetUserAssignments)
+		users.PUT("/:id/employee", h.BindUserEmployee)
+
+		districts := admin.Group("/districts", h.RequirePermission(entities.PermUsersManage))
+		districts.GET("", h.ListDistricts)
+		districts.POST("", h.AddDistrict)
+
+		roles := admin.Group("", h.RequirePermission(entities.PermRolesManage))
+		roles.GET("/roles", h.ListRoles)
+		roles.GET("/permissions", h.ListPermissions)
+		roles.PUT("/roles/:name/permissions", h.SetRolePermissions)
+
+		keys := admin.Group("/api-keys", h.RequirePermission(entities.PermApiKeysManage))
+		keys.GET("", h.ListApiKeys)
+		keys.POST("", h.CreateApiKey)
+		keys.POST("/:id/rotate", h.RotateApiKey)
+		keys.DELETE("/:id", h.RevokeApiKey)
+
+		admin.GET("/audit", h.RequirePermission(entities.PermAuditRead), h.ListAudit)
+		admin.PATCH("/quality-grades/:id", h.RequirePermission(entities.PermCatalogManage), h.UpdateQualityGrade)
+		admin.GET("/condition-settings", h.RequirePermission(entities.PermCatalogManage), h.GetConditionSettings)
+		admin.PUT("/condition-settings", h.RequirePermission(entities.PermCatalogManage), h.UpdateConditionSettings)
+	}
+
+	api := r.Group("/api", h.AuthMiddleware())
 	{
 		// 1. Dashboard (Сводные данные)
 
+		// Глобальный поиск: типы в выдаче отфильтрованы по правам пользователя
+		api.GET("/search", h.Search)
+
 		// 2. Defects (Списки + Детали)
-		api.GET("/defects", h.ListDefects)
-		api.GET("/defects/:id", h.GetDefectDetail)
+		defects := api.Group("", h.RequirePermission(entities.PermDefectsRead))
+		defects.GET("/defects", h.ListDefects)
+		defects.GET("/defects/export", h.RequirePermission(entities.PermReportsExport), h.ExportDefects)
+		defects.GET("/defects/:id", h.GetDefectDetail)
+		defects.GET("/defects/:id/transitions", h.GetDefectTransitions)
+		defects.GET("/quality-grades", h.ListQualityGrades)
+
+		defectsWrite := defects.Group("", h.RequirePermission(entities.PermDefectsWrite))
+		defectsWrite.POST("/defects", h.CreateDefect)
+		defectsWrite.POST("/defects/bulk", h.BulkDefects)
+		defectsWrite.PATCH("/defects/:id", h.UpdateDefect)
+		defectsWrite.DELETE("/defects/:id", h.DeleteDefect)
+		defectsWrite.POST("/defects/:id/transitions", h.TransitionDefect)
+
+		defects.GET("/defects/:id/growth", h.GetDefectGrowth)
+		defectsWrite.POST("/defects/:id/measurements", h.AddDefectMeasurement)
+		defects.GET("/defects/:id/assessment", h.GetDefectAssessment)
+
+		defects.GET("/defects/:id/comments", h.ListComments)
+		defects.GET("/defects/:id/live", h.DefectLive)
+		defectsWrite.POST("/defects/:id/comments", h.CreateComment)
+		defectsWrite.PATCH("/comments/:id", h.UpdateComment)
+		defectsWrite.DELETE("/comments/:id", h.DeleteComment)
+
+		defects.GET("/defects/:id/attachments", h.ListAttachments(entities.AttachmentOwnerDefect))
+		defects.POST("/defects/:id/attachments", h.RequirePermission(entities.PermAttachmentsWrite), h.UploadAttachment(entities.AttachmentOwnerDefect))
+
+		// Наряды на ремонт
+		workOrders := api.Group("/work-orders")
+		workOrders.GET("", h.RequirePermission(entities.PermWorkOrdersRead), h.ListWorkOrders)
+		workOrders.GET("/:id", h.RequirePermission(entities.PermWorkOrdersRead), h.GetWorkOrder)
+		workOrders.POST("", h.RequirePermission(entities.PermWorkOrdersManage), h.CreateWorkOrder)
+		workOrders.POST("/:id/assign", h.RequirePermission(entities.PermWorkOrdersManage), h.AssignWorkOrder)
+		workOrders.POST("/:id/cancel", h.RequirePermission(entities.PermWorkOrdersManage), h.CancelWorkOrder)
+		workOrders.POST("/:id/start", h.RequirePermission(entities.PermWorkOrdersExecute), h.StartWorkOrder)
+		workOrders.POST("/:id/complete", h.RequirePermission(entities.PermWorkOrdersExecute), h.CompleteWorkOrder)
 
 		// 3. Import
-		api.POST("/import/csv", h.ImportCSV)
+		imports := api.Group("/import", h.RequirePermission(entities.PermImportRun))
+		imports.POST("/csv", h.ImportCSV)
 
 		// 4. Reports
-		api.GET("/reports", h.ExportReport)
-		api.GET("/reports/export", h.ExportReport)
-		api.GET("/pipelines/:id")
+		reports := api.Group("/reports", h.RequirePermission(entities.PermReportsExport))
+		reports.GET("", h.ExportReport)
+		reports.GET("/export", h.ExportReport)
+
+		pipelines := api.Group("/pipelines", h.RequirePermission(entities.PermPipelinesRead))
+		pipelines.GET("", h.ListPipelines)
+		pipelines.GET("/:id", h.GetPipeline)
+		pipelines.POST("", h.RequirePermission(entities.PermPipelinesManage), h.CreatePipeline)
+		pipelines.PATCH("/:id", h.RequirePermission(entities.PermPipelinesManage), h.UpdatePipeline)
+		pipelines.POST("/:id/archive", h.RequirePermission(entities.PermPipelinesManage), h.ArchivePipeline)
+		pipelines.POST("/:id/restore", h.RequirePermission(entities.PermPipelinesManage), h.RestorePipeline)
+		pipelines.GET("/:id/route", h.GetPipelineRoute)
+		pipelines.PUT("/:id/route", h.RequirePermission(entities.PermPipelinesManage), h.SetPipelineRoute)
+		pipelines.GET("/:id/chainage", h.LocateOnPipeline)
+		pipelines.GET("/:id/objects", h.ListPipelineObjects)
+		pipelines.GET("/:id/condition", h.GetPipelineCondition)
+		pipelines.GET("/:id/maop", h.GetPipelineMaop)
+		pipelines.PUT("/:id/maop", h.RequirePermission(entities.PermRestrictionsManage), h.SetPipelineMaop)
+
+		// Реестр ограничений давления
+		restrictions := api.Group("/restrictions", h.RequirePermission(entities.PermPipelinesRead))
+		restrictions.GET("", h.ListRestrictions)
+		restrictions.GET("/segments", h.ListRestrictedSegments)
+		restrictions.GET("/:id", h.GetRestriction)
+		restrictions.POST("", h.RequirePermission(entities.PermRestrictionsManage), h.ImposeRestriction)
+		restrictions.POST("/:id/lift", h.RequirePermission(entities.PermRestrictionsManage), h.LiftRestriction)
 
 		// 5. Actions (Websocket trigger)
-		api.GET("/heatmap", h.GetHeatmap)
-		api.POST("/heatmap", h.GetHeatmapData)
-		api.GET("/objects/:id", h.GetObject)
-		api.POST("objects/:id")
+		defects.GET("/heatmap", h.GetHeatmap)
+		defects.POST("/heatmap", h.GetHeatmapData)
+
+		districts := api.Group("/districts", h.RequirePermission(entities.PermObjectsRead))
+		districts.GET("", h.ListDistrictSummaries)
+		districts.GET("/boundaries", h.GetDistrictBoundaries)
+		districts.PUT("/boundaries", h.RequirePermission(entities.PermDistrictsManage), h.ImportDistrictBoundaries)
+		districts.GET("/:id", h.GetDistrictSummary)
+
+		objects := api.Group("/objects", h.RequirePermission(entities.PermObjectsRead))
+		objects.GET("", h.ListObjects)
+		objects.POST("", h.RequirePermission(entities.PermObjectsWrite), h.CreateObject)
+		objects.GET("/:id", h.GetObject)
+		objects.PATCH("/:id", h.RequirePermission(entities.PermObjectsWrite), h.UpdateObject)
+		objects.DELETE("/:id", h.RequirePermission(entities.PermObjectsWrite), h.DeleteObject)
+		objects.POST("/:id", h.RequirePermission(entities.PermAIRun), h.CallAI)
+		objects.PUT("/:id/pipe", h.RequirePermission(entities.PermObjectsWrite), h.SetObjectPipe)
+		objects.GET("/:id/maop", h.GetObjectMaop)
+		objects.GET("/:id/condition", h.GetObjectCondition)
+		objects.GET("/:id/attachments", h.ListAttachments(entities.AttachmentOwnerObject))
+		objects.POST("/:id/attachments", h.RequirePermission(entities.PermAttachmentsWrite), h.UploadAttachment(entities.AttachmentOwnerObject))
+
+		diagnostics := api.Group("/diagnostics", h.RequirePermission(entities.PermObjectsRead))
+		diagnostics.GET("/:id/attachments", h.ListAttachments(entities.AttachmentOwnerDiagnostic))
+		diagnostics.POST("/:id/attachments", h.RequirePermission(entities.PermAttachmentsWrite), h.UploadAttachment(entities.AttachmentOwnerDiagnostic))
+
+		// Вложения: право на чтение проверяется по владельцу вложения
+		attachments := api.Group("/attachments")
+		attachments.GET("/:id", h.GetAttachment)
+		attachments.GET("/:id/download", h.DownloadAttachment)
+		attachments.GET("/:id/thumbnail", h.AttachmentThumbnail)
+		attachments.DELETE("/:id", h.RequirePermission(entities.PermAttachmentsWrite), h.DeleteAttachment)
 	}
 	return r
 }
@@ -152,10 +259,12 @@ func (h *Handler) InitRoutes() *gin.Engine {
 func (h *Handler) CallAI(c *gin.Context) {
 	id := c.Param("id")
 	idInt, _ := strconv.Atoi(c.Param("id"))
+	// фоновая задача переживает запрос, но сохраняет область видимости и автора для аудита
+	ctx := context.WithoutCancel(c.Request.Context())
 
 	go func() {
 		h.hub.Notify(id, gin.H{"status": "accepted", "id": id})
-		res, err := h.objsService.ExposeAlert(c, uint(idInt))
+		res, err := h.objsService.ExposeAlert(ctx, uint(idInt))
 		if err != nil {
 			h.hub.Notify(id, gin.H{"status": "error", "id": id})
 			return
@@ -163,7 +272,7 @@ func (h *Handler) CallAI(c *gin.Context) {
 		h.hub.Notify(id, gin.H{"prediction": res.Probability, "condition": res.Condition})
 
 		if res.Probability > 70 {
-			resul, err := h.defectService.AutoEmployeeAssign(c, res.ObjectId, 2)
+			resul, err := h.defectService.AutoEmployeeAssign(ctx, res.ObjectId, 2)
 			if err != nil {
 				h.hub.Notify(id, gin.H{"status": "error", "id": id})
 				return
@@ -190,45 +299,6 @@ func (h *Handler) GetObject(c *gin.Context) {
 	})
 }
 
-// --- Implementation ---
-// GET /api/pipelines/:id
-func (h *Handler) GetPipeline(c *gin.Context) {
-	id, _ := strconv.Atoi(c.Param("id"))
-
-	avgImp, err := h.defectRepo.GetAvgImportanceByPipeline(c, uint(id))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "defByYear"})
-		return
-	}
-
-	objs, _, err := h.defectRepo.ListObjectsByPipeline(c, uint(id), 1, 10)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "defByYear"})
-		return
-	}
-
-	defs, _, err := h.defectRepo.ListByPipeline(c, uint(id), 1, 10)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "defByYear"})
-		return
-	}
-
-	stats, err := h.defectRepo.GetPipelineStats(c, uint(id))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "defByYear"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"avg_imp":       avgImp,
-		"objects_count": stats.TotalObjects,
-		"defect_count":  stats.TotalDefects,
-		"distribution":  stats.StatusDistribution,
-		"objects":       objs,
-		"defects":       defs,
-	})
-}
-
 // GET /api/dashboard
 func (h *Handler) GetDashboard(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -258,6 +328,12 @@ func (h *Handler) GetDashboard(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "defByCrit"})
 	}
 
+	condition, err := h.conditionService.Overview(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "condition"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"metrics": gin.H{
 			"top_5":        top5,
@@ -271,29 +347,28 @@ func (h *Handler) GetDashboard(c *gin.Context) {
 			"inspections_by_year":   inspectionsByYear,
 			"inspections_by_method": inspectionsByMethod,
 		},
+		"condition": condition,
 		// Top Risks можно получить отдельным запросом к репо с сортировкой
 	})
 }
 
-// GET /api/defects?page=1&limit=10&search=Corrosion
+// GET /api/defects?page=1&limit=10&search=Corrosion&status=New,Triaged&sort=-depth
+// Параметры фильтра — см. defectFilterFromQuery; для больших выборок вместо page передается cursor из next_cursor.
 func (h *Handler) ListDefects(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	filter := entities.DefectFilter{
-		Page:   page,
-		Limit:  limit,
-		Search: c.Query("search"),
+	filter, err := defectFilterFromQuery(c)
+	if err != nil {
+		writeDefectError(c, err, "listDefects")
+		return
 	}
-	data, total, err := h.defectRepo.List(c.Request.Context(), filter)
+	res, err := h.defectService.ListDefects(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		writeDefectError(c, err, "listDefects")
 		return
 	}
 
 	c.JSON(200, gin.H{
-		"data": data,
-		"meta": gin.H{"total": total, "page": page, "limit": limit},
+		"data": res.Data,
+		"meta": gin.H{"total": res.Total, "page": filter.Page, "limit": filter.Limit, "next_cursor": res.NextCursor},
 	})
 }
 
@@ -305,6 +380,10 @@ func (h *Handler) GetDefectDetail(c *gin.Context) {
 		c.JSON(404, gin.H{"error": "not found"})
 		return
 	}
+	if defect.Growth, err = h.defectService.Growth(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "defectGrowth"})
+		return
+	}
 	c.JSON(200, defect)
 }
 
@@ -330,15 +409,16 @@ func (h *Handler) ImportCSV(c *gin.Context) {
 	uuid := uuid.NewString()
 	redisKey := fmt.Sprintf("import:scv:%s", uuid)
 	h.redis.Set(c, redisKey, b)
+	ctx := context.WithoutCancel(c.Request.Context())
 
 	go func() {
 		h.hub.Notify(uuid, gin.H{"id": uuid, "status": "processing"})
-		err := h.csvService.ImportObjects(c, redisKey)
+		err := h.csvService.ImportObjects(ctx, redisKey)
 		if err != nil {
 			h.hub.Notify(uuid, gin.H{"id": uuid, "status": "error"})
 			return
 		}
-		err = h.csvService.ImportDiagnostics(c, redisKey)
+		err = h.csvService.ImportDiagnostics(ctx, redisKey)
 		if err != nil {
 			h.hub.Notify(uuid, gin.H{"id": uuid, "status": "error"})
 			return
@@ -445,48 +525,18 @@ func (h *Handler) ExportReport(c *gin.Context) {
 	}
 }
 
-// POST /api/heatmap?query
+// POST /api/heatmap?query — точки карты по тому же фильтру, что и список дефектов
 func (h *Handler) GetHeatmapData(c *gin.Context) {
-	// 1. Парсинг ID трубы
-	pipelineID, _ := strconv.Atoi(c.Query("pipeline_id"))
-
-	// 2. Парсинг дат
-	var dateFrom, dateTo time.Time
-	layout := "2006-01-02"
-	if val := c.Query("date_from"); val != "" {
-		dateFrom, _ = time.Parse(layout, val)
-	}
-	if val := c.Query("date_to"); val != "" {
-		dateTo, _ = time.Parse(layout, val)
-		// Добавляем 24 часа, чтобы захватить весь последний день
-		if !dateTo.IsZero() {
-			dateTo = dateTo.Add(24 * time.Hour)
-		}
-	}
-
-	// 3. Парсинг severity (теперь это одно число)
-	severityInt, _ := strconv.Atoi(c.Query("severity"))
-
-	// 4. Сборка фильтра
-	filter := entities.DefectFilter{
-		PipelineID: uint(pipelineID),
-		DateFrom:   dateFrom,
-		DateTo:     dateTo,
-		Severity:   severityInt, // Передаем как uint (0 если не задано)
-	}
-
-	// 5. Получаем данные из репозитория
-	points, err := h.defectRepo.GetHeatmapPoints(c.Request.Context(), filter)
+	filter, err := defectFilterFromQuery(c)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		writeDefectError(c, err, "heatmapData")
 		return
 	}
 
-	// 6. Отдаем JSON
-	// Если points == nil, отдаем пустой массив, чтобы фронт не упал
-	if points == nil {
-		c.JSON(200, []entities.HeatPoint{})
-	} else {
-		c.JSON(200, points)
+	points, err := h.defectService.HeatmapPoints(c.Request.Context(), filter)
+	if err != nil {
+		writeDefectError(c, err, "heatmapData")
+		return
 	}
+	c.JSON(200, points)
 }
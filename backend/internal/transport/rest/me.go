@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/service"
+)
+
+// writeWorkspaceError — общая обработка ошибок для /me
+func writeWorkspaceError(c *gin.Context, err error, op string) {
+	switch {
+	case errors.Is(err, service.ErrNoEmployee), errors.Is(err, repository.ErrUserNotFound),
+		errors.Is(err, repository.ErrEmployeeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /me
+func (h *Handler) Me(c *gin.Context) {
+	principal, _ := principalFrom(c)
+
+	ws, err := h.workspaceService.Me(c.Request.Context(), principal.UserId)
+	if err != nil {
+		writeWorkspaceError(c, err, "me")
+		return
+	}
+	c.JSON(http.StatusOK, ws)
+}
+
+// GET /me/defects?page=1&limit=20
+func (h *Handler) MyDefects(c *gin.Context) {
+	principal, _ := principalFrom(c)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	defects, total, err := h.workspaceService.MyDefects(c.Request.Context(), principal.UserId, page, limit)
+	if err != nil {
+		writeWorkspaceError(c, err, "myDefects")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": defects,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// GET /me/objects?page=1&limit=20
+func (h *Handler) MyObjects(c *gin.Context) {
+	principal, _ := principalFrom(c)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	objects, total, err := h.workspaceService.MyObjects(c.Request.Context(), principal.UserId, page, limit)
+	if err != nil {
+		writeWorkspaceError(c, err, "myObjects")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": objects,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// GET /me/queue — назначенные дефекты и активные наряды сотрудника
+func (h *Handler) MyQueue(c *gin.Context) {
+	principal, _ := principalFrom(c)
+
+	items, err := h.workspaceService.MyQueue(c.Request.Context(), principal.UserId)
+	if err != nil {
+		writeWorkspaceError(c, err, "myQueue")
+		return
+	}
+	if items == nil {
+		items = []entities.WorkItem{}
+	}
+
+	orders, err := h.workspaceService.MyWorkOrders(c.Request.Context(), principal.UserId)
+	if err != nil {
+		writeWorkspaceError(c, err, "myWorkOrders")
+		return
+	}
+	if orders == nil {
+		orders = []entities.WorkOrder{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "work_orders": orders})
+}
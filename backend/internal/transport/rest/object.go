@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+func writeObjectError(c *gin.Context, err error, op string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrObjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrObjectInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /api/objects?pipeline_id=1,2&type_id=3&material=Сталь&risk=high,medium
+// &bbox=min_lon,min_lat,max_lon,max_lat&sort=-probability&page=1&limit=20
+func (h *Handler) ListObjects(c *gin.Context) {
+	verr := &entities.ValidationError{}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filter := entities.ObjectFilter{
+		PipelineIds: queryIds(c, "pipeline_id", verr),
+		TypeIds:     queryIds(c, "type_id", verr),
+		Materials:   queryList(c, "material"),
+		RiskBands:   queryList(c, "risk"),
+		BBox:        queryBBox(c, verr),
+		Page:        page,
+		Limit:       limit,
+	}
+	if sort := c.Query("sort"); sort != "" {
+		filter.Desc = strings.HasPrefix(sort, "-")
+		filter.Sort = strings.TrimPrefix(sort, "-")
+	}
+	if err := verr.Err(); err != nil {
+		writeObjectError(c, err, "listObjects")
+		return
+	}
+
+	objects, total, err := h.objsService.ListObjects(c.Request.Context(), filter)
+	if err != nil {
+		writeObjectError(c, err, "listObjects")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": objects,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// POST /api/objects
+func (h *Handler) CreateObject(c *gin.Context) {
+	var req entities.ObjectInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	object, err := h.objsService.CreateObject(c.Request.Context(), req)
+	if err != nil {
+		writeObjectError(c, err, "createObject")
+		return
+	}
+	c.JSON(http.StatusCreated, object)
+}
+
+// PATCH /api/objects/:id
+func (h *Handler) UpdateObject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req entities.ObjectInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	object, err := h.objsService.UpdateObject(c.Request.Context(), uint(id), req)
+	if err != nil {
+		writeObjectError(c, err, "updateObject")
+		return
+	}
+	c.JSON(http.StatusOK, object)
+}
+
+// DELETE /api/objects/:id — только для объектов без дефектов, диагностик и прочих связанных записей
+func (h *Handler) DeleteObject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.objsService.DeleteObject(c.Request.Context(), uint(id)); err != nil {
+		writeObjectError(c, err, "deleteObject")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,291 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/pkg/geo"
+)
+
+func writePipelineError(c *gin.Context, err error, op string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrPipelineNotFound), errors.Is(err, repository.ErrPipelineNoRoute):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrPipelineArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /api/pipelines?search=MT&operator=&product=&archived=true&page=1&limit=20
+func (h *Handler) ListPipelines(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	archived, _ := strconv.ParseBool(c.Query("archived"))
+
+	filter := entities.PipelineFilter{
+		Search:          c.Query("search"),
+		Operator:        c.Query("operator"),
+		Product:         c.Query("product"),
+		IncludeArchived: archived,
+		Page:            page,
+		Limit:           limit,
+	}
+
+	pipelines, total, err := h.pipelineService.List(c.Request.Context(), filter)
+	if err != nil {
+		writePipelineError(c, err, "listPipelines")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": pipelines,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// GET /api/pipelines/:id — паспорт, статистика и первые объекты и дефекты трубопровода
+func (h *Handler) GetPipeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	pipeline, err := h.pipelineService.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		writePipelineError(c, err, "getPipeline")
+		return
+	}
+
+	objs, _, err := h.defectRepo.ListObjectsByPipeline(c.Request.Context(), uint(id), 1, 10)
+	if err != nil {
+		writePipelineError(c, err, "getPipeline")
+		return
+	}
+
+	defs, _, err := h.defectRepo.ListByPipeline(c.Request.Context(), uint(id), 1, 10)
+	if err != nil {
+		writePipelineError(c, err, "getPipeline")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pipeline":      pipeline,
+		"avg_imp":       pipeline.AvgImportance,
+		"objects_count": pipeline.Stats.TotalObjects,
+		"defect_count":  pipeline.Stats.TotalDefects,
+		"distribution":  pipeline.Stats.StatusDistribution,
+		"objects":       objs,
+		"defects":       defs,
+	})
+}
+
+// POST /api/pipelines
+func (h *Handler) CreatePipeline(c *gin.Context) {
+	var req entities.PipelineInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	pipeline, err := h.pipelineService.Create(c.Request.Context(), req)
+	if err != nil {
+		writePipelineError(c, err, "createPipeline")
+		return
+	}
+	c.JSON(http.StatusCreated, pipeline)
+}
+
+// PATCH /api/pipelines/:id
+func (h *Handler) UpdatePipeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req entities.PipelineInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	pipeline, err := h.pipelineService.Update(c.Request.Context(), uint(id), req)
+	if err != nil {
+		writePipelineError(c, err, "updatePipeline")
+		return
+	}
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// POST /api/pipelines/:id/archive
+func (h *Handler) ArchivePipeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	pipeline, err := h.pipelineService.Archive(c.Request.Context(), uint(id))
+	if err != nil {
+		writePipelineError(c, err, "archivePipeline")
+		return
+	}
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// POST /api/pipelines/:id/restore
+func (h *Handler) RestorePipeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	pipeline, err := h.pipelineService.Restore(c.Request.Context(), uint(id))
+	if err != nil {
+		writePipelineError(c, err, "restorePipeline")
+		return
+	}
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// maxGeoUpload — предел размера файла с геометрией (трасса, границы)
+const maxGeoUpload = 20 << 20
+
+// readGeoUpload читает геометрию из multipart-поля file или из тела запроса.
+// Формат — параметр format, иначе расширение файла, иначе определяется по содержимому.
+func readGeoUpload(c *gin.Context) ([]byte, string, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGeoUpload)
+	format := strings.ToLower(c.Query("format"))
+
+	var src io.Reader = c.Request.Body
+	if header, err := c.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return nil, "", false
+		}
+		defer file.Close()
+		src = file
+		if format == "" {
+			switch strings.ToLower(filepath.Ext(header.Filename)) {
+			case ".kml":
+				format = geo.FormatKML
+			case ".geojson", ".json":
+				format = geo.FormatGeoJSON
+			}
+		}
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+			return nil, "", false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return nil, "", false
+	}
+	if len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return nil, "", false
+	}
+	return data, format, true
+}
+
+// PUT /api/pipelines/:id/route?start_km=0&format=geojson|kml — загрузка трассы (файл в поле file или тело запроса)
+func (h *Handler) SetPipelineRoute(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	startKm, err := strconv.ParseFloat(c.DefaultQuery("start_km", "0"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_km"})
+		return
+	}
+	data, format, ok := readGeoUpload(c)
+	if !ok {
+		return
+	}
+
+	res, err := h.pipelineService.SetRoute(c.Request.Context(), uint(id), data, format, startKm)
+	if err != nil {
+		writePipelineError(c, err, "setPipelineRoute")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GET /api/pipelines/:id/route — трасса в GeoJSON
+func (h *Handler) GetPipelineRoute(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	route, err := h.pipelineService.Route(c.Request.Context(), uint(id))
+	if err != nil {
+		writePipelineError(c, err, "getPipelineRoute")
+		return
+	}
+	c.JSON(http.StatusOK, route)
+}
+
+// GET /api/pipelines/:id/chainage?lat=51.1&lon=71.4 — пикет ближайшей к точке позиции на трассе
+func (h *Handler) LocateOnPipeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, errLon := strconv.ParseFloat(c.Query("lon"), 64)
+	if errLat != nil || errLon != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lon are required"})
+		return
+	}
+
+	chainage, err := h.pipelineService.Locate(c.Request.Context(), uint(id), lat, lon)
+	if err != nil {
+		writePipelineError(c, err, "locateOnPipeline")
+		return
+	}
+	c.JSON(http.StatusOK, chainage)
+}
+
+// GET /api/pipelines/:id/objects?km_min=120&km_max=160 — объекты по порядку вдоль трассы
+func (h *Handler) ListPipelineObjects(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	verr := &entities.ValidationError{}
+	km := queryRange(c, "km", verr)
+	if len(verr.Fields) > 0 {
+		writeValidationError(c, verr)
+		return
+	}
+
+	objects, err := h.pipelineService.ObjectsAlong(c.Request.Context(), uint(id), km)
+	if err != nil {
+		writePipelineError(c, err, "listPipelineObjects")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": objects})
+}
@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+)
+
+func writeRestrictionError(c *gin.Context, err error, op string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrRestrictionNotFound), errors.Is(err, repository.ErrPipelineNotFound),
+		errors.Is(err, repository.ErrObjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrRestrictionNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /api/restrictions?pipeline_id=1&object_id=2&active=true&page=1&limit=20
+func (h *Handler) ListRestrictions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	pipelineId, _ := strconv.Atoi(c.Query("pipeline_id"))
+	objectId, _ := strconv.Atoi(c.Query("object_id"))
+	active, _ := strconv.ParseBool(c.Query("active"))
+
+	filter := entities.RestrictionFilter{
+		PipelineId: uint(pipelineId),
+		ObjectId:   uint(objectId),
+		ActiveOnly: active,
+		Page:       page,
+		Limit:      limit,
+	}
+
+	restrictions, total, err := h.restrictionService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listRestrictions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": restrictions,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// GET /api/restrictions/:id
+func (h *Handler) GetRestriction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	restriction, err := h.restrictionService.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		writeRestrictionError(c, err, "getRestriction")
+		return
+	}
+	c.JSON(http.StatusOK, restriction)
+}
+
+// POST /api/restrictions {"pipeline_id": 1, "object_id": 2, "defect_id": 3, "max_pressure": 5.2, "reason": "...", "valid_until": "..."}
+func (h *Handler) ImposeRestriction(c *gin.Context) {
+	var req entities.RestrictionInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	restriction, err := h.restrictionService.Impose(c.Request.Context(), req)
+	if err != nil {
+		writeRestrictionError(c, err, "imposeRestriction")
+		return
+	}
+	c.JSON(http.StatusCreated, restriction)
+}
+
+// POST /api/restrictions/:id/lift {"reason": "..."}
+func (h *Handler) LiftRestriction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	restriction, err := h.restrictionService.Lift(c.Request.Context(), uint(id), req.Reason)
+	if err != nil {
+		writeRestrictionError(c, err, "liftRestriction")
+		return
+	}
+	c.JSON(http.StatusOK, restriction)
+}
+
+// GET /api/restrictions/segments — участки, которые сейчас работают на пониженном давлении
+func (h *Handler) ListRestrictedSegments(c *gin.Context) {
+	segments, err := h.restrictionService.RestrictedSegments(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "restrictedSegments"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": segments})
+}
+
+// GET /api/pipelines/:id/maop — проектное и фактически допустимое давление по участкам
+func (h *Handler) GetPipelineMaop(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	res, err := h.restrictionService.PipelineMaop(c.Request.Context(), uint(id))
+	if err != nil {
+		writeRestrictionError(c, err, "pipelineMaop")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// PUT /api/pipelines/:id/maop {"design_maop": 7.4}
+func (h *Handler) SetPipelineMaop(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req struct {
+		DesignMaop float64 `json:"design_maop"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	res, err := h.restrictionService.SetDesignMaop(c.Request.Context(), uint(id), req.DesignMaop)
+	if err != nil {
+		writeRestrictionError(c, err, "setPipelineMaop")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GET /api/objects/:id/maop
+func (h *Handler) GetObjectMaop(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	res, err := h.restrictionService.SegmentMaop(c.Request.Context(), uint(id))
+	if err != nil {
+		writeRestrictionError(c, err, "objectMaop")
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
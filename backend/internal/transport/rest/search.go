@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+)
+
+// searchPermissions — право, без которого тип не попадает в выдачу
+var searchPermissions = map[string]string{
+	entities.SearchDefect:   entities.PermDefectsRead,
+	entities.SearchObject:   entities.PermObjectsRead,
+	entities.SearchPipeline: entities.PermPipelinesRead,
+}
+
+// GET /api/search?q=коррозия&types=defect,object&limit=20 — строка глобального поиска.
+// Без types ищет по всем типам, которые пользователю разрешено смотреть.
+func (h *Handler) Search(c *gin.Context) {
+	principal, _ := principalFrom(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	requested := entities.SearchTypes
+	if raw := c.Query("types"); raw != "" {
+		requested = strings.Split(raw, ",")
+	}
+
+	types := make([]string, 0, len(requested))
+	for _, t := range requested {
+		t = strings.TrimSpace(t)
+		perm, known := searchPermissions[t]
+		if known {
+			allowed, err := h.accessService.Allowed(c.Request.Context(), principal, perm)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "permissions"})
+				return
+			}
+			if !allowed {
+				continue
+			}
+		}
+		// неизвестный тип пропускаем дальше — сервис вернет по нему ошибку валидации
+		types = append(types, t)
+	}
+
+	results, err := h.searchService.Search(c.Request.Context(), c.Query("q"), types, limit)
+	if err != nil {
+		var verr *entities.ValidationError
+		if errors.As(err, &verr) {
+			writeValidationError(c, verr)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "search"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": results, "types": types})
+}
@@ -0,0 +1,173 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rwrrioe/integrity/backend/internal/domain/entities"
+	"github.com/rwrrioe/integrity/backend/internal/repository"
+	"github.com/rwrrioe/integrity/backend/internal/service"
+)
+
+func writeWorkOrderError(c *gin.Context, err error, op string) {
+	var verr *entities.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(c, verr)
+	case errors.Is(err, repository.ErrWorkOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWorkOrderScope):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWorkOrderState), errors.Is(err, service.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": op})
+	}
+}
+
+// GET /api/work-orders?status=Assigned&priority=high&employee_id=1&defect_id=2&page=1&limit=20
+func (h *Handler) ListWorkOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	employeeId, _ := strconv.Atoi(c.Query("employee_id"))
+	defectId, _ := strconv.Atoi(c.Query("defect_id"))
+
+	filter := entities.WorkOrderFilter{
+		Status:     c.Query("status"),
+		Priority:   c.Query("priority"),
+		EmployeeId: uint(employeeId),
+		DefectId:   uint(defectId),
+		Page:       page,
+		Limit:      limit,
+	}
+
+	orders, total, err := h.workOrderService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "listWorkOrders"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": orders,
+		"meta": gin.H{"total": total, "page": page, "limit": limit},
+	})
+}
+
+// GET /api/work-orders/:id
+func (h *Handler) GetWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	order, err := h.workOrderService.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		writeWorkOrderError(c, err, "getWorkOrder")
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// POST /api/work-orders {"title": "...", "priority": "high", "method_id": 1, "defect_ids": [1, 2]}
+func (h *Handler) CreateWorkOrder(c *gin.Context) {
+	var in entities.WorkOrderInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	order, err := h.workOrderService.Create(c.Request.Context(), in)
+	if err != nil {
+		writeWorkOrderError(c, err, "createWorkOrder")
+		return
+	}
+	c.JSON(http.StatusCreated, order)
+}
+
+// POST /api/work-orders/:id/assign {"employee_ids": [1, 2], "planned_start": "...", "planned_finish": "..."}
+func (h *Handler) AssignWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var in entities.WorkOrderAssignment
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	order, err := h.workOrderService.Assign(c.Request.Context(), uint(id), in)
+	if err != nil {
+		writeWorkOrderError(c, err, "assignWorkOrder")
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// POST /api/work-orders/:id/start
+func (h *Handler) StartWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	order, err := h.workOrderService.Start(c.Request.Context(), uint(id))
+	if err != nil {
+		writeWorkOrderError(c, err, "startWorkOrder")
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// POST /api/work-orders/:id/complete {"completion_report": "..."}
+func (h *Handler) CompleteWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req struct {
+		CompletionReport string `json:"completion_report"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	order, err := h.workOrderService.Complete(c.Request.Context(), uint(id), req.CompletionReport)
+	if err != nil {
+		writeWorkOrderError(c, err, "completeWorkOrder")
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// POST /api/work-orders/:id/cancel {"reason": "..."}
+func (h *Handler) CancelWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	order, err := h.workOrderService.Cancel(c.Request.Context(), uint(id), req.Reason)
+	if err != nil {
+		writeWorkOrderError(c, err, "cancelWorkOrder")
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
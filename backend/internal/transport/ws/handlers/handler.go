@@ -3,6 +3,7 @@ package ws_handlers
 import (
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -13,6 +14,10 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
+// DefectTopicPrefix — пространство топиков карточек дефектов. Подписка на них идет только
+// через /api/defects/:id/live с проверкой доступа, открытый /ws такие топики не принимает.
+const DefectTopicPrefix = "defect:"
+
 type Handler struct {
 	ws *hub.WebSocketHub
 }
@@ -31,12 +36,34 @@ func (h *Handler) WebSocket(c *gin.Context) {
 		})
 		return
 	}
+	if strings.HasPrefix(taskID, DefectTopicPrefix) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "topic is not available",
+		})
+		return
+	}
 
+	h.Subscribe(c, taskID)
+}
+
+// Subscribe переводит запрос в WebSocket и держит подписку на топик, пока клиент не закроет соединение
+func (h *Handler) Subscribe(c *gin.Context, topic string) {
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Println("failed to upgrade")
 		return
 	}
 
-	h.ws.AddClient(taskID, conn)
+	h.ws.AddClient(topic, conn)
+	defer func() {
+		h.ws.RemoveConn(topic, conn)
+		conn.Close()
+	}()
+
+	// входящие сообщения не нужны, но читать надо, чтобы заметить закрытие соединения
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
 }
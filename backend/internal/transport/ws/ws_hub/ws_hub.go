@@ -1,43 +1,99 @@
 package ws_hub
 
 import (
+	"encoding/json"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// writeWait — сколько ждем запись в одно соединение; медленный клиент не должен задерживать остальных
+const writeWait = 10 * time.Second
+
+// WebSocketHub рассылает сообщения по топикам. На один топик может быть подписано несколько соединений:
+// задача импорта или отчета — обычно одно, карточка дефекта — все, кто ее сейчас открыл.
 type WebSocketHub struct {
 	mu      sync.Mutex
-	clients map[string]*websocket.Conn
+	clients map[string]map[*websocket.Conn]*client
+}
+
+// client — подписанное соединение. gorilla/websocket допускает только одного пишущего,
+// поэтому запись в соединение идет под его собственным мьютексом, а не под мьютексом хаба.
+type client struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
 }
 
 func NewWebSocketHub() *WebSocketHub {
-	return &WebSocketHub{clients: make(map[string]*websocket.Conn)}
+	return &WebSocketHub{clients: make(map[string]map[*websocket.Conn]*client)}
 }
 
 func (h *WebSocketHub) AddClient(id string, conn *websocket.Conn) {
 	h.mu.Lock()
-	h.clients[id] = conn
-	h.mu.Unlock()
+	defer h.mu.Unlock()
+
+	conns, ok := h.clients[id]
+	if !ok {
+		conns = make(map[*websocket.Conn]*client)
+		h.clients[id] = conns
+	}
+	conns[conn] = &client{conn: conn}
 }
 
+// Notify отправляет payload всем подписчикам топика. Список соединений копируется под мьютексом хаба,
+// а пишем уже без него: зависшее соединение не блокирует подписку и рассылку по другим топикам.
 func (h *WebSocketHub) Notify(id string, payload interface{}) {
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to encode WS message to %s: %v", id, err)
+		return
+	}
+
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	clients := make([]*client, 0, len(h.clients[id]))
+	for _, c := range h.clients[id] {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
 
-	if conn, ok := h.clients[id]; ok {
-		if err := conn.WriteJSON(payload); err != nil {
+	for _, c := range clients {
+		if err := c.write(msg); err != nil {
 			log.Printf("Failed to send WS message to %s: %v", id, err)
 
-			conn.Close()
-			delete(h.clients, id)
+			c.conn.Close()
+			h.RemoveConn(id, c.conn)
 		}
 	}
 }
 
+func (c *client) write(msg []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, msg)
+}
+
 func (h *WebSocketHub) RemoveClient(id string) {
 	h.mu.Lock()
 	delete(h.clients, id)
 	h.mu.Unlock()
 }
+
+// RemoveConn отписывает одно соединение, не трогая остальных подписчиков топика
+func (h *WebSocketHub) RemoveConn(id string, conn *websocket.Conn) {
+	h.mu.Lock()
+	h.remove(id, conn)
+	h.mu.Unlock()
+}
+
+func (h *WebSocketHub) remove(id string, conn *websocket.Conn) {
+	delete(h.clients[id], conn)
+	if len(h.clients[id]) == 0 {
+		delete(h.clients, id)
+	}
+}
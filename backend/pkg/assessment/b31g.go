@@ -0,0 +1,138 @@
+// Package assessment — оценка остаточной прочности трубы с дефектом потери металла
+// по ASME B31G (исходная методика) и Modified B31G (0.85dL).
+//
+// Единицы: размеры в мм, напряжения и давления в МПа.
+package assessment
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// MethodB31G — исходный B31G: параболическая форма дефекта, поток напряжений 1.1·SMYS
+	MethodB31G = "b31g"
+	// MethodModifiedB31G — Modified B31G: площадь 0.85·d·L, поток напряжений SMYS + 69 МПа
+	MethodModifiedB31G = "modified_b31g"
+)
+
+// Решение по дефекту
+const (
+	VerdictAccept  = "accept"
+	VerdictRepair  = "repair"
+	VerdictReplace = "replace"
+)
+
+const (
+	// DefaultDesignFactor — расчетный коэффициент для класса 1 по ASME B31.8
+	DefaultDesignFactor = 0.72
+	// maxDepthRatio — глубже 80% стенки методика неприменима, участок подлежит замене
+	maxDepthRatio = 0.8
+)
+
+var ErrInvalidInput = fmt.Errorf("invalid assessment input")
+
+var Methods = []string{MethodB31G, MethodModifiedB31G}
+
+type Pipe struct {
+	Diameter      float64 // наружный диаметр D, мм
+	WallThickness float64 // номинальная толщина стенки t, мм
+	SMYS          float64 // минимальный предел текучести, МПа
+	MAOP          float64 // максимальное допустимое рабочее давление, МПа
+	DesignFactor  float64 // F; 0 — DefaultDesignFactor
+}
+
+type Defect struct {
+	Depth  float64 // максимальная глубина d, мм
+	Length float64 // осевая длина L, мм
+}
+
+type Result struct {
+	Method                string
+	DepthRatio            float64 // d/t
+	FlowStress            float64 // МПа
+	FoliasFactor          float64 // M — коэффициент выпучивания (Folias)
+	FailurePressure       float64 // давление разрушения, МПа
+	SafePressure          float64 // безопасное давление = давление разрушения · F, МПа
+	EstimatedRepairFactor float64 // ERF = MAOP / безопасное давление; > 1 — дефект требует ремонта
+	Verdict               string
+}
+
+// Assess рассчитывает давление разрушения, безопасное давление, ERF и решение по дефекту
+func Assess(method string, pipe Pipe, defect Defect) (Result, error) {
+	if pipe.Diameter <= 0 || pipe.WallThickness <= 0 || pipe.SMYS <= 0 || pipe.MAOP <= 0 {
+		return Result{}, fmt.Errorf("%w: diameter, wall thickness, SMYS and MAOP must be positive", ErrInvalidInput)
+	}
+	if pipe.WallThickness >= pipe.Diameter/2 {
+		return Result{}, fmt.Errorf("%w: wall thickness must be less than the radius", ErrInvalidInput)
+	}
+	if defect.Depth < 0 || defect.Length < 0 {
+		return Result{}, fmt.Errorf("%w: defect depth and length must not be negative", ErrInvalidInput)
+	}
+	if defect.Depth >= pipe.WallThickness {
+		return Result{Method: method, DepthRatio: defect.Depth / pipe.WallThickness, Verdict: VerdictReplace}, nil
+	}
+
+	F := pipe.DesignFactor
+	if F <= 0 {
+		F = DefaultDesignFactor
+	}
+
+	D, t, d, L := pipe.Diameter, pipe.WallThickness, defect.Depth, defect.Length
+	ratio := d / t
+	z := L * L / (D * t)
+
+	var flow, M, hoop float64
+	switch method {
+	case MethodB31G:
+		flow = 1.1 * pipe.SMYS
+		if z <= 20 {
+			M = math.Sqrt(1 + 0.8*z)
+			hoop = flow * (1 - 2.0/3.0*ratio) / (1 - 2.0/3.0*ratio/M)
+		} else {
+			// длинный дефект: сечение считается равномерно утоненным
+			M = math.Inf(1)
+			hoop = flow * (1 - ratio)
+		}
+	case MethodModifiedB31G:
+		flow = pipe.SMYS + 69
+		if z <= 50 {
+			M = math.Sqrt(1 + 0.6275*z - 0.003375*z*z)
+		} else {
+			M = 0.032*z + 3.3
+		}
+		hoop = flow * (1 - 0.85*ratio) / (1 - 0.85*ratio/M)
+	default:
+		return Result{}, fmt.Errorf("%w: unknown method %q", ErrInvalidInput, method)
+	}
+
+	failure := 2 * hoop * t / D
+	safe := failure * F
+	res := Result{
+		Method:                method,
+		DepthRatio:            round(ratio, 4),
+		FlowStress:            round(flow, 2),
+		FoliasFactor:          round(M, 4),
+		FailurePressure:       round(failure, 3),
+		SafePressure:          round(safe, 3),
+		EstimatedRepairFactor: round(pipe.MAOP/safe, 3),
+	}
+	if math.IsInf(M, 1) {
+		res.FoliasFactor = 0
+	}
+
+	switch {
+	case ratio > maxDepthRatio || failure <= pipe.MAOP:
+		res.Verdict = VerdictReplace
+	case res.EstimatedRepairFactor > 1:
+		res.Verdict = VerdictRepair
+	default:
+		res.Verdict = VerdictAccept
+	}
+	return res, nil
+}
+
+func round(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
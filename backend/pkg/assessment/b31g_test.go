@@ -0,0 +1,149 @@
+package assessment
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// Пример из практики B31G: труба 30" × 0.375" X52 (762 × 9.525 мм, SMYS 52 ksi = 359 МПа),
+// коррозия 0.15" × 6" (3.81 × 152.4 мм, d/t = 0.4, z = 3.2).
+// Ожидаемые давления разрушения в psi: B31G — 1221, Modified B31G — 1274 (8.42 и 8.79 МПа).
+var referencePipe = Pipe{Diameter: 762, WallThickness: 9.525, SMYS: 359, MAOP: 5}
+
+func TestAssessReferenceValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		defect      Defect
+		flowStress  float64
+		folias      float64
+		failure     float64
+		safe        float64
+		verdict     string
+		infiniteLen bool
+	}{
+		{
+			name:       "b31g short defect",
+			method:     MethodB31G,
+			defect:     Defect{Depth: 3.81, Length: 152.4},
+			flowStress: 394.9,
+			folias:     1.8868,
+			failure:    8.431,
+			safe:       6.071,
+			verdict:    VerdictAccept,
+		},
+		{
+			name:       "modified b31g short defect",
+			method:     MethodModifiedB31G,
+			defect:     Defect{Depth: 3.81, Length: 152.4},
+			flowStress: 428,
+			folias:     1.7244,
+			failure:    8.796,
+			safe:       6.333,
+			verdict:    VerdictAccept,
+		},
+		{
+			// z > 20: исходный B31G считает сечение равномерно утоненным, P = 2·1.1·SMYS·(1 − d/t)·t/D
+			name:        "b31g long defect",
+			method:      MethodB31G,
+			defect:      Defect{Depth: 3.81, Length: 1000},
+			flowStress:  394.9,
+			failure:     5.924,
+			safe:        4.265,
+			verdict:     VerdictRepair,
+			infiniteLen: true,
+		},
+		{
+			// z > 50: M = 0.032·z + 3.3
+			name:       "modified b31g long defect",
+			method:     MethodModifiedB31G,
+			defect:     Defect{Depth: 3.81, Length: 1000},
+			flowStress: 428,
+			folias:     7.7089,
+			failure:    7.388,
+			safe:       5.319,
+			verdict:    VerdictAccept,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Assess(tt.method, referencePipe, tt.defect)
+			if err != nil {
+				t.Fatalf("Assess: %v", err)
+			}
+			approx(t, "flow stress", res.FlowStress, tt.flowStress, 0.01)
+			if tt.infiniteLen {
+				if res.FoliasFactor != 0 {
+					t.Errorf("folias factor = %v, want 0 for a long defect", res.FoliasFactor)
+				}
+			} else {
+				approx(t, "folias factor", res.FoliasFactor, tt.folias, 0.0001)
+			}
+			approx(t, "failure pressure", res.FailurePressure, tt.failure, 0.001)
+			approx(t, "safe pressure", res.SafePressure, tt.safe, 0.001)
+			approx(t, "ERF", res.EstimatedRepairFactor, round(referencePipe.MAOP/tt.safe, 3), 0.002)
+			approx(t, "depth ratio", res.DepthRatio, 0.4, 0.0001)
+			if res.Verdict != tt.verdict {
+				t.Errorf("verdict = %s, want %s", res.Verdict, tt.verdict)
+			}
+		})
+	}
+}
+
+func TestAssessVerdicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		pipe    Pipe
+		defect  Defect
+		verdict string
+	}{
+		{"no defect", referencePipe, Defect{}, VerdictAccept},
+		{"through wall", referencePipe, Defect{Depth: 9.525, Length: 10}, VerdictReplace},
+		{"deeper than 80%", referencePipe, Defect{Depth: 8, Length: 5}, VerdictReplace},
+		{"safe pressure below MAOP", Pipe{Diameter: 762, WallThickness: 9.525, SMYS: 359, MAOP: 7}, Defect{Depth: 3.81, Length: 152.4}, VerdictRepair},
+		{"failure pressure below MAOP", Pipe{Diameter: 762, WallThickness: 9.525, SMYS: 359, MAOP: 9}, Defect{Depth: 3.81, Length: 152.4}, VerdictReplace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Assess(MethodModifiedB31G, tt.pipe, tt.defect)
+			if err != nil {
+				t.Fatalf("Assess: %v", err)
+			}
+			if res.Verdict != tt.verdict {
+				t.Errorf("verdict = %s, want %s", res.Verdict, tt.verdict)
+			}
+		})
+	}
+}
+
+func TestAssessInvalidInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		pipe   Pipe
+		defect Defect
+	}{
+		{"unknown method", "rstreng", referencePipe, Defect{Depth: 1, Length: 10}},
+		{"zero diameter", MethodB31G, Pipe{WallThickness: 9.525, SMYS: 359, MAOP: 5}, Defect{}},
+		{"wall thicker than radius", MethodB31G, Pipe{Diameter: 10, WallThickness: 5, SMYS: 359, MAOP: 5}, Defect{}},
+		{"negative depth", MethodB31G, referencePipe, Defect{Depth: -1, Length: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Assess(tt.method, tt.pipe, tt.defect); !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("err = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
+
+func approx(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %v, want %v ± %v", name, got, want, tolerance)
+	}
+}
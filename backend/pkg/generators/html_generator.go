@@ -266,6 +266,28 @@ const singleDefectTemplate = `
         <img src="data:image/png;base64,{{.MapImageBase64}}" />
         {{end}}
 
+        {{with .Assessment}}
+        <h3>Remaining Strength ({{.Method}})</h3>
+        <div class="metrics">
+            <div class="metric-box">
+                <div>Failure Pressure</div>
+                <div class="metric-val">{{printf "%.2f" .FailurePressure}} MPa</div>
+            </div>
+            <div class="metric-box">
+                <div>Safe Pressure</div>
+                <div class="metric-val">{{printf "%.2f" .SafePressure}} MPa</div>
+            </div>
+            <div class="metric-box">
+                <div>ERF</div>
+                <div class="metric-val">{{printf "%.2f" .EstimatedRepairFactor}}</div>
+            </div>
+            <div class="metric-box">
+                <div>Verdict</div>
+                <div class="metric-val">{{.Verdict}}</div>
+            </div>
+        </div>
+        {{end}}
+
         <h3>AI Solution & Assessment</h3>
         <div class="ai-section">
             {{.LlmSolution}}
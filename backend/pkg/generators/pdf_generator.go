@@ -3,6 +3,7 @@ package generators
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/johnfercher/maroto/pkg/color"
@@ -141,6 +142,31 @@ func (g *PDFGenerator) GenerateSingleDefect(data *entities.SingleDefectReport) (
 		})
 	}
 
+	if a := data.Assessment; a != nil {
+		m.Row(10, func() {
+			m.Col(12, func() {
+				m.Text(fmt.Sprintf("Remaining Strength (%s)", a.Method), props.Text{Size: 12, Style: consts.Bold, Top: 5})
+			})
+		})
+
+		m.Row(20, func() {
+			m.Col(3, func() {
+				buildMetricCard(m, "Failure Pressure", fmt.Sprintf("%.2f MPa", a.FailurePressure))
+			})
+			m.Col(3, func() {
+				buildMetricCard(m, "Safe Pressure", fmt.Sprintf("%.2f MPa", a.SafePressure))
+			})
+			m.Col(3, func() {
+				buildMetricCard(m, "ERF", fmt.Sprintf("%.2f", a.EstimatedRepairFactor))
+			})
+			m.Col(3, func() {
+				buildMetricCard(m, "Verdict", strings.ToUpper(a.Verdict))
+			})
+		})
+
+		m.Line(1.0)
+	}
+
 	m.Row(10, func() {
 		m.Col(12, func() {
 			m.Text("AI Technical Assessment & Solution", props.Text{Size: 12, Style: consts.Bold, Top: 5})
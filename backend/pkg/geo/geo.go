@@ -0,0 +1,106 @@
+// Package geo — разбор геометрий трассы и границ из GeoJSON и KML в WKT для PostGIS.
+//
+// Координаты — WGS84 (EPSG:4326), порядок как в GeoJSON: долгота, широта.
+package geo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoGeometry      = errors.New("no suitable geometry found")
+	ErrInvalidGeometry = errors.New("invalid geometry")
+)
+
+// Форматы исходного файла
+const (
+	FormatGeoJSON = "geojson"
+	FormatKML     = "kml"
+)
+
+type Point struct {
+	Lon float64
+	Lat float64
+}
+
+// Line — ломаная (LineString)
+type Line []Point
+
+// DetectFormat определяет формат по содержимому: KML — это XML
+func DetectFormat(data []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))), []byte("<")) {
+		return FormatKML
+	}
+	return FormatGeoJSON
+}
+
+// ParseLines достает все линии из файла; format пустой — определить по содержимому
+func ParseLines(data []byte, format string) ([]Line, error) {
+	var (
+		lines []Line
+		err   error
+	)
+	switch resolve(data, format) {
+	case FormatKML:
+		lines, err = kmlLines(data)
+	case FormatGeoJSON:
+		lines, err = geoJSONLines(data)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidGeometry, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, ErrNoGeometry
+	}
+	for _, l := range lines {
+		if len(l) < 2 {
+			return nil, fmt.Errorf("%w: line must have at least 2 points", ErrInvalidGeometry)
+		}
+		if err := checkPoints(l); err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+func resolve(data []byte, format string) string {
+	if format == "" {
+		return DetectFormat(data)
+	}
+	return strings.ToLower(format)
+}
+
+func checkPoints(points []Point) error {
+	for _, p := range points {
+		if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+			return fmt.Errorf("%w: coordinate %g,%g out of range", ErrInvalidGeometry, p.Lon, p.Lat)
+		}
+	}
+	return nil
+}
+
+// LinesWKT — LINESTRING для одной линии и MULTILINESTRING для нескольких
+func LinesWKT(lines []Line) string {
+	if len(lines) == 1 {
+		return "LINESTRING" + coordsWKT(lines[0])
+	}
+	parts := make([]string, len(lines))
+	for i, l := range lines {
+		parts[i] = coordsWKT(l)
+	}
+	return "MULTILINESTRING(" + strings.Join(parts, ", ") + ")"
+}
+
+func coordsWKT(points []Point) string {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = strconv.FormatFloat(p.Lon, 'f', -1, 64) + " " + strconv.FormatFloat(p.Lat, 'f', -1, 64)
+	}
+	return "(" + strings.Join(coords, ", ") + ")"
+}
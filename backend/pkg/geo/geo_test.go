@@ -0,0 +1,151 @@
+package geo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		want   []Line
+	}{
+		{
+			name: "geojson linestring",
+			data: `{"type":"LineString","coordinates":[[71.4,51.1],[71.5,51.2,300]]}`,
+			want: []Line{{{71.4, 51.1}, {71.5, 51.2}}},
+		},
+		{
+			name: "geojson feature collection",
+			data: `{"type":"FeatureCollection","features":[
+				{"type":"Feature","properties":{},"geometry":{"type":"MultiLineString","coordinates":[[[1,2],[3,4]],[[5,6],[7,8]]]}},
+				{"type":"Feature","properties":{},"geometry":null},
+				{"type":"Feature","properties":{},"geometry":{"type":"Point","coordinates":[1,2]}}
+			]}`,
+			want: []Line{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}},
+		},
+		{
+			name: "kml detected by content",
+			data: "\xef\xbb\xbf<?xml version=\"1.0\"?><kml><Document><Placemark><LineString>" +
+				"<coordinates>71.4,51.1,0 71.5,51.2,0\n 71.6,51.3</coordinates></LineString></Placemark></Document></kml>",
+			want: []Line{{{71.4, 51.1}, {71.5, 51.2}, {71.6, 51.3}}},
+		},
+		{
+			name:   "kml ignores polygons",
+			format: FormatKML,
+			data: `<kml><Placemark><Polygon><outerBoundaryIs><LinearRing><coordinates>0,0 1,0 1,1 0,0</coordinates></LinearRing></outerBoundaryIs></Polygon></Placemark>
+				<Placemark><LineString><coordinates>1,2 3,4</coordinates></LineString></Placemark></kml>`,
+			want: []Line{{{1, 2}, {3, 4}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLines([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("ParseLines: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLinesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		want   error
+	}{
+		{"no lines", `{"type":"Point","coordinates":[1,2]}`, "", ErrNoGeometry},
+		{"single point line", `{"type":"LineString","coordinates":[[1,2]]}`, "", ErrInvalidGeometry},
+		{"latitude out of range", `{"type":"LineString","coordinates":[[1,2],[3,95]]}`, "", ErrInvalidGeometry},
+		{"missing type", `{"coordinates":[[1,2],[3,4]]}`, "", ErrInvalidGeometry},
+		{"broken json", `{"type":`, "", ErrInvalidGeometry},
+		{"bad kml coordinate", `<kml><LineString><coordinates>1,2 x,4</coordinates></LineString></kml>`, "", ErrInvalidGeometry},
+		{"unknown format", `{}`, "shp", ErrInvalidGeometry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLines([]byte(tt.data), tt.format); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesWKT(t *testing.T) {
+	one := []Line{{{71.4, 51.1}, {71.5, 51.25}}}
+	if got, want := LinesWKT(one), "LINESTRING(71.4 51.1, 71.5 51.25)"; got != want {
+		t.Errorf("LinesWKT = %q, want %q", got, want)
+	}
+
+	two := []Line{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}}
+	if got, want := LinesWKT(two), "MULTILINESTRING((1 2, 3 4), (5 6, 7 8))"; got != want {
+		t.Errorf("LinesWKT = %q, want %q", got, want)
+	}
+}
+
+func TestParseFeatures(t *testing.T) {
+	data := `{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"code":"  ALM ","name":"Алматы"},
+		 "geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1]]]}},
+		{"type":"Feature","properties":{"code":750000000},
+		 "geometry":{"type":"MultiPolygon","coordinates":[[[[0,0],[2,0],[2,2],[0,0]]],[[[5,5],[6,5],[6,6],[5,5]]]]}},
+		{"type":"Feature","properties":{"code":"LINE"},"geometry":{"type":"LineString","coordinates":[[0,0],[1,1]]}}
+	]}`
+
+	features, err := ParseFeatures([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseFeatures: %v", err)
+	}
+	if len(features) != 2 {
+		t.Fatalf("features = %d, want 2 (line is skipped)", len(features))
+	}
+
+	if got := features[0].Prop("code"); got != "ALM" {
+		t.Errorf("code = %q, want ALM", got)
+	}
+	if got := features[1].Prop("kato", "code"); got != "750000000" {
+		t.Errorf("numeric code = %q, want 750000000", got)
+	}
+
+	// незамкнутое кольцо замыкается
+	ring := features[0].Polygons[0][0]
+	if len(ring) != 4 || ring[0] != ring[3] {
+		t.Errorf("ring = %v, want closed ring of 4 points", ring)
+	}
+	if len(features[1].Polygons) != 2 {
+		t.Errorf("multipolygon parts = %d, want 2", len(features[1].Polygons))
+	}
+
+	wkt := PolygonsWKT(features[0].Polygons)
+	if want := "MULTIPOLYGON(((0 0, 1 0, 1 1, 0 0)))"; wkt != want {
+		t.Errorf("PolygonsWKT = %q, want %q", wkt, want)
+	}
+}
+
+func TestParseFeaturesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"degenerate ring", `{"type":"Polygon","coordinates":[[[0,0],[1,1],[0,0]]]}`},
+		{"longitude out of range", `{"type":"Polygon","coordinates":[[[0,0],[181,0],[1,1],[0,0]]]}`},
+		{"broken json", `[`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFeatures([]byte(tt.data)); !errors.Is(err, ErrInvalidGeometry) {
+				t.Errorf("err = %v, want ErrInvalidGeometry", err)
+			}
+		})
+	}
+}
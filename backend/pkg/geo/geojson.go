@@ -0,0 +1,91 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// geoJSON — общая форма для FeatureCollection, Feature, GeometryCollection и простых геометрий
+type geoJSON struct {
+	Type        string            `json:"type"`
+	Features    []json.RawMessage `json:"features"`
+	Geometry    json.RawMessage   `json:"geometry"`
+	Geometries  []json.RawMessage `json:"geometries"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+}
+
+// walkGeoJSON обходит документ и вызывает fn для каждой простой геометрии
+func walkGeoJSON(data []byte, fn func(kind string, coords json.RawMessage) error) error {
+	var doc geoJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+	}
+
+	switch doc.Type {
+	case "FeatureCollection":
+		for _, f := range doc.Features {
+			if err := walkGeoJSON(f, fn); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if len(doc.Geometry) == 0 || string(doc.Geometry) == "null" {
+			return nil
+		}
+		return walkGeoJSON(doc.Geometry, fn)
+	case "GeometryCollection":
+		for _, g := range doc.Geometries {
+			if err := walkGeoJSON(g, fn); err != nil {
+				return err
+			}
+		}
+	case "":
+		return fmt.Errorf("%w: missing type", ErrInvalidGeometry)
+	default:
+		return fn(doc.Type, doc.Coordinates)
+	}
+	return nil
+}
+
+func geoJSONLines(data []byte) ([]Line, error) {
+	var lines []Line
+	err := walkGeoJSON(data, func(kind string, coords json.RawMessage) error {
+		switch kind {
+		case "LineString":
+			var raw [][]float64
+			if err := json.Unmarshal(coords, &raw); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+			}
+			line, err := toPoints(raw)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, line)
+		case "MultiLineString":
+			var raw [][][]float64
+			if err := json.Unmarshal(coords, &raw); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+			}
+			for _, r := range raw {
+				line, err := toPoints(r)
+				if err != nil {
+					return err
+				}
+				lines = append(lines, line)
+			}
+		}
+		return nil
+	})
+	return lines, err
+}
+
+func toPoints(raw [][]float64) ([]Point, error) {
+	points := make([]Point, 0, len(raw))
+	for _, c := range raw {
+		if len(c) < 2 {
+			return nil, fmt.Errorf("%w: position must have lon and lat", ErrInvalidGeometry)
+		}
+		points = append(points, Point{Lon: c[0], Lat: c[1]})
+	}
+	return points, nil
+}
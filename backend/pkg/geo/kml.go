@@ -0,0 +1,76 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// kmlCoordinates вызывает fn для содержимого каждого <coordinates> внутри элемента parent;
+// path — путь элементов от корня документа
+func kmlCoordinates(data []byte, parent string, fn func(path []string, text string) error) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var path []string
+	var text strings.Builder
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if t.Name.Local == "coordinates" && len(path) >= 2 && path[len(path)-2] == parent {
+				if err := fn(path, text.String()); err != nil {
+					return err
+				}
+			}
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+	}
+}
+
+// parseKMLPoints разбирает "lon,lat[,alt] lon,lat[,alt] ..."
+func parseKMLPoints(text string) ([]Point, error) {
+	var points []Point
+	for _, tuple := range strings.Fields(text) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("%w: bad coordinate %q", ErrInvalidGeometry, tuple)
+		}
+		lon, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("%w: bad coordinate %q", ErrInvalidGeometry, tuple)
+		}
+		points = append(points, Point{Lon: lon, Lat: lat})
+	}
+	return points, nil
+}
+
+func kmlLines(data []byte) ([]Line, error) {
+	var lines []Line
+	err := kmlCoordinates(data, "LineString", func(_ []string, text string) error {
+		points, err := parseKMLPoints(text)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, points)
+		return nil
+	})
+	return lines, err
+}
@@ -0,0 +1,141 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Polygon — внешнее кольцо и дырки; кольца замкнуты
+type Polygon []Line
+
+// Feature — объект GeoJSON с его свойствами и полигонами (Polygon и MultiPolygon складываются вместе)
+type Feature struct {
+	Properties map[string]interface{}
+	Polygons   []Polygon
+}
+
+// Prop — строковое свойство объекта; числа (коды в некоторых выгрузках) тоже приводятся к строке
+func (f Feature) Prop(keys ...string) string {
+	for _, key := range keys {
+		switch v := f.Properties[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// ParseFeatures достает полигональные объекты из GeoJSON. Объекты без полигонов пропускаются;
+// голая геометрия без Feature возвращается как один объект без свойств.
+func ParseFeatures(data []byte) ([]Feature, error) {
+	var doc geoJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+	}
+
+	raw := []json.RawMessage{data}
+	if doc.Type == "FeatureCollection" {
+		raw = doc.Features
+	}
+
+	var features []Feature
+	for _, r := range raw {
+		var feature struct {
+			Type       string                 `json:"type"`
+			Properties map[string]interface{} `json:"properties"`
+			Geometry   json.RawMessage        `json:"geometry"`
+		}
+		if err := json.Unmarshal(r, &feature); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+		geometry := r
+		if feature.Type == "Feature" {
+			if len(feature.Geometry) == 0 || string(feature.Geometry) == "null" {
+				continue
+			}
+			geometry = feature.Geometry
+		}
+
+		polygons, err := geoJSONPolygons(geometry)
+		if err != nil {
+			return nil, err
+		}
+		if len(polygons) > 0 {
+			features = append(features, Feature{Properties: feature.Properties, Polygons: polygons})
+		}
+	}
+	if len(features) == 0 {
+		return nil, ErrNoGeometry
+	}
+	return features, nil
+}
+
+func geoJSONPolygons(data []byte) ([]Polygon, error) {
+	var polygons []Polygon
+	err := walkGeoJSON(data, func(kind string, coords json.RawMessage) error {
+		var raw [][][][]float64
+		switch kind {
+		case "Polygon":
+			var one [][][]float64
+			if err := json.Unmarshal(coords, &one); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+			}
+			raw = [][][][]float64{one}
+		case "MultiPolygon":
+			if err := json.Unmarshal(coords, &raw); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+			}
+		default:
+			return nil
+		}
+
+		for _, rings := range raw {
+			polygon := make(Polygon, 0, len(rings))
+			for _, r := range rings {
+				ring, err := toPoints(r)
+				if err != nil {
+					return err
+				}
+				if ring, err = closeRing(ring); err != nil {
+					return err
+				}
+				polygon = append(polygon, ring)
+			}
+			if len(polygon) > 0 {
+				polygons = append(polygons, polygon)
+			}
+		}
+		return nil
+	})
+	return polygons, err
+}
+
+// closeRing замыкает кольцо, если выгрузка этого не сделала, и проверяет координаты
+func closeRing(ring Line) (Line, error) {
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		ring = append(ring, ring[0])
+	}
+	if len(ring) < 4 {
+		return nil, fmt.Errorf("%w: polygon ring must have at least 3 distinct points", ErrInvalidGeometry)
+	}
+	return ring, checkPoints(ring)
+}
+
+// PolygonsWKT — MULTIPOLYGON из всех полигонов
+func PolygonsWKT(polygons []Polygon) string {
+	parts := make([]string, len(polygons))
+	for i, p := range polygons {
+		rings := make([]string, len(p))
+		for j, r := range p {
+			rings[j] = coordsWKT(r)
+		}
+		parts[i] = "(" + strings.Join(rings, ", ") + ")"
+	}
+	return "MULTIPOLYGON(" + strings.Join(parts, ", ") + ")"
+}